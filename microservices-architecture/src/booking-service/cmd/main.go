@@ -3,84 +3,250 @@ package main
 import (
 	"booking-service/config"
 	"booking-service/internal/client"
+	"booking-service/internal/grpcapi"
 	"booking-service/internal/handler"
+	"booking-service/internal/metrics"
+	"booking-service/internal/middleware"
 	"booking-service/internal/repository"
 	"booking-service/internal/service"
 	"booking-service/migrations"
-	"log"
+	"context"
+	"log/slog"
+	"net"
+	"os"
+	"time"
+
+	"platform/eventbus"
+	"platform/health"
+	"platform/logging"
+	"platform/tracing"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
-	"github.com/gofiber/fiber/v2/middleware/logger"
 )
 
 func main() {
+	// Log JSON lines at LOG_LEVEL
+	logging.Setup("booking-service")
+
+	ctx := context.Background()
+
+	// Trace requests, queries and calls to OTEL_TRACES_EXPORTER (default none)
+	shutdownTracing, err := tracing.Setup(ctx, "booking-service")
+	if err != nil {
+		logging.Fatal("Failed to set up tracing", "error", err)
+	}
+	defer shutdownTracing(context.Background())
+
 	// Connect to database
 	config.ConnectDatabase()
 
 	// Run migrations
 	migrations.RunMigrations(config.DB)
 
+	if err := config.DB.Use(tracing.GormPlugin()); err != nil {
+		logging.Fatal("Failed to trace database", "error", err)
+	}
+
+	// Expose metrics on /metrics unless METRICS_ENABLED=false
+	metricsEnabled := metrics.Enabled()
+	if metricsEnabled {
+		if err := config.DB.Use(metrics.GormPlugin()); err != nil {
+			logging.Fatal("Failed to instrument database", "error", err)
+		}
+	}
+
 	// Initialize clients
-	userClient := client.NewUserClient()
-	paymentClient := client.NewPaymentClient()
-	webhookClient := client.NewWebhookClient()
+	transport := client.NewTransport(client.LoadTransportConfig())
+	userAPI := client.NewSDKClient(transport)
+	serviceTokens := client.NewServiceTokenSource(userAPI)
+	userClient, err := client.NewUserClient(transport, serviceTokens)
+	if err != nil {
+		logging.Fatal("Failed to create user service client", "error", err)
+	}
+	paymentClient, err := client.NewPaymentClient(transport, serviceTokens)
+	if err != nil {
+		logging.Fatal("Failed to create payment service client", "error", err)
+	}
 
 	// Initialize repositories
 	bookingRepo := repository.NewBookingRepository(config.DB)
 	eventRepo := repository.NewEventRepository(config.DB)
 	ticketRepo := repository.NewTicketRepository(config.DB)
+	sagaRepo := repository.NewCheckoutSagaRepository(config.DB)
+	deliveryRepo := repository.NewOutboundDeliveryRepository(config.DB)
+	processedRepo := repository.NewProcessedMessageRepository(config.DB)
+	if metricsEnabled {
+		metrics.RegisterTicketQuota(ticketRepo)
+	}
+
+	// Connect to the event bus
+	bus, err := eventbus.New(ctx, eventbus.LoadConfig(), config.ConnectEventBusDatabase())
+	if err != nil {
+		logging.Fatal("Failed to connect to event bus", "error", err)
+	}
+	defer bus.Close()
 
 	// Initialize services
-	bookingService := service.NewBookingService(config.DB, bookingRepo, ticketRepo, eventRepo, userClient, paymentClient, webhookClient)
+	deliveryConfig := service.LoadDeliveryConfig()
+	deliveryService := service.NewDeliveryService(deliveryRepo, bus, deliveryConfig)
+	bookingService := service.NewBookingService(config.DB, bookingRepo, ticketRepo, eventRepo, processedRepo, userClient, paymentClient, deliveryService)
+	checkoutConfig := service.LoadCheckoutConfig()
+	checkoutService := service.NewCheckoutService(config.DB, bookingRepo, ticketRepo, eventRepo, sagaRepo, processedRepo, paymentClient, deliveryService, checkoutConfig)
 
 	// Initialize handlers
-	bookingHandler := handler.NewBookingHandler(bookingService, userClient)
+	serviceTokenVerifier := middleware.NewServiceTokenVerifier(userAPI)
+	bookingServer := grpcapi.NewBookingServer(bookingService)
+	bookingHandler := handler.NewBookingHandler(bookingService, checkoutService)
+	checkoutHandler := handler.NewCheckoutHandler(checkoutService)
 	eventHandler := handler.NewEventHandler(eventRepo)
 	ticketHandler := handler.NewTicketHandler(ticketRepo)
+	paymentEventHandler := handler.NewPaymentEventHandler(bookingService, checkoutService)
+	deliveryHandler := handler.NewDeliveryHandler(deliveryService)
+
+	// Confirm or cancel bookings as payments settle
+	if err := paymentEventHandler.Subscribe(bus); err != nil {
+		logging.Fatal("Failed to subscribe to payment events", "error", err)
+	}
+
+	// Retry failed checkout steps, expire unpaid bookings and pick up
+	// checkouts left behind by crashed instances
+	go func() {
+		ticker := time.NewTicker(checkoutConfig.ResumeInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if _, err := checkoutService.ResumeDue(ctx); err != nil {
+				slog.Error("Failed to resume checkouts", "error", err)
+			}
+			// Bookings made before checkouts ran as sagas expire on their own
+			if _, err := bookingService.ExpireBookings(ctx); err != nil {
+				slog.Error("Failed to expire bookings", "error", err)
+			}
+		}
+	}()
+
+	// Re-send events the event bus did not accept
+	go func() {
+		ticker := time.NewTicker(deliveryConfig.RetryInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if _, err := deliveryService.RetryDue(ctx); err != nil {
+				slog.Error("Failed to retry deliveries", "error", err)
+			}
+		}
+	}()
+
+	// Readiness checks the database and, with HEALTH_CHECK_DOWNSTREAM=true,
+	// that the services called can be reached
+	healthConfig := health.LoadConfig()
+	checker := health.NewChecker("booking-service", healthConfig)
+	checker.Add("database", health.Database(config.DB, healthConfig.PoolSaturation))
+	checker.AddDownstream("user-service", health.Reachable(client.UserServiceAddr()))
+	checker.AddDownstream("payment-service", health.Reachable(client.PaymentServiceAddr()))
 
 	// Initialize Fiber app
 	app := fiber.New(fiber.Config{
-		AppName: "Booking Service",
+		AppName:               "Booking Service",
+		DisableStartupMessage: true, // "Server starting" is logged instead
+		// Requests arrive through the gateway, which passes the client
+		// address on in X-Forwarded-For
+		ProxyHeader:             fiber.HeaderXForwardedFor,
+		EnableTrustedProxyCheck: true,
+		TrustedProxies:          config.TrustedProxies(),
+		EnableIPValidation:      true,
 	})
 
 	// Middleware
-	app.Use(logger.New())
+	app.Use(middleware.RequestID())
+	app.Use(tracing.HTTP())
+	app.Use(middleware.AccessLog())
+	if metricsEnabled {
+		app.Use(metrics.HTTP())
+		app.Get("/metrics", metrics.Handler())
+	}
 	app.Use(cors.New())
+	app.Use(middleware.Deadline("REQUEST_TIMEOUT", 10*time.Second))
 
 	// Routes
 	api := app.Group("/api/v1")
 
 	// Event routes
-	events := api.Group("/events")
+	events := api.Group("/events", middleware.OptionalAPIKey(userClient, "events:read"))
 	events.Get("/", eventHandler.GetAllEvents)
 	events.Get("/:id", eventHandler.GetEventByID)
 	events.Get("/:id/tickets", ticketHandler.GetTicketsByEventID)
 
 	// Ticket routes
-	tickets := api.Group("/tickets")
+	tickets := api.Group("/tickets", middleware.OptionalAPIKey(userClient, "events:read"))
 	tickets.Get("/:id", ticketHandler.GetTicketByID)
 
 	// Booking routes
 	bookings := api.Group("/bookings")
-	bookings.Post("/", bookingHandler.CreateBooking)
+	bookings.Post("/",
+		middleware.Deadline("REQUEST_TIMEOUT_CREATE_BOOKING", 15*time.Second),
+		middleware.Authenticate(userClient, "bookings:write"),
+		bookingHandler.CreateBooking,
+	)
 	bookings.Get("/", bookingHandler.GetAllBookings)
 	bookings.Get("/:id", bookingHandler.GetBookingByID)
-	bookings.Put("/:id/status", bookingHandler.UpdateBookingStatus)
-	bookings.Post("/webhook/payment", bookingHandler.HandlePaymentWebhook)
-
-	// Health check
-	app.Get("/health", func(c *fiber.Ctx) error {
-		return c.JSON(fiber.Map{
-			"status":  "ok",
-			"service": "booking-service",
-		})
+	bookings.Post("/:id/checkout",
+		middleware.Deadline("REQUEST_TIMEOUT_CHECKOUT", 15*time.Second),
+		middleware.Authenticate(userClient, "bookings:write"),
+		checkoutHandler.Checkout,
+	)
+
+	// Internal routes, only callable by other services
+	bookings.Put("/:id/status",
+		middleware.RequireServiceToken(serviceTokenVerifier, "bookings:status:write"),
+		bookingHandler.UpdateBookingStatus,
+	)
+
+	// Admin routes
+	admin := api.Group("/admin", middleware.Authenticate(userClient, ""), middleware.RequireRole("admin"))
+	admin.Get("/sagas", checkoutHandler.GetSagas)
+	admin.Get("/sagas/:id", checkoutHandler.GetSaga)
+	admin.Get("/deliveries", deliveryHandler.GetDeliveries)
+	admin.Post("/deliveries/replay", deliveryHandler.ReplayDeliveries)
+	admin.Post("/deliveries/:id/replay", deliveryHandler.ReplayDelivery)
+
+	// Probes: /livez while the process is up, /readyz while its dependencies
+	// are usable. /health is kept for existing callers.
+	app.Get("/livez", checker.Livez)
+	app.Get("/readyz", checker.Readyz)
+	app.Get("/health", checker.Readyz)
+
+	// Start the internal gRPC API on its own port
+	grpcPort := os.Getenv("GRPC_PORT")
+	if grpcPort == "" {
+		grpcPort = "50052"
+	}
+	grpcListener, err := net.Listen("tcp", ":"+grpcPort)
+	if err != nil {
+		logging.Fatal("Failed to listen for gRPC", "error", err)
+	}
+	grpcServer := grpcapi.NewServer(serviceTokenVerifier, bookingServer)
+	go func() {
+		slog.Info("gRPC server starting", "port", grpcPort)
+		if err := grpcServer.Serve(grpcListener); err != nil {
+			logging.Fatal("Failed to start gRPC server", "error", err)
+		}
+	}()
+
+	// On SIGINT or SIGTERM, report not ready, give load balancers time to
+	// drain the service and finish the requests in flight
+	stopped := checker.OnShutdown(func(ctx context.Context) {
+		if err := app.ShutdownWithContext(ctx); err != nil {
+			slog.Error("Failed to shut down server", "error", err)
+		}
+		grpcServer.GracefulStop()
 	})
 
 	// Start server
 	port := ":3002"
-	log.Printf("Server starting on port %s", port)
+	slog.Info("Server starting", "port", port)
 	if err := app.Listen(port); err != nil {
-		log.Fatal("Failed to start server:", err)
+		logging.Fatal("Failed to start server", "error", err)
 	}
+	<-stopped
+	slog.Info("Server stopped")
 }
@@ -0,0 +1,117 @@
+package client
+
+import (
+	"booking-service/internal/metrics"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"platform/logging"
+	"platform/tracing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// RPCError is returned for gRPC calls that end with a status other than OK.
+// It unwraps to ErrNotFound, ErrUnauthorized, ErrUnavailable or ErrTimeout
+// where the code maps to one.
+type RPCError struct {
+	Target  string
+	Code    codes.Code
+	Message string
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("%s returned %s: %s", e.Target, e.Code, e.Message)
+}
+
+func (e *RPCError) Unwrap() error {
+	switch e.Code {
+	case codes.NotFound:
+		return ErrNotFound
+	case codes.Unauthenticated, codes.PermissionDenied:
+		return ErrUnauthorized
+	case codes.DeadlineExceeded:
+		return ErrTimeout
+	case codes.Unavailable, codes.ResourceExhausted, codes.Internal, codes.Unknown, codes.DataLoss:
+		return ErrUnavailable
+	}
+	return nil
+}
+
+// DialGRPC opens a connection to an internal gRPC API. Calls on it share the
+// transport's per-attempt timeout, retries and circuit breakers. Every
+// internal RPC is idempotent, so all of them are retried. The request ID and
+// trace context of the call's context are passed on in the x-request-id and
+// traceparent metadata.
+func (t *Transport) DialGRPC(target string) (*grpc.ClientConn, error) {
+	return grpc.Dial(target,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithUnaryInterceptor(t.unaryInterceptor(target)),
+	)
+}
+
+func (t *Transport) unaryInterceptor(target string) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) (err error) {
+		start := time.Now()
+		ctx, span := tracing.StartRPC(ctx, target, method)
+		defer func() {
+			tracing.EndRPC(span, err)
+			metrics.ObserveClient(target, method, callOutcome(err), start)
+		}()
+
+		breaker := t.breaker(target)
+		if id := logging.RequestID(ctx); id != "" {
+			ctx = metadata.AppendToOutgoingContext(ctx, logging.MetadataRequestID, id)
+		}
+
+		var lastErr error
+		for attempt := 0; attempt <= t.cfg.MaxRetries; attempt++ {
+			if attempt > 0 {
+				if err := sleepContext(ctx, t.backoff(attempt)); err != nil {
+					return err
+				}
+			}
+
+			if !breaker.allow() {
+				return fmt.Errorf("%w: circuit open for %s", ErrUnavailable, target)
+			}
+
+			attemptCtx, cancel := context.WithTimeout(ctx, t.cfg.Timeout)
+			err := invoker(attemptCtx, method, req, reply, cc, opts...)
+			cancel()
+			if err != nil && ctx.Err() != nil {
+				// The caller gave up, which says nothing about the host
+				breaker.release()
+				return ctx.Err()
+			}
+
+			err = classifyRPC(target, err)
+			breaker.record(err == nil || !isHostFailure(err))
+			if err == nil {
+				return nil
+			}
+
+			lastErr = err
+			if !errors.Is(err, ErrUnavailable) && !errors.Is(err, ErrTimeout) {
+				break
+			}
+		}
+
+		return lastErr
+	}
+}
+
+// classifyRPC turns a gRPC status into an *RPCError
+func classifyRPC(target string, err error) error {
+	if err == nil {
+		return nil
+	}
+	st := status.Convert(err)
+	return &RPCError{Target: target, Code: st.Code(), Message: st.Message()}
+}
@@ -1,101 +1,119 @@
 package client
 
 import (
-	"bytes"
-	"encoding/json"
-	"fmt"
-	"io"
-	"net/http"
+	"context"
+	"errors"
 	"os"
+	"time"
+
+	paymentv1 "proto/payment/v1"
+	"sdk"
+
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 type PaymentClient interface {
-	CreatePayment(bookingID uint, amount float64) (*PaymentResponse, error)
-	GetPaymentStatus(paymentID string) (*PaymentResponse, error)
+	CreatePayment(ctx context.Context, bookingID uuid.UUID, amount float64, paymentMethod string) (*sdk.Payment, error)
+	GetPaymentStatus(ctx context.Context, paymentID string) (*sdk.Payment, error)
 }
 
 type paymentClient struct {
-	baseURL string
-}
-
-type PaymentResponse struct {
-	ID        string  `json:"id"`
-	BookingID uint    `json:"booking_id"`
-	Amount    float64 `json:"amount"`
-	Status    string  `json:"status"`
+	rpc    paymentv1.PaymentServiceClient
+	tokens TokenSource
 }
 
-type PaymentRequest struct {
-	BookingID uint    `json:"booking_id"`
-	Amount    float64 `json:"amount"`
-}
+// PaymentServiceAudience is the audience of service tokens for payment-service
+const PaymentServiceAudience = "payment-service"
 
-func NewPaymentClient() PaymentClient {
-	baseURL := os.Getenv("PAYMENT_SERVICE_URL")
-	if baseURL == "" {
-		baseURL = "http://localhost:3002"
+// PaymentServiceAddr returns the PaymentService gRPC target,
+// PAYMENT_SERVICE_GRPC_ADDR
+func PaymentServiceAddr() string {
+	if addr := os.Getenv("PAYMENT_SERVICE_GRPC_ADDR"); addr != "" {
+		return addr
 	}
-	return &paymentClient{baseURL: baseURL}
+	return "localhost:50053"
 }
 
-func (c *paymentClient) CreatePayment(bookingID uint, amount float64) (*PaymentResponse, error) {
-	url := fmt.Sprintf("%s/api/v1/payments", c.baseURL)
-
-	reqBody := PaymentRequest{
-		BookingID: bookingID,
-		Amount:    amount,
-	}
-
-	jsonData, err := json.Marshal(reqBody)
+// NewPaymentClient calls the PaymentService gRPC API at
+// PAYMENT_SERVICE_GRPC_ADDR
+func NewPaymentClient(transport *Transport, tokens TokenSource) (PaymentClient, error) {
+	conn, err := transport.DialGRPC(PaymentServiceAddr())
 	if err != nil {
 		return nil, err
 	}
+	return &paymentClient{rpc: paymentv1.NewPaymentServiceClient(conn), tokens: tokens}, nil
+}
 
-	resp, err := http.Post(url, "application/json", bytes.NewBuffer(jsonData))
+// CreatePayment opens a payment for the booking. Payment service keeps one
+// payment per booking, so the call is safe to repeat: when the payment
+// already exists it is returned instead.
+func (c *paymentClient) CreatePayment(ctx context.Context, bookingID uuid.UUID, amount float64, paymentMethod string) (*sdk.Payment, error) {
+	ctx, err := withServiceToken(ctx, c.tokens, PaymentServiceAudience)
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to payment service: %v", err)
+		return nil, err
 	}
-	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
+	payment, err := c.rpc.CreatePayment(ctx, &paymentv1.CreatePaymentRequest{
+		BookingId:     bookingID.String(),
+		Amount:        amount,
+		PaymentMethod: paymentMethod,
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		return nil, fmt.Errorf("payment service returned status %d: %s", resp.StatusCode, string(body))
+	return fromPaymentProto(payment)
+}
+
+func (c *paymentClient) GetPaymentStatus(ctx context.Context, paymentID string) (*sdk.Payment, error) {
+	ctx, err := withServiceToken(ctx, c.tokens, PaymentServiceAudience)
+	if err != nil {
+		return nil, err
 	}
 
-	var paymentResp PaymentResponse
-	if err := json.Unmarshal(body, &paymentResp); err != nil {
+	payment, err := c.rpc.GetPayment(ctx, &paymentv1.GetPaymentRequest{Id: paymentID})
+	if err != nil {
 		return nil, err
 	}
 
-	return &paymentResp, nil
+	return fromPaymentProto(payment)
 }
 
-func (c *paymentClient) GetPaymentStatus(paymentID string) (*PaymentResponse, error) {
-	url := fmt.Sprintf("%s/api/v1/payments/%s", c.baseURL, paymentID)
-
-	resp, err := http.Get(url)
+func fromPaymentProto(payment *paymentv1.Payment) (*sdk.Payment, error) {
+	id, err := uuid.Parse(payment.GetId())
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to payment service: %v", err)
+		return nil, errors.New("invalid payment data received")
 	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
+	bookingID, err := uuid.Parse(payment.GetBookingId())
 	if err != nil {
-		return nil, err
+		return nil, errors.New("invalid payment data received")
 	}
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("payment service returned status %d: %s", resp.StatusCode, string(body))
+	userID, err := uuid.Parse(payment.GetUserId())
+	if err != nil {
+		return nil, errors.New("invalid payment data received")
 	}
 
-	var paymentResp PaymentResponse
-	if err := json.Unmarshal(body, &paymentResp); err != nil {
-		return nil, err
-	}
+	return &sdk.Payment{
+		ID:            id,
+		BookingID:     bookingID,
+		UserID:        userID,
+		Amount:        payment.GetAmount(),
+		Currency:      payment.GetCurrency(),
+		PaymentMethod: payment.GetPaymentMethod(),
+		Status:        payment.GetStatus(),
+		ExpiredAt:     optionalTime(payment.GetExpiredAt()),
+		PaidAt:        optionalTime(payment.GetPaidAt()),
+		CreatedAt:     payment.GetCreatedAt().AsTime(),
+		UpdatedAt:     payment.GetUpdatedAt().AsTime(),
+	}, nil
+}
 
-	return &paymentResp, nil
+// optionalTime converts an unset timestamp to nil
+func optionalTime(ts *timestamppb.Timestamp) *time.Time {
+	if ts == nil {
+		return nil
+	}
+	t := ts.AsTime()
+	return &t
 }
@@ -0,0 +1,349 @@
+package client
+
+import (
+	"booking-service/internal/metrics"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"platform/logging"
+	"platform/tracing"
+)
+
+// Errors returned by Transport, possibly wrapped. Use errors.Is to check.
+var (
+	ErrUnavailable  = errors.New("service unavailable")
+	ErrTimeout      = errors.New("service timed out")
+	ErrNotFound     = errors.New("resource not found")
+	ErrUnauthorized = errors.New("not authorized")
+)
+
+// TransportConfig tunes the shared HTTP transport
+type TransportConfig struct {
+	Timeout          time.Duration // per attempt, including reading the body
+	MaxRetries       int           // extra attempts for idempotent requests
+	BackoffBase      time.Duration
+	BackoffMax       time.Duration
+	BreakerThreshold int // consecutive failures before a host's circuit opens
+	BreakerCooldown  time.Duration
+}
+
+// LoadTransportConfig reads the HTTP_CLIENT_* settings from the environment
+func LoadTransportConfig() TransportConfig {
+	return TransportConfig{
+		Timeout:          getEnvDuration("HTTP_CLIENT_TIMEOUT", 5*time.Second),
+		MaxRetries:       getEnvInt("HTTP_CLIENT_MAX_RETRIES", 2),
+		BackoffBase:      getEnvDuration("HTTP_CLIENT_BACKOFF_BASE", 100*time.Millisecond),
+		BackoffMax:       getEnvDuration("HTTP_CLIENT_BACKOFF_MAX", 2*time.Second),
+		BreakerThreshold: getEnvInt("HTTP_CLIENT_BREAKER_THRESHOLD", 5),
+		BreakerCooldown:  getEnvDuration("HTTP_CLIENT_BREAKER_COOLDOWN", 30*time.Second),
+	}
+}
+
+// Transport is the HTTP layer shared by every client. It pools connections,
+// bounds each attempt with a timeout, retries idempotent requests with
+// jittered exponential backoff and keeps a circuit breaker per host.
+type Transport struct {
+	cfg    TransportConfig
+	client *http.Client
+
+	mu       sync.Mutex
+	breakers map[string]*breaker
+}
+
+func NewTransport(cfg TransportConfig) *Transport {
+	transport := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   3 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   20,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   5 * time.Second,
+		ExpectContinueTimeout: time.Second,
+	}
+
+	return &Transport{
+		cfg:      cfg,
+		client:   &http.Client{Transport: transport},
+		breakers: make(map[string]*breaker),
+	}
+}
+
+// HTTPClient returns an http.Client that sends its requests through the
+// transport
+func (t *Transport) HTTPClient() *http.Client {
+	return &http.Client{Transport: t}
+}
+
+// RoundTrip implements http.RoundTripper. GET, HEAD, OPTIONS, PUT and DELETE
+// requests, and requests carrying an Idempotency-Key header, are retried
+// when the host is unavailable, times out or answers with 429 or a 5xx
+// status. The response of the last attempt is returned. The call, retries
+// included, is traced as one client span.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	req, span := tracing.StartClient(req)
+	resp, err := t.roundTrip(req)
+	tracing.EndClient(span, resp, err)
+
+	outcome := callOutcome(err)
+	if err == nil && isUnavailableStatus(resp.StatusCode) {
+		outcome = metrics.OutcomeUnavailable
+	} else if err == nil && resp.StatusCode >= http.StatusBadRequest {
+		outcome = metrics.OutcomeError
+	}
+	metrics.ObserveClient(req.URL.Host, req.Method, outcome, start)
+	return resp, err
+}
+
+func (t *Transport) roundTrip(req *http.Request) (*http.Response, error) {
+	host := req.URL.Host
+	breaker := t.breaker(host)
+
+	attempts := 1
+	if isIdempotent(req) {
+		attempts += t.cfg.MaxRetries
+	}
+
+	var lastResp *http.Response
+	var lastErr error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			if err := sleepContext(req.Context(), t.backoff(attempt)); err != nil {
+				return nil, err
+			}
+		}
+
+		if !breaker.allow() {
+			return nil, fmt.Errorf("%w: circuit open for %s", ErrUnavailable, host)
+		}
+
+		resp, err := t.attempt(req)
+		if err != nil && req.Context().Err() != nil {
+			// The caller gave up, which says nothing about the host
+			breaker.release()
+			return nil, err
+		}
+
+		failed := err != nil || isUnavailableStatus(resp.StatusCode)
+		breaker.record(!failed)
+		if !failed {
+			return resp, nil
+		}
+
+		lastResp, lastErr = resp, err
+		if err != nil && !errors.Is(err, ErrUnavailable) && !errors.Is(err, ErrTimeout) {
+			break
+		}
+	}
+
+	return lastResp, lastErr
+}
+
+// attempt sends req once, with the request ID of its context unless it sets
+// one and with the traceparent of its span. The body is read within the per-attempt timeout and handed back
+// buffered.
+func (t *Transport) attempt(req *http.Request) (*http.Response, error) {
+	ctx, cancel := context.WithTimeout(req.Context(), t.cfg.Timeout)
+	defer cancel()
+
+	attemptReq := req.Clone(ctx)
+	if req.Body != nil && req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		attemptReq.Body = body
+	}
+	if id := logging.RequestID(ctx); id != "" && attemptReq.Header.Get(logging.HeaderRequestID) == "" {
+		attemptReq.Header.Set(logging.HeaderRequestID, id)
+	}
+	tracing.InjectHeader(ctx, attemptReq.Header)
+
+	resp, err := t.client.Do(attemptReq)
+	if err != nil {
+		return nil, classify(req, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, classify(req, err)
+	}
+
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	return resp, nil
+}
+
+// backoff returns a random wait of up to BackoffBase doubled per attempt,
+// capped at BackoffMax ("full jitter")
+func (t *Transport) backoff(attempt int) time.Duration {
+	ceiling := t.cfg.BackoffBase
+	for i := 1; i < attempt && ceiling < t.cfg.BackoffMax; i++ {
+		ceiling *= 2
+	}
+	if ceiling > t.cfg.BackoffMax {
+		ceiling = t.cfg.BackoffMax
+	}
+	if ceiling <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(ceiling)))
+}
+
+func (t *Transport) breaker(host string) *breaker {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	b, ok := t.breakers[host]
+	if !ok {
+		b = &breaker{threshold: t.cfg.BreakerThreshold, cooldown: t.cfg.BreakerCooldown}
+		t.breakers[host] = b
+	}
+	return b
+}
+
+// classify maps transport errors to ErrTimeout or ErrUnavailable. A caller
+// that cancelled its own context gets the context error back instead.
+func classify(req *http.Request, err error) error {
+	if ctxErr := req.Context().Err(); ctxErr != nil {
+		return ctxErr
+	}
+
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return fmt.Errorf("%w: %s: %v", ErrTimeout, req.URL.Host, err)
+	}
+	return fmt.Errorf("%w: %s: %v", ErrUnavailable, req.URL.Host, err)
+}
+
+// isHostFailure reports whether err counts against the host's circuit
+// breaker. Client errors such as NotFound do not.
+func isHostFailure(err error) bool {
+	return errors.Is(err, ErrUnavailable) || errors.Is(err, ErrTimeout)
+}
+
+// isUnavailableStatus reports whether a response status means the host is
+// overloaded or failing
+// callOutcome classifies the error of a call for metrics
+func callOutcome(err error) string {
+	switch {
+	case err == nil:
+		return metrics.OutcomeOK
+	case errors.Is(err, ErrTimeout), errors.Is(err, context.DeadlineExceeded):
+		return metrics.OutcomeTimeout
+	case errors.Is(err, ErrUnavailable):
+		return metrics.OutcomeUnavailable
+	}
+	return metrics.OutcomeError
+}
+
+func isUnavailableStatus(code int) bool {
+	return code == http.StatusTooManyRequests || code >= 500
+}
+
+func isIdempotent(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return req.Header.Get("Idempotency-Key") != ""
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// breaker opens after threshold consecutive failures. Once cooldown has
+// passed a single trial request is let through; its outcome closes the
+// circuit or opens it for another cooldown.
+type breaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+	trial     bool
+}
+
+func (b *breaker) allow() bool {
+	if b.threshold <= 0 {
+		return true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.failures < b.threshold {
+		return true
+	}
+	if time.Now().Before(b.openUntil) || b.trial {
+		return false
+	}
+	b.trial = true
+	return true
+}
+
+// release ends a trial without an outcome
+func (b *breaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.trial = false
+}
+
+func (b *breaker) record(success bool) {
+	if b.threshold <= 0 {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.trial = false
+	if success {
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+	if b.failures >= b.threshold {
+		b.openUntil = time.Now().Add(b.cooldown)
+	}
+}
+
+func getEnvInt(key string, defaultValue int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
@@ -0,0 +1,106 @@
+package grpcapi
+
+import (
+	"booking-service/internal/middleware"
+	"booking-service/internal/model"
+	"booking-service/internal/service"
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"platform/tracing"
+	bookingv1 "proto/booking/v1"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// bookingScopes lists the service token scopes each RPC requires
+var bookingScopes = map[string][]string{
+	bookingv1.BookingService_GetBooking_FullMethodName:          {"bookings:read"},
+	bookingv1.BookingService_UpdateBookingStatus_FullMethodName: {"bookings:status:write"},
+}
+
+type BookingServer struct {
+	bookingv1.UnimplementedBookingServiceServer
+	service service.BookingService
+}
+
+func NewBookingServer(service service.BookingService) *BookingServer {
+	return &BookingServer{service: service}
+}
+
+// NewServer returns a gRPC server exposing BookingService to callers with a
+// service token
+func NewServer(verifier *middleware.ServiceTokenVerifier, bookingServer *BookingServer) *grpc.Server {
+	server := grpc.NewServer(grpc.ChainUnaryInterceptor(
+		tracing.UnaryServer(),
+		middleware.UnaryRequestID(),
+		middleware.UnaryServiceToken(verifier, bookingScopes),
+	))
+	bookingv1.RegisterBookingServiceServer(server, bookingServer)
+	return server
+}
+
+func (s *BookingServer) GetBooking(ctx context.Context, req *bookingv1.GetBookingRequest) (*bookingv1.Booking, error) {
+	id, err := uuid.Parse(req.GetId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid booking ID")
+	}
+
+	booking, err := s.service.GetBookingByID(ctx, id)
+	switch {
+	case errors.Is(err, service.ErrBookingNotFound):
+		return nil, status.Error(codes.NotFound, err.Error())
+	case err != nil:
+		slog.ErrorContext(ctx, "Failed to get booking", "booking_id", id, "error", err)
+		return nil, status.Error(codes.Internal, "failed to get booking")
+	}
+
+	return toBookingProto(booking), nil
+}
+
+func (s *BookingServer) UpdateBookingStatus(ctx context.Context, req *bookingv1.UpdateBookingStatusRequest) (*bookingv1.UpdateBookingStatusResponse, error) {
+	id, err := uuid.Parse(req.GetId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid booking ID")
+	}
+
+	err = s.service.UpdateBookingStatus(ctx, id, req.GetStatus())
+	switch {
+	case errors.Is(err, service.ErrBookingNotFound):
+		return nil, status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, service.ErrBookingNotPending):
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	case err != nil:
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	return &bookingv1.UpdateBookingStatusResponse{}, nil
+}
+
+func toBookingProto(booking *model.BookingResponse) *bookingv1.Booking {
+	return &bookingv1.Booking{
+		Id:          booking.ID.String(),
+		UserId:      booking.UserID.String(),
+		EventId:     booking.EventID.String(),
+		TicketId:    booking.TicketID.String(),
+		Quantity:    int32(booking.Quantity),
+		TotalAmount: booking.TotalAmount,
+		Status:      booking.Status,
+		ExpiredAt:   optionalTimestamp(booking.ExpiredAt),
+		CreatedAt:   timestamppb.New(booking.CreatedAt),
+	}
+}
+
+// optionalTimestamp leaves the field unset for a nil time
+func optionalTimestamp(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
@@ -1,54 +1,52 @@
 package handler
 
 import (
-	"booking-service/internal/client"
 	"booking-service/internal/service"
+	"errors"
+	"os"
+	"sdk"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 )
 
 type BookingHandler struct {
-	service    service.BookingService
-	userClient client.UserClient
+	service              service.BookingService
+	checkout             service.CheckoutService
+	requireVerifiedEmail bool
 }
 
-func NewBookingHandler(service service.BookingService, userClient client.UserClient) *BookingHandler {
+func NewBookingHandler(service service.BookingService, checkout service.CheckoutService) *BookingHandler {
 	return &BookingHandler{
-		service:    service,
-		userClient: userClient,
+		service:              service,
+		checkout:             checkout,
+		requireVerifiedEmail: os.Getenv("REQUIRE_VERIFIED_EMAIL") == "true",
 	}
 }
 
 type CreateBookingRequest struct {
-	EventID  string `json:"event_id" validate:"required"`
-	TicketID string `json:"ticket_id" validate:"required"`
-	Quantity int    `json:"quantity" validate:"required"`
+	EventID       string `json:"event_id" validate:"required"`
+	TicketID      string `json:"ticket_id" validate:"required"`
+	Quantity      int    `json:"quantity" validate:"required"`
+	PaymentMethod string `json:"payment_method"` // optional, pays straight away
 }
 
 type UpdateBookingStatusRequest struct {
 	Status string `json:"status" validate:"required"`
 }
 
-type PaymentWebhookRequest struct {
-	Event     string `json:"event"`      // payment.success, payment.failed, payment.expired
-	PaymentID string `json:"payment_id"`
-	BookingID string `json:"booking_id"`
-	Status    string `json:"status"`
-}
-
 func (h *BookingHandler) CreateBooking(c *fiber.Ctx) error {
-	authToken := c.Get("Authorization")
-	if authToken == "" {
+	// Set by middleware.Authenticate from a bearer token or an API key
+	user, ok := c.Locals("user").(*sdk.User)
+	if !ok {
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 			"error": "Authorization token is required",
 		})
 	}
 
-	user, err := h.userClient.GetAuthenticatedUser(authToken)
-	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": "Failed to authenticate user: " + err.Error(),
+	if h.requireVerifiedEmail && !user.EmailVerified {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Email address must be verified before booking",
 		})
 	}
 
@@ -79,16 +77,16 @@ func (h *BookingHandler) CreateBooking(c *fiber.Ctx) error {
 		})
 	}
 
-	userID, err := uuid.Parse(user.ID)
+	saga, err := h.checkout.Start(c.UserContext(), user.ID, eventID, ticketID, req.Quantity, req.PaymentMethod)
 	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid user ID format",
+			"error": err.Error(),
 		})
 	}
 
-	booking, err := h.service.CreateBooking(userID, eventID, ticketID, req.Quantity)
+	booking, err := h.service.GetBookingByID(c.UserContext(), saga.BookingID)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": err.Error(),
 		})
 	}
@@ -108,12 +106,17 @@ func (h *BookingHandler) GetBookingByID(c *fiber.Ctx) error {
 		})
 	}
 
-	booking, err := h.service.GetBookingByID(id)
-	if err != nil {
+	booking, err := h.service.GetBookingByID(c.UserContext(), id)
+	if errors.Is(err, service.ErrBookingNotFound) {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"error": err.Error(),
 		})
 	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get booking",
+		})
+	}
 
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
 		"message": "Booking retrieved successfully",
@@ -122,7 +125,7 @@ func (h *BookingHandler) GetBookingByID(c *fiber.Ctx) error {
 }
 
 func (h *BookingHandler) GetAllBookings(c *fiber.Ctx) error {
-	bookings, err := h.service.GetAllBookings()
+	bookings, err := h.service.GetAllBookings(c.UserContext())
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": err.Error(),
@@ -170,7 +173,7 @@ func (h *BookingHandler) UpdateBookingStatus(c *fiber.Ctx) error {
 		})
 	}
 
-	if err := h.service.UpdateBookingStatus(id, req.Status); err != nil {
+	if err := h.service.UpdateBookingStatus(c.UserContext(), id, req.Status); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": err.Error(),
 		})
@@ -180,48 +183,3 @@ func (h *BookingHandler) UpdateBookingStatus(c *fiber.Ctx) error {
 		"message": "Booking status updated successfully",
 	})
 }
-
-func (h *BookingHandler) HandlePaymentWebhook(c *fiber.Ctx) error {
-	var req PaymentWebhookRequest
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request body",
-		})
-	}
-
-	if req.BookingID == "" || req.Event == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "booking_id and event are required",
-		})
-	}
-
-	bookingID, err := uuid.Parse(req.BookingID)
-	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid booking ID format",
-		})
-	}
-
-	switch req.Event {
-	case "payment.success":
-		if err := h.service.UpdateBookingStatus(bookingID, "CONFIRMED"); err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": err.Error(),
-			})
-		}
-	case "payment.failed", "payment.expired":
-		if err := h.service.UpdateBookingStatus(bookingID, "CANCELLED"); err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": err.Error(),
-			})
-		}
-	default:
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Unknown event type",
-		})
-	}
-
-	return c.Status(fiber.StatusOK).JSON(fiber.Map{
-		"message": "Payment webhook processed successfully",
-	})
-}
@@ -0,0 +1,148 @@
+package handler
+
+import (
+	"booking-service/internal/model"
+	"booking-service/internal/repository"
+	"booking-service/internal/service"
+	"errors"
+	"sdk"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type CheckoutHandler struct {
+	service service.CheckoutService
+}
+
+func NewCheckoutHandler(service service.CheckoutService) *CheckoutHandler {
+	return &CheckoutHandler{service: service}
+}
+
+type CheckoutRequest struct {
+	PaymentMethod string `json:"payment_method" validate:"required"`
+}
+
+// Checkout pays for a booking created without a payment method
+func (h *CheckoutHandler) Checkout(c *fiber.Ctx) error {
+	user, ok := c.Locals("user").(*sdk.User)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Authorization token is required",
+		})
+	}
+
+	bookingID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid booking ID",
+		})
+	}
+
+	var req CheckoutRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	saga, err := h.service.Checkout(c.UserContext(), bookingID, user.ID, req.PaymentMethod)
+	if err != nil {
+		status := fiber.StatusBadRequest
+		switch {
+		case errors.Is(err, service.ErrSagaNotFound):
+			status = fiber.StatusNotFound
+		case errors.Is(err, service.ErrSagaBusy), errors.Is(err, service.ErrCheckoutStarted),
+			errors.Is(err, service.ErrHoldExpired):
+			status = fiber.StatusConflict
+		}
+		return c.Status(status).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	if saga.Status == model.SagaStatusCompensating || saga.Status == model.SagaStatusCompensated {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Checkout failed: " + saga.FailureReason,
+			"data":  fiber.Map{"checkout": saga},
+		})
+	}
+
+	if saga.PaymentID == nil {
+		// Payment service could not be reached; the saga keeps retrying
+		return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+			"message": "Checkout is being processed",
+			"data":    fiber.Map{"checkout": saga},
+		})
+	}
+
+	payment, err := h.service.GetPayment(c.UserContext(), saga)
+	if err != nil {
+		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+			"error": "Failed to retrieve payment",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Payment created successfully",
+		"data": fiber.Map{
+			"checkout": saga,
+			"payment":  payment,
+		},
+	})
+}
+
+func (h *CheckoutHandler) GetSagas(c *fiber.Ctx) error {
+	filter := repository.CheckoutSagaFilter{
+		Status: c.Query("status"),
+		Limit:  c.QueryInt("limit", 100),
+	}
+	if param := c.Query("booking_id"); param != "" {
+		id, err := uuid.Parse(param)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid booking ID",
+			})
+		}
+		filter.BookingID = &id
+	}
+
+	sagas, err := h.service.ListSagas(c.UserContext(), filter)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve checkouts",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Checkouts retrieved successfully",
+		"data":    sagas,
+	})
+}
+
+// GetSaga returns one saga with the log of every step run or compensated
+func (h *CheckoutHandler) GetSaga(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid checkout ID",
+		})
+	}
+
+	saga, err := h.service.GetSaga(c.UserContext(), id)
+	if err != nil {
+		if errors.Is(err, service.ErrSagaNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve checkout",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Checkout retrieved successfully",
+		"data":    saga,
+	})
+}
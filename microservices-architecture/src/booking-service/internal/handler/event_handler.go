@@ -27,7 +27,7 @@ func (h *EventHandler) GetEventByID(c *fiber.Ctx) error {
 		})
 	}
 
-	event, err := h.eventRepo.FindByID(id)
+	event, err := h.eventRepo.FindByID(c.UserContext(), id)
 	if err != nil {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"error": "Event not found",
@@ -47,7 +47,7 @@ func (h *EventHandler) GetEventByID(c *fiber.Ctx) error {
 }
 
 func (h *EventHandler) GetAllEvents(c *fiber.Ctx) error {
-	events, err := h.eventRepo.FindAll()
+	events, err := h.eventRepo.FindAll(c.UserContext())
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to retrieve events",
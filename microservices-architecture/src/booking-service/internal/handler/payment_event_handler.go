@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"booking-service/internal/service"
+	"context"
+	"errors"
+	"log/slog"
+
+	"platform/eventbus"
+
+	"github.com/google/uuid"
+)
+
+// ConsumerGroup is the event bus consumer group of booking service
+const ConsumerGroup = "booking-service"
+
+// PaymentEventHandler moves checkouts on as payments settle
+type PaymentEventHandler struct {
+	service  service.BookingService
+	checkout service.CheckoutService
+}
+
+func NewPaymentEventHandler(service service.BookingService, checkout service.CheckoutService) *PaymentEventHandler {
+	return &PaymentEventHandler{service: service, checkout: checkout}
+}
+
+// Subscribe registers the handler for payment.success and payment.failed
+func (h *PaymentEventHandler) Subscribe(bus eventbus.EventBus) error {
+	if err := bus.Subscribe(eventbus.SubjectPaymentSuccess, ConsumerGroup, h.HandlePaymentSuccess); err != nil {
+		return err
+	}
+	return bus.Subscribe(eventbus.SubjectPaymentFailed, ConsumerGroup, h.HandlePaymentFailed)
+}
+
+func (h *PaymentEventHandler) HandlePaymentSuccess(ctx context.Context, msg eventbus.Message) error {
+	return h.handlePayment(ctx, msg, true)
+}
+
+func (h *PaymentEventHandler) HandlePaymentFailed(ctx context.Context, msg eventbus.Message) error {
+	return h.handlePayment(ctx, msg, false)
+}
+
+func (h *PaymentEventHandler) handlePayment(ctx context.Context, msg eventbus.Message, paid bool) error {
+	var event eventbus.PaymentEvent
+	if err := msg.Decode(&event); err != nil || event.BookingID == uuid.Nil {
+		return eventbus.Permanent(errors.New("invalid payment event payload"))
+	}
+
+	eventID, err := msg.EventID(event.EventID)
+	if err != nil {
+		return eventbus.Permanent(errors.New("invalid payment event payload"))
+	}
+
+	err = h.checkout.HandlePaymentResult(ctx, eventID, event.BookingID, event.PaymentID, paid)
+	if errors.Is(err, service.ErrSagaNotFound) {
+		// Bookings made before checkouts ran as sagas are updated directly
+		err = h.service.HandlePaymentResult(ctx, eventID, event.BookingID, paid)
+	}
+
+	if errors.Is(err, service.ErrDuplicateEvent) {
+		slog.InfoContext(ctx, "Event ignored: already processed", "subject", msg.Subject, "event_id", eventID, "booking_id", event.BookingID)
+		return nil
+	}
+	if errors.Is(err, service.ErrBookingNotPending) {
+		// The booking expired or was cancelled first, there is nothing
+		// left to do here
+		slog.InfoContext(ctx, "Event ignored: booking is not pending", "subject", msg.Subject, "booking_id", event.BookingID)
+		return nil
+	}
+	// ErrSagaBusy is redelivered once the other instance is done
+	return err
+}
@@ -27,7 +27,7 @@ func (h *TicketHandler) GetTicketByID(c *fiber.Ctx) error {
 		})
 	}
 
-	ticket, err := h.ticketRepo.FindByID(id)
+	ticket, err := h.ticketRepo.FindByID(c.UserContext(), id)
 	if err != nil {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"error": "Ticket not found",
@@ -47,7 +47,7 @@ func (h *TicketHandler) GetTicketByID(c *fiber.Ctx) error {
 }
 
 func (h *TicketHandler) GetAllTickets(c *fiber.Ctx) error {
-	tickets, err := h.ticketRepo.FindAll()
+	tickets, err := h.ticketRepo.FindAll(c.UserContext())
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to retrieve tickets",
@@ -80,7 +80,7 @@ func (h *TicketHandler) GetTicketsByEventID(c *fiber.Ctx) error {
 		})
 	}
 
-	tickets, err := h.ticketRepo.FindByEventID(eventID)
+	tickets, err := h.ticketRepo.FindByEventID(c.UserContext(), eventID)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to retrieve tickets",
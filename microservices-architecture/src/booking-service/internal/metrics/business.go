@@ -0,0 +1,77 @@
+package metrics
+
+import (
+	"booking-service/internal/model"
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var bookingTransitions = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "booking_status_transitions_total",
+	Help: "Booking status changes, by previous and new status and reason. New bookings come from an empty status.",
+}, []string{"from", "to", "reason"})
+
+func init() {
+	Registry.MustRegister(bookingTransitions)
+}
+
+// Reasons for booking status transitions
+const (
+	ReasonCreated        = "created"
+	ReasonPaid           = "paid"
+	ReasonPaymentFailed  = "payment_failed"
+	ReasonExpired        = "expired"
+	ReasonCheckoutFailed = "checkout_failed"
+	ReasonStatusUpdate   = "status_update" // set through the internal API
+)
+
+// BookingTransition counts a booking moving from one status to another once
+// the change is committed
+func BookingTransition(from, to, reason string) {
+	bookingTransitions.WithLabelValues(from, to, reason).Inc()
+}
+
+// TicketLister lists every ticket with its remaining quota
+type TicketLister interface {
+	FindAll(ctx context.Context) ([]model.Ticket, error)
+}
+
+var ticketQuotaDesc = prometheus.NewDesc(
+	"ticket_quota_remaining",
+	"Tickets left to book, by event, ticket and category.",
+	[]string{"event_id", "ticket_id", "category"}, nil,
+)
+
+// quotaCollector reads remaining quotas from the database on every scrape,
+// so each replica reports the same, current numbers
+type quotaCollector struct {
+	tickets TicketLister
+}
+
+// RegisterTicketQuota adds the ticket_quota_remaining gauge, read from
+// tickets
+func RegisterTicketQuota(tickets TicketLister) {
+	Registry.MustRegister(quotaCollector{tickets: tickets})
+}
+
+func (c quotaCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- ticketQuotaDesc
+}
+
+func (c quotaCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tickets, err := c.tickets.FindAll(ctx)
+	if err != nil {
+		slog.Error("failed to read ticket quotas for metrics", "error", err)
+		return
+	}
+	for _, ticket := range tickets {
+		ch <- prometheus.MustNewConstMetric(ticketQuotaDesc, prometheus.GaugeValue, float64(ticket.Quota),
+			ticket.EventID.String(), ticket.ID.String(), ticket.Category)
+	}
+}
@@ -0,0 +1,106 @@
+package middleware
+
+import (
+	"booking-service/internal/client"
+	"errors"
+	"sdk"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Authenticate accepts either a user's bearer token or a partner API key in
+// X-API-Key. API keys must carry scope; a logged in user may do anything
+// their account can. The user is stored in the "user" local.
+func Authenticate(userClient client.UserClient, scope string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if apiKey := c.Get("X-API-Key"); apiKey != "" {
+			return authenticateAPIKey(c, userClient, apiKey, scope)
+		}
+
+		authToken := c.Get("Authorization")
+		if authToken == "" {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Authorization token or API key is required",
+			})
+		}
+
+		user, err := userClient.GetAuthenticatedUser(c.UserContext(), authToken)
+		if err != nil {
+			if isUnavailable(err) {
+				return userServiceUnavailable(c)
+			}
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Failed to authenticate user: " + err.Error(),
+			})
+		}
+
+		c.Locals("user", user)
+		return c.Next()
+	}
+}
+
+// OptionalAPIKey lets anonymous requests through, but a request presenting
+// X-API-Key must use a valid key that carries scope, so that partner usage
+// is recorded against the key
+func OptionalAPIKey(userClient client.UserClient, scope string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if apiKey := c.Get("X-API-Key"); apiKey != "" {
+			return authenticateAPIKey(c, userClient, apiKey, scope)
+		}
+		return c.Next()
+	}
+}
+
+// RequireRole only lets through users signed in with a bearer token whose
+// role is one of roles. It must run after Authenticate. API keys are turned
+// away whatever their owner's role.
+func RequireRole(roles ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		user, ok := c.Locals("user").(*sdk.User)
+		if ok && c.Locals("apiKeyID") == nil {
+			for _, allowed := range roles {
+				if user.Role == allowed {
+					return c.Next()
+				}
+			}
+		}
+
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Insufficient permissions",
+		})
+	}
+}
+
+func authenticateAPIKey(c *fiber.Ctx, userClient client.UserClient, apiKey, scope string) error {
+	key, err := userClient.GetAPIKeyUser(c.UserContext(), apiKey)
+	if err != nil {
+		if isUnavailable(err) {
+			return userServiceUnavailable(c)
+		}
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid or revoked API key",
+		})
+	}
+
+	if !containsScope(key.Scopes, scope) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "API key is missing scope " + scope,
+		})
+	}
+
+	c.Locals("user", &key.User)
+	c.Locals("apiKeyID", key.KeyID)
+	return c.Next()
+}
+
+// isUnavailable reports whether err means user service could not answer,
+// as opposed to rejecting the credentials
+func isUnavailable(err error) bool {
+	return errors.Is(err, client.ErrUnavailable) || errors.Is(err, client.ErrTimeout)
+}
+
+func userServiceUnavailable(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+		"error": "Authentication service is unavailable, please try again later",
+	})
+}
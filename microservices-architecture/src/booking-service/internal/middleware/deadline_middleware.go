@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"os"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Deadline bounds the request context passed to services, repositories and
+// clients. The timeout is read from the env variable, falling back to
+// fallback if it is unset or invalid. A Deadline further down the chain
+// replaces the one set before it rather than nesting inside it, so routes
+// can be given more (or less) time than the app-wide default.
+//
+// Client disconnects do not cancel the context: fasthttp does not report a
+// closed connection while the handler runs, so work started for a client
+// that went away runs on until it finishes or hits the deadline.
+func Deadline(env string, fallback time.Duration) fiber.Handler {
+	timeout := fallback
+	if value, err := time.ParseDuration(os.Getenv(env)); err == nil && value > 0 {
+		timeout = value
+	}
+
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(c.UserContext()), timeout)
+		defer cancel()
+
+		c.SetUserContext(ctx)
+		err := c.Next()
+
+		// Only answer for the deadline the handler actually ran under
+		if c.UserContext() == ctx && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return c.Status(fiber.StatusGatewayTimeout).JSON(fiber.Map{
+				"error": "Request timed out",
+			})
+		}
+		return err
+	}
+}
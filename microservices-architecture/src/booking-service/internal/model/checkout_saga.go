@@ -0,0 +1,81 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Checkout saga steps, in the order they run. Each is undone by its
+// compensating action, in reverse order, when a later step fails.
+const (
+	SagaStepReserveQuota  = "reserve_quota"  // undone by releasing the quota
+	SagaStepCreatePayment = "create_payment" // undone by cancelling the payment
+	SagaStepAwaitPayment  = "await_payment"  // waiting has nothing to undo
+	SagaStepConfirm       = "confirm"        // last step, never undone
+)
+
+// Checkout saga statuses
+const (
+	SagaStatusRunning      = "RUNNING"      // Step is due to run
+	SagaStatusWaiting      = "WAITING"      // Step waits for a payment method or outcome
+	SagaStatusCompensating = "COMPENSATING" // Step is due to be compensated
+	SagaStatusCompleted    = "COMPLETED"
+	SagaStatusCompensated  = "COMPENSATED"
+)
+
+// Saga log actions and outcomes
+const (
+	SagaActionExecute    = "execute"
+	SagaActionCompensate = "compensate"
+
+	SagaOutcomeSuccess = "success"
+	SagaOutcomeFailure = "failure"
+)
+
+// CheckoutSaga coordinates one booking from reserving tickets to confirming
+// it once paid. Its state is saved after every step so that any instance
+// can pick it up again after a crash.
+type CheckoutSaga struct {
+	ID            uuid.UUID         `gorm:"type:uuid;primaryKey" json:"id"`
+	BookingID     uuid.UUID         `gorm:"type:uuid;not null;uniqueIndex" json:"booking_id"`
+	UserID        uuid.UUID         `gorm:"type:uuid;not null" json:"user_id"`
+	Amount        float64           `gorm:"type:decimal(12,2);not null" json:"amount"`
+	PaymentMethod string            `gorm:"type:varchar(50)" json:"payment_method,omitempty"`
+	PaymentID     *uuid.UUID        `gorm:"type:uuid" json:"payment_id,omitempty"`
+	Status        string            `gorm:"type:varchar(20);not null;index:idx_checkout_sagas_due,priority:1" json:"status"`
+	Step          string            `gorm:"type:varchar(30);not null" json:"step"`
+	FailureReason string            `gorm:"type:text" json:"failure_reason,omitempty"`
+	Attempts      int               `gorm:"not null;default:0" json:"attempts"` // at the current step
+	LastError     string            `gorm:"type:text" json:"last_error,omitempty"`
+	NextAttemptAt time.Time         `gorm:"not null;index:idx_checkout_sagas_due,priority:2" json:"next_attempt_at"`
+	ExpiresAt     time.Time         `gorm:"not null" json:"expires_at"` // end of the booking hold
+	LockedUntil   *time.Time        `json:"locked_until,omitempty"`
+	CreatedAt     time.Time         `json:"created_at"`
+	UpdatedAt     time.Time         `json:"updated_at"`
+	Log           []CheckoutSagaLog `gorm:"foreignKey:SagaID" json:"log,omitempty"`
+}
+
+func (s *CheckoutSaga) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return nil
+}
+
+// IsFinished reports whether the saga has nothing left to do
+func (s *CheckoutSaga) IsFinished() bool {
+	return s.Status == SagaStatusCompleted || s.Status == SagaStatusCompensated
+}
+
+// CheckoutSagaLog records every step run or compensated, for inspection
+type CheckoutSagaLog struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	SagaID    uuid.UUID `gorm:"type:uuid;not null;index" json:"-"`
+	Step      string    `gorm:"type:varchar(30);not null" json:"step"`
+	Action    string    `gorm:"type:varchar(20);not null" json:"action"`
+	Outcome   string    `gorm:"type:varchar(20);not null" json:"outcome"`
+	Error     string    `gorm:"type:text" json:"error,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,68 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Outbound delivery statuses
+const (
+	DeliveryStatusPending   = "PENDING"   // Being published
+	DeliveryStatusDelivered = "DELIVERED" // Accepted by the event bus
+	DeliveryStatusFailed    = "FAILED"    // Due to be retried at NextRetryAt
+	DeliveryStatusDead      = "DEAD"      // Out of attempts, only replayed by hand
+)
+
+// OutboundDelivery records one event this service published for another,
+// with the outcome of the last attempt, so that nothing is lost when the
+// event bus is unreachable
+type OutboundDelivery struct {
+	ID          uuid.UUID  `gorm:"type:uuid;primaryKey"`
+	Subject     string     `gorm:"type:varchar(255);not null;index"`
+	Payload     []byte     `gorm:"type:jsonb;not null"`
+	Status      string     `gorm:"type:varchar(20);not null;index:idx_outbound_deliveries_due,priority:1"`
+	Attempts    int        `gorm:"not null;default:0"`
+	LastError   string     `gorm:"type:text"` // excerpt of the event bus's reply
+	NextRetryAt *time.Time `gorm:"index:idx_outbound_deliveries_due,priority:2"`
+	DeliveredAt *time.Time
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+func (d *OutboundDelivery) BeforeCreate(tx *gorm.DB) error {
+	if d.ID == uuid.Nil {
+		d.ID = uuid.New()
+	}
+	return nil
+}
+
+type OutboundDeliveryResponse struct {
+	ID          uuid.UUID       `json:"id"`
+	Subject     string          `json:"subject"`
+	Payload     json.RawMessage `json:"payload"`
+	Status      string          `json:"status"`
+	Attempts    int             `json:"attempts"`
+	LastError   string          `json:"last_error,omitempty"`
+	NextRetryAt *time.Time      `json:"next_retry_at,omitempty"`
+	DeliveredAt *time.Time      `json:"delivered_at,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+}
+
+func (d *OutboundDelivery) ToResponse() *OutboundDeliveryResponse {
+	return &OutboundDeliveryResponse{
+		ID:          d.ID,
+		Subject:     d.Subject,
+		Payload:     json.RawMessage(d.Payload),
+		Status:      d.Status,
+		Attempts:    d.Attempts,
+		LastError:   d.LastError,
+		NextRetryAt: d.NextRetryAt,
+		DeliveredAt: d.DeliveredAt,
+		CreatedAt:   d.CreatedAt,
+		UpdatedAt:   d.UpdatedAt,
+	}
+}
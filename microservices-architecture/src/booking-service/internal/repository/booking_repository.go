@@ -2,6 +2,8 @@ package repository
 
 import (
 	"booking-service/internal/model"
+	"context"
+	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
@@ -9,13 +11,14 @@ import (
 )
 
 type BookingRepository interface {
-	Create(booking *model.Booking) error
-	FindByID(id uuid.UUID) (*model.Booking, error)
-	FindByIDForUpdate(id uuid.UUID) (*model.Booking, error)
-	FindAll() ([]model.Booking, error)
-	FindByUserID(userID uuid.UUID) ([]model.Booking, error)
-	Update(booking *model.Booking) error
-	UpdateStatus(id uuid.UUID, status string) error
+	Create(ctx context.Context, booking *model.Booking) error
+	FindByID(ctx context.Context, id uuid.UUID) (*model.Booking, error)
+	FindByIDForUpdate(ctx context.Context, id uuid.UUID) (*model.Booking, error)
+	FindAll(ctx context.Context) ([]model.Booking, error)
+	FindByUserID(ctx context.Context, userID uuid.UUID) ([]model.Booking, error)
+	Update(ctx context.Context, booking *model.Booking) error
+	UpdateStatus(ctx context.Context, id uuid.UUID, status string) error
+	FindExpiredPendingWithoutSaga(ctx context.Context, now time.Time, limit int) ([]model.Booking, error)
 	WithTx(tx *gorm.DB) BookingRepository
 }
 
@@ -27,22 +30,22 @@ func NewBookingRepository(db *gorm.DB) BookingRepository {
 	return &bookingRepository{db: db}
 }
 
-func (r *bookingRepository) Create(booking *model.Booking) error {
-	return r.db.Create(booking).Error
+func (r *bookingRepository) Create(ctx context.Context, booking *model.Booking) error {
+	return r.db.WithContext(ctx).Create(booking).Error
 }
 
-func (r *bookingRepository) FindByID(id uuid.UUID) (*model.Booking, error) {
+func (r *bookingRepository) FindByID(ctx context.Context, id uuid.UUID) (*model.Booking, error) {
 	var booking model.Booking
-	err := r.db.Preload("Event").Preload("Ticket").First(&booking, "id = ?", id).Error
+	err := r.db.WithContext(ctx).Preload("Event").Preload("Ticket").First(&booking, "id = ?", id).Error
 	if err != nil {
 		return nil, err
 	}
 	return &booking, nil
 }
 
-func (r *bookingRepository) FindByIDForUpdate(id uuid.UUID) (*model.Booking, error) {
+func (r *bookingRepository) FindByIDForUpdate(ctx context.Context, id uuid.UUID) (*model.Booking, error) {
 	var booking model.Booking
-	err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).
+	err := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).
 		Preload("Event").Preload("Ticket").
 		First(&booking, "id = ?", id).Error
 	if err != nil {
@@ -51,24 +54,38 @@ func (r *bookingRepository) FindByIDForUpdate(id uuid.UUID) (*model.Booking, err
 	return &booking, nil
 }
 
-func (r *bookingRepository) FindAll() ([]model.Booking, error) {
+func (r *bookingRepository) FindAll(ctx context.Context) ([]model.Booking, error) {
 	var bookings []model.Booking
-	err := r.db.Preload("Event").Preload("Ticket").Find(&bookings).Error
+	err := r.db.WithContext(ctx).Preload("Event").Preload("Ticket").Find(&bookings).Error
 	return bookings, err
 }
 
-func (r *bookingRepository) FindByUserID(userID uuid.UUID) ([]model.Booking, error) {
+func (r *bookingRepository) FindByUserID(ctx context.Context, userID uuid.UUID) ([]model.Booking, error) {
 	var bookings []model.Booking
-	err := r.db.Preload("Event").Preload("Ticket").Where("user_id = ?", userID).Find(&bookings).Error
+	err := r.db.WithContext(ctx).Preload("Event").Preload("Ticket").Where("user_id = ?", userID).Find(&bookings).Error
 	return bookings, err
 }
 
-func (r *bookingRepository) Update(booking *model.Booking) error {
-	return r.db.Save(booking).Error
+func (r *bookingRepository) Update(ctx context.Context, booking *model.Booking) error {
+	return r.db.WithContext(ctx).Save(booking).Error
 }
 
-func (r *bookingRepository) UpdateStatus(id uuid.UUID, status string) error {
-	return r.db.Model(&model.Booking{}).Where("id = ?", id).Update("status", status).Error
+func (r *bookingRepository) UpdateStatus(ctx context.Context, id uuid.UUID, status string) error {
+	return r.db.WithContext(ctx).Model(&model.Booking{}).Where("id = ?", id).Update("status", status).Error
+}
+
+// FindExpiredPendingWithoutSaga returns pending bookings whose hold ran out
+// before now and that no checkout saga looks after, oldest first. They were
+// made before checkouts ran as sagas.
+func (r *bookingRepository) FindExpiredPendingWithoutSaga(ctx context.Context, now time.Time, limit int) ([]model.Booking, error) {
+	var bookings []model.Booking
+	err := r.db.WithContext(ctx).
+		Where("status = ? AND expired_at < ?", "PENDING", now).
+		Where("NOT EXISTS (SELECT 1 FROM checkout_sagas WHERE checkout_sagas.booking_id = bookings.id)").
+		Order("expired_at").
+		Limit(limit).
+		Find(&bookings).Error
+	return bookings, err
 }
 
 func (r *bookingRepository) WithTx(tx *gorm.DB) BookingRepository {
@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"booking-service/internal/model"
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CheckoutSagaFilter narrows FindAll. Zero values match everything.
+type CheckoutSagaFilter struct {
+	Status    string
+	BookingID *uuid.UUID
+	Limit     int
+}
+
+type CheckoutSagaRepository interface {
+	Create(ctx context.Context, saga *model.CheckoutSaga) error
+	FindByID(ctx context.Context, id uuid.UUID) (*model.CheckoutSaga, error)
+	FindByBookingID(ctx context.Context, bookingID uuid.UUID) (*model.CheckoutSaga, error)
+	FindAll(ctx context.Context, filter CheckoutSagaFilter) ([]model.CheckoutSaga, error)
+	FindDue(ctx context.Context, now time.Time, limit int) ([]model.CheckoutSaga, error)
+	Claim(ctx context.Context, id uuid.UUID, now, until time.Time) (bool, error)
+	Release(ctx context.Context, id uuid.UUID) error
+	Save(ctx context.Context, saga *model.CheckoutSaga) error
+	AddLog(ctx context.Context, entry *model.CheckoutSagaLog) error
+	WithTx(tx *gorm.DB) CheckoutSagaRepository
+}
+
+type checkoutSagaRepository struct {
+	db *gorm.DB
+}
+
+func NewCheckoutSagaRepository(db *gorm.DB) CheckoutSagaRepository {
+	return &checkoutSagaRepository{db: db}
+}
+
+func (r *checkoutSagaRepository) Create(ctx context.Context, saga *model.CheckoutSaga) error {
+	return r.db.WithContext(ctx).Omit("Log").Create(saga).Error
+}
+
+// FindByID includes the step log, oldest entry first
+func (r *checkoutSagaRepository) FindByID(ctx context.Context, id uuid.UUID) (*model.CheckoutSaga, error) {
+	var saga model.CheckoutSaga
+	err := r.db.WithContext(ctx).
+		Preload("Log", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		First(&saga, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &saga, nil
+}
+
+func (r *checkoutSagaRepository) FindByBookingID(ctx context.Context, bookingID uuid.UUID) (*model.CheckoutSaga, error) {
+	var saga model.CheckoutSaga
+	err := r.db.WithContext(ctx).First(&saga, "booking_id = ?", bookingID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &saga, nil
+}
+
+func (r *checkoutSagaRepository) FindAll(ctx context.Context, filter CheckoutSagaFilter) ([]model.CheckoutSaga, error) {
+	query := r.db.WithContext(ctx).Order("created_at DESC")
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.BookingID != nil {
+		query = query.Where("booking_id = ?", *filter.BookingID)
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+
+	var sagas []model.CheckoutSaga
+	err := query.Find(&sagas).Error
+	return sagas, err
+}
+
+// FindDue returns unlocked sagas with a step due to run or be compensated,
+// and waiting sagas whose booking hold has run out
+func (r *checkoutSagaRepository) FindDue(ctx context.Context, now time.Time, limit int) ([]model.CheckoutSaga, error) {
+	var sagas []model.CheckoutSaga
+	err := r.db.WithContext(ctx).
+		Where("(locked_until IS NULL OR locked_until < ?)", now).
+		Where(
+			r.db.Where("status IN ? AND next_attempt_at <= ?",
+				[]string{model.SagaStatusRunning, model.SagaStatusCompensating}, now).
+				Or("status = ? AND expires_at <= ?", model.SagaStatusWaiting, now),
+		).
+		Order("next_attempt_at").
+		Limit(limit).
+		Find(&sagas).Error
+	return sagas, err
+}
+
+// Claim locks the saga for one instance until the given time. It reports
+// false when another instance holds the lock.
+func (r *checkoutSagaRepository) Claim(ctx context.Context, id uuid.UUID, now, until time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&model.CheckoutSaga{}).
+		Where("id = ? AND (locked_until IS NULL OR locked_until < ?)", id, now).
+		Update("locked_until", until)
+	return result.RowsAffected == 1, result.Error
+}
+
+// Release drops the lock taken by Claim
+func (r *checkoutSagaRepository) Release(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Model(&model.CheckoutSaga{}).
+		Where("id = ?", id).
+		Update("locked_until", nil).Error
+}
+
+// Save stores the saga's state, including its lock
+func (r *checkoutSagaRepository) Save(ctx context.Context, saga *model.CheckoutSaga) error {
+	return r.db.WithContext(ctx).Omit("Log").Save(saga).Error
+}
+
+func (r *checkoutSagaRepository) AddLog(ctx context.Context, entry *model.CheckoutSagaLog) error {
+	return r.db.WithContext(ctx).Create(entry).Error
+}
+
+func (r *checkoutSagaRepository) WithTx(tx *gorm.DB) CheckoutSagaRepository {
+	return &checkoutSagaRepository{db: tx}
+}
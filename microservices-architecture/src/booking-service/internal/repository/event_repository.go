@@ -2,17 +2,18 @@ package repository
 
 import (
 	"booking-service/internal/model"
+	"context"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
 type EventRepository interface {
-	Create(event *model.Event) error
-	FindByID(id uuid.UUID) (*model.Event, error)
-	FindAll() ([]model.Event, error)
-	Update(event *model.Event) error
-	Delete(id uuid.UUID) error
+	Create(ctx context.Context, event *model.Event) error
+	FindByID(ctx context.Context, id uuid.UUID) (*model.Event, error)
+	FindAll(ctx context.Context) ([]model.Event, error)
+	Update(ctx context.Context, event *model.Event) error
+	Delete(ctx context.Context, id uuid.UUID) error
 }
 
 type eventRepository struct {
@@ -23,29 +24,29 @@ func NewEventRepository(db *gorm.DB) EventRepository {
 	return &eventRepository{db: db}
 }
 
-func (r *eventRepository) Create(event *model.Event) error {
-	return r.db.Create(event).Error
+func (r *eventRepository) Create(ctx context.Context, event *model.Event) error {
+	return r.db.WithContext(ctx).Create(event).Error
 }
 
-func (r *eventRepository) FindByID(id uuid.UUID) (*model.Event, error) {
+func (r *eventRepository) FindByID(ctx context.Context, id uuid.UUID) (*model.Event, error) {
 	var event model.Event
-	err := r.db.First(&event, "id = ?", id).Error
+	err := r.db.WithContext(ctx).First(&event, "id = ?", id).Error
 	if err != nil {
 		return nil, err
 	}
 	return &event, nil
 }
 
-func (r *eventRepository) FindAll() ([]model.Event, error) {
+func (r *eventRepository) FindAll(ctx context.Context) ([]model.Event, error) {
 	var events []model.Event
-	err := r.db.Find(&events).Error
+	err := r.db.WithContext(ctx).Find(&events).Error
 	return events, err
 }
 
-func (r *eventRepository) Update(event *model.Event) error {
-	return r.db.Save(event).Error
+func (r *eventRepository) Update(ctx context.Context, event *model.Event) error {
+	return r.db.WithContext(ctx).Save(event).Error
 }
 
-func (r *eventRepository) Delete(id uuid.UUID) error {
-	return r.db.Delete(&model.Event{}, "id = ?", id).Error
+func (r *eventRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Delete(&model.Event{}, "id = ?", id).Error
 }
@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"booking-service/internal/model"
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// OutboundDeliveryFilter narrows FindAll. Zero values match everything.
+type OutboundDeliveryFilter struct {
+	Status  string
+	Subject string
+	Limit   int
+}
+
+type OutboundDeliveryRepository interface {
+	Create(ctx context.Context, delivery *model.OutboundDelivery) error
+	FindByID(ctx context.Context, id uuid.UUID) (*model.OutboundDelivery, error)
+	FindAll(ctx context.Context, filter OutboundDeliveryFilter) ([]model.OutboundDelivery, error)
+	ClaimDue(ctx context.Context, now, until time.Time, limit int) ([]model.OutboundDelivery, error)
+	Claim(ctx context.Context, id uuid.UUID, now, until time.Time) (*model.OutboundDelivery, error)
+	Save(ctx context.Context, delivery *model.OutboundDelivery) error
+	WithTx(tx *gorm.DB) OutboundDeliveryRepository
+}
+
+type outboundDeliveryRepository struct {
+	db *gorm.DB
+}
+
+func NewOutboundDeliveryRepository(db *gorm.DB) OutboundDeliveryRepository {
+	return &outboundDeliveryRepository{db: db}
+}
+
+func (r *outboundDeliveryRepository) Create(ctx context.Context, delivery *model.OutboundDelivery) error {
+	return r.db.WithContext(ctx).Create(delivery).Error
+}
+
+func (r *outboundDeliveryRepository) FindByID(ctx context.Context, id uuid.UUID) (*model.OutboundDelivery, error) {
+	var delivery model.OutboundDelivery
+	if err := r.db.WithContext(ctx).First(&delivery, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+func (r *outboundDeliveryRepository) FindAll(ctx context.Context, filter OutboundDeliveryFilter) ([]model.OutboundDelivery, error) {
+	query := r.db.WithContext(ctx).Order("created_at DESC")
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.Subject != "" {
+		query = query.Where("subject = ?", filter.Subject)
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+
+	var deliveries []model.OutboundDelivery
+	err := query.Find(&deliveries).Error
+	return deliveries, err
+}
+
+// ClaimDue marks up to limit due deliveries pending, pushes their next
+// retry out to until and returns them. Deliveries left pending by an
+// instance that died mid-publish come due again the same way.
+func (r *outboundDeliveryRepository) ClaimDue(ctx context.Context, now, until time.Time, limit int) ([]model.OutboundDelivery, error) {
+	var deliveries []model.OutboundDelivery
+	err := r.db.WithContext(ctx).Raw(`UPDATE outbound_deliveries
+		SET status = ?, next_retry_at = ?, updated_at = ?
+		WHERE id IN (
+			SELECT id FROM outbound_deliveries
+			WHERE status IN ? AND next_retry_at <= ?
+			ORDER BY next_retry_at LIMIT ? FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *`,
+		model.DeliveryStatusPending, until, now,
+		[]string{model.DeliveryStatusPending, model.DeliveryStatusFailed}, now, limit,
+	).Scan(&deliveries).Error
+	return deliveries, err
+}
+
+// Claim claims one delivery whatever its status, the way ClaimDue does. A
+// delivery that is pending with its claim running past now is being
+// published elsewhere and is not claimed: Claim returns
+// gorm.ErrRecordNotFound for it, as for an unknown ID.
+func (r *outboundDeliveryRepository) Claim(ctx context.Context, id uuid.UUID, now, until time.Time) (*model.OutboundDelivery, error) {
+	var deliveries []model.OutboundDelivery
+	err := r.db.WithContext(ctx).Raw(`UPDATE outbound_deliveries
+		SET status = ?, next_retry_at = ?, updated_at = ?
+		WHERE id IN (
+			SELECT id FROM outbound_deliveries
+			WHERE id = ? AND NOT (status = ? AND next_retry_at > ?)
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *`,
+		model.DeliveryStatusPending, until, now,
+		id, model.DeliveryStatusPending, now,
+	).Scan(&deliveries).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(deliveries) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &deliveries[0], nil
+}
+
+func (r *outboundDeliveryRepository) Save(ctx context.Context, delivery *model.OutboundDelivery) error {
+	return r.db.WithContext(ctx).Save(delivery).Error
+}
+
+func (r *outboundDeliveryRepository) WithTx(tx *gorm.DB) OutboundDeliveryRepository {
+	return &outboundDeliveryRepository{db: tx}
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"booking-service/internal/model"
+	"context"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
@@ -9,15 +10,15 @@ import (
 )
 
 type TicketRepository interface {
-	Create(ticket *model.Ticket) error
-	FindByID(id uuid.UUID) (*model.Ticket, error)
-	FindByIDForUpdate(id uuid.UUID) (*model.Ticket, error)
-	FindByEventID(eventID uuid.UUID) ([]model.Ticket, error)
-	FindAll() ([]model.Ticket, error)
-	Update(ticket *model.Ticket) error
-	Delete(id uuid.UUID) error
-	ReduceQuota(id uuid.UUID, quantity int) error
-	IncreaseQuota(id uuid.UUID, quantity int) error
+	Create(ctx context.Context, ticket *model.Ticket) error
+	FindByID(ctx context.Context, id uuid.UUID) (*model.Ticket, error)
+	FindByIDForUpdate(ctx context.Context, id uuid.UUID) (*model.Ticket, error)
+	FindByEventID(ctx context.Context, eventID uuid.UUID) ([]model.Ticket, error)
+	FindAll(ctx context.Context) ([]model.Ticket, error)
+	Update(ctx context.Context, ticket *model.Ticket) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	ReduceQuota(ctx context.Context, id uuid.UUID, quantity int) error
+	IncreaseQuota(ctx context.Context, id uuid.UUID, quantity int) error
 	WithTx(tx *gorm.DB) TicketRepository
 }
 
@@ -29,22 +30,22 @@ func NewTicketRepository(db *gorm.DB) TicketRepository {
 	return &ticketRepository{db: db}
 }
 
-func (r *ticketRepository) Create(ticket *model.Ticket) error {
-	return r.db.Create(ticket).Error
+func (r *ticketRepository) Create(ctx context.Context, ticket *model.Ticket) error {
+	return r.db.WithContext(ctx).Create(ticket).Error
 }
 
-func (r *ticketRepository) FindByID(id uuid.UUID) (*model.Ticket, error) {
+func (r *ticketRepository) FindByID(ctx context.Context, id uuid.UUID) (*model.Ticket, error) {
 	var ticket model.Ticket
-	err := r.db.Preload("Event").First(&ticket, "id = ?", id).Error
+	err := r.db.WithContext(ctx).Preload("Event").First(&ticket, "id = ?", id).Error
 	if err != nil {
 		return nil, err
 	}
 	return &ticket, nil
 }
 
-func (r *ticketRepository) FindByIDForUpdate(id uuid.UUID) (*model.Ticket, error) {
+func (r *ticketRepository) FindByIDForUpdate(ctx context.Context, id uuid.UUID) (*model.Ticket, error) {
 	var ticket model.Ticket
-	err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).
+	err := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).
 		Preload("Event").
 		First(&ticket, "id = ?", id).Error
 	if err != nil {
@@ -53,33 +54,33 @@ func (r *ticketRepository) FindByIDForUpdate(id uuid.UUID) (*model.Ticket, error
 	return &ticket, nil
 }
 
-func (r *ticketRepository) FindByEventID(eventID uuid.UUID) ([]model.Ticket, error) {
+func (r *ticketRepository) FindByEventID(ctx context.Context, eventID uuid.UUID) ([]model.Ticket, error) {
 	var tickets []model.Ticket
-	err := r.db.Where("event_id = ?", eventID).Find(&tickets).Error
+	err := r.db.WithContext(ctx).Where("event_id = ?", eventID).Find(&tickets).Error
 	return tickets, err
 }
 
-func (r *ticketRepository) FindAll() ([]model.Ticket, error) {
+func (r *ticketRepository) FindAll(ctx context.Context) ([]model.Ticket, error) {
 	var tickets []model.Ticket
-	err := r.db.Preload("Event").Find(&tickets).Error
+	err := r.db.WithContext(ctx).Preload("Event").Find(&tickets).Error
 	return tickets, err
 }
 
-func (r *ticketRepository) Update(ticket *model.Ticket) error {
-	return r.db.Save(ticket).Error
+func (r *ticketRepository) Update(ctx context.Context, ticket *model.Ticket) error {
+	return r.db.WithContext(ctx).Save(ticket).Error
 }
 
-func (r *ticketRepository) Delete(id uuid.UUID) error {
-	return r.db.Delete(&model.Ticket{}, "id = ?", id).Error
+func (r *ticketRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Delete(&model.Ticket{}, "id = ?", id).Error
 }
 
-func (r *ticketRepository) ReduceQuota(id uuid.UUID, quantity int) error {
-	return r.db.Model(&model.Ticket{}).Where("id = ?", id).
+func (r *ticketRepository) ReduceQuota(ctx context.Context, id uuid.UUID, quantity int) error {
+	return r.db.WithContext(ctx).Model(&model.Ticket{}).Where("id = ?", id).
 		Update("quota", gorm.Expr("quota - ?", quantity)).Error
 }
 
-func (r *ticketRepository) IncreaseQuota(id uuid.UUID, quantity int) error {
-	return r.db.Model(&model.Ticket{}).Where("id = ?", id).
+func (r *ticketRepository) IncreaseQuota(ctx context.Context, id uuid.UUID, quantity int) error {
+	return r.db.WithContext(ctx).Model(&model.Ticket{}).Where("id = ?", id).
 		Update("quota", gorm.Expr("quota + ?", quantity)).Error
 }
 
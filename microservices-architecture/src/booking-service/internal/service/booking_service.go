@@ -2,20 +2,42 @@ package service
 
 import (
 	"booking-service/internal/client"
+	"booking-service/internal/metrics"
 	"booking-service/internal/model"
 	"booking-service/internal/repository"
+	"context"
 	"errors"
+	"log/slog"
 	"time"
 
+	"platform/eventbus"
+	"platform/logging"
+	"platform/tracing"
+
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
+// expiryBatchSize bounds the bookings expired per ExpireBookings call
+const expiryBatchSize = 100
+
+var (
+	ErrBookingNotFound   = errors.New("booking not found")
+	ErrBookingNotPending = errors.New("booking is not pending")
+)
+
 type BookingService interface {
-	CreateBooking(userID uuid.UUID, eventID uuid.UUID, ticketID uuid.UUID, quantity int) (*model.BookingResponse, error)
-	GetBookingByID(id uuid.UUID) (*model.BookingResponse, error)
-	GetAllBookings() ([]model.BookingResponse, error)
-	UpdateBookingStatus(id uuid.UUID, status string) error
+	GetBookingByID(ctx context.Context, id uuid.UUID) (*model.BookingResponse, error)
+	GetAllBookings(ctx context.Context) ([]model.BookingResponse, error)
+	UpdateBookingStatus(ctx context.Context, id uuid.UUID, status string) error
+	// HandlePaymentResult confirms or cancels a booking made before
+	// checkouts ran as sagas. An event handled before gets
+	// ErrDuplicateEvent.
+	HandlePaymentResult(ctx context.Context, eventID, id uuid.UUID, paid bool) error
+	// ExpireBookings cancels pending bookings made before checkouts ran as
+	// sagas once their hold runs out, giving their tickets back. It returns
+	// how many were cancelled.
+	ExpireBookings(ctx context.Context) (int, error)
 }
 
 type bookingService struct {
@@ -23,9 +45,10 @@ type bookingService struct {
 	bookingRepo   repository.BookingRepository
 	ticketRepo    repository.TicketRepository
 	eventRepo     repository.EventRepository
+	processedRepo repository.ProcessedMessageRepository
 	userClient    client.UserClient
 	paymentClient client.PaymentClient
-	webhookClient client.WebhookClient
+	bus           eventbus.Publisher
 }
 
 func NewBookingService(
@@ -33,82 +56,32 @@ func NewBookingService(
 	bookingRepo repository.BookingRepository,
 	ticketRepo repository.TicketRepository,
 	eventRepo repository.EventRepository,
+	processedRepo repository.ProcessedMessageRepository,
 	userClient client.UserClient,
 	paymentClient client.PaymentClient,
-	webhookClient client.WebhookClient,
+	bus eventbus.Publisher,
 ) BookingService {
 	return &bookingService{
 		db:            db,
 		bookingRepo:   bookingRepo,
 		ticketRepo:    ticketRepo,
 		eventRepo:     eventRepo,
+		processedRepo: processedRepo,
 		userClient:    userClient,
 		paymentClient: paymentClient,
-		webhookClient: webhookClient,
+		bus:           bus,
 	}
 }
 
-func (s *bookingService) CreateBooking(userID uuid.UUID, eventID uuid.UUID, ticketID uuid.UUID, quantity int) (*model.BookingResponse, error) {
-	if quantity <= 0 {
-		return nil, errors.New("quantity must be greater than 0")
+func (s *bookingService) GetBookingByID(ctx context.Context, id uuid.UUID) (*model.BookingResponse, error) {
+	booking, err := s.bookingRepo.FindByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrBookingNotFound
 	}
-
-	event, err := s.eventRepo.FindByID(eventID)
-	if err != nil {
-		return nil, errors.New("event not found")
-	}
-
-	var booking *model.Booking
-	var ticket *model.Ticket
-
-	err = s.db.Transaction(func(tx *gorm.DB) error {
-		ticketRepoTx := s.ticketRepo.WithTx(tx)
-		bookingRepoTx := s.bookingRepo.WithTx(tx)
-
-		ticket, err = ticketRepoTx.FindByIDForUpdate(ticketID)
-		if err != nil {
-			return errors.New("ticket not found")
-		}
-
-		if ticket.EventID != eventID {
-			return errors.New("ticket does not belong to the specified event")
-		}
-
-		if ticket.Quota < quantity {
-			return errors.New("insufficient ticket quota")
-		}
-
-		totalAmount := ticket.Price * float64(quantity)
-		expiredAt := time.Now().Add(15 * time.Minute)
-
-		booking = &model.Booking{
-			UserID:      userID,
-			EventID:     eventID,
-			TicketID:    ticketID,
-			Quantity:    quantity,
-			TotalAmount: totalAmount,
-			Status:      "PENDING",
-			ExpiredAt:   &expiredAt,
-		}
-
-		if err := bookingRepoTx.Create(booking); err != nil {
-			return err
-		}
-
-		if err := ticketRepoTx.ReduceQuota(ticketID, quantity); err != nil {
-			return err
-		}
-
-		return nil
-	})
-
 	if err != nil {
 		return nil, err
 	}
 
-	booking.Event = *event
-	booking.Ticket = *ticket
-
 	return &model.BookingResponse{
 		ID:          booking.ID,
 		UserID:      booking.UserID,
@@ -122,27 +95,8 @@ func (s *bookingService) CreateBooking(userID uuid.UUID, eventID uuid.UUID, tick
 	}, nil
 }
 
-func (s *bookingService) GetBookingByID(id uuid.UUID) (*model.BookingResponse, error) {
-	booking, err := s.bookingRepo.FindByID(id)
-	if err != nil {
-		return nil, errors.New("booking not found")
-	}
-
-	return &model.BookingResponse{
-		ID:          booking.ID,
-		UserID:      booking.UserID,
-		EventID:     booking.EventID,
-		TicketID:    booking.TicketID,
-		Quantity:    booking.Quantity,
-		TotalAmount: booking.TotalAmount,
-		Status:      booking.Status,
-		ExpiredAt:   booking.ExpiredAt,
-		CreatedAt:   booking.CreatedAt,
-	}, nil
-}
-
-func (s *bookingService) GetAllBookings() ([]model.BookingResponse, error) {
-	bookings, err := s.bookingRepo.FindAll()
+func (s *bookingService) GetAllBookings(ctx context.Context) ([]model.BookingResponse, error) {
+	bookings, err := s.bookingRepo.FindAll(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -165,41 +119,109 @@ func (s *bookingService) GetAllBookings() ([]model.BookingResponse, error) {
 	return response, nil
 }
 
-func (s *bookingService) UpdateBookingStatus(id uuid.UUID, status string) error {
-	return s.db.Transaction(func(tx *gorm.DB) error {
-		bookingRepoTx := s.bookingRepo.WithTx(tx)
-		ticketRepoTx := s.ticketRepo.WithTx(tx)
+func (s *bookingService) UpdateBookingStatus(ctx context.Context, id uuid.UUID, status string) error {
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return s.updateStatus(ctx, tx, id, status)
+	})
+	if err != nil {
+		return err
+	}
+	metrics.BookingTransition("PENDING", status, metrics.ReasonStatusUpdate)
+	return nil
+}
+
+func (s *bookingService) HandlePaymentResult(ctx context.Context, eventID, id uuid.UUID, paid bool) error {
+	subject, status, reason := eventbus.SubjectPaymentFailed, "CANCELLED", metrics.ReasonPaymentFailed
+	if paid {
+		subject, status, reason = eventbus.SubjectPaymentSuccess, "CONFIRMED", metrics.ReasonPaid
+	}
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := markProcessed(ctx, s.processedRepo.WithTx(tx), eventID, subject); err != nil {
+			return err
+		}
+		return s.updateStatus(ctx, tx, id, status)
+	})
+	if err != nil {
+		return err
+	}
+	metrics.BookingTransition("PENDING", status, reason)
+	return nil
+}
+
+func (s *bookingService) ExpireBookings(ctx context.Context) (int, error) {
+	bookings, err := s.bookingRepo.FindExpiredPendingWithoutSaga(ctx, time.Now(), expiryBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for _, booking := range bookings {
+		// Publish first: if cancelling fails the next run publishes again,
+		// which payment service ignores for payments no longer pending
+		event := eventbus.BookingEvent{
+			EventID:   uuid.New(),
+			BookingID: booking.ID,
+			RequestID: logging.RequestID(ctx),
+			Trace:     tracing.Inject(ctx),
+		}
+		if err := s.bus.Publish(ctx, eventbus.SubjectBookingExpired, event); err != nil {
+			return expired, err
+		}
 
-		booking, err := bookingRepoTx.FindByIDForUpdate(id)
+		err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			return s.updateStatus(ctx, tx, booking.ID, "CANCELLED")
+		})
+		if errors.Is(err, ErrBookingNotPending) {
+			// Confirmed or cancelled since it was read
+			continue
+		}
 		if err != nil {
-			return errors.New("booking not found")
+			slog.ErrorContext(ctx, "Failed to expire booking", "booking_id", booking.ID, "error", err)
+			continue
 		}
+		metrics.BookingTransition("PENDING", "CANCELLED", metrics.ReasonExpired)
+		expired++
+	}
+
+	return expired, nil
+}
 
-		if booking.Status != "PENDING" {
-			return errors.New("booking is not pending")
+// updateStatus moves a pending booking to status within tx, giving the
+// tickets of a cancelled one back
+func (s *bookingService) updateStatus(ctx context.Context, tx *gorm.DB, id uuid.UUID, status string) error {
+	bookingRepoTx := s.bookingRepo.WithTx(tx)
+	ticketRepoTx := s.ticketRepo.WithTx(tx)
+
+	booking, err := bookingRepoTx.FindByIDForUpdate(ctx, id)
+	if err != nil {
+		return ErrBookingNotFound
+	}
+
+	if booking.Status != "PENDING" {
+		return ErrBookingNotPending
+	}
+
+	switch status {
+	case "CONFIRMED":
+		if err := bookingRepoTx.UpdateStatus(ctx, id, "CONFIRMED"); err != nil {
+			return err
+		}
+	case "CANCELLED":
+		if _, err := ticketRepoTx.FindByIDForUpdate(ctx, booking.TicketID); err != nil {
+			return errors.New("ticket not found")
+		}
+
+		if err := bookingRepoTx.UpdateStatus(ctx, id, "CANCELLED"); err != nil {
+			return err
 		}
 
-		switch status {
-		case "CONFIRMED":
-			if err := bookingRepoTx.UpdateStatus(id, "CONFIRMED"); err != nil {
-				return err
-			}
-		case "CANCELLED":
-			if _, err := ticketRepoTx.FindByIDForUpdate(booking.TicketID); err != nil {
-				return errors.New("ticket not found")
-			}
-
-			if err := bookingRepoTx.UpdateStatus(id, "CANCELLED"); err != nil {
-				return err
-			}
-
-			if err := ticketRepoTx.IncreaseQuota(booking.TicketID, booking.Quantity); err != nil {
-				return err
-			}
-		default:
-			return errors.New("invalid status")
+		if err := ticketRepoTx.IncreaseQuota(ctx, booking.TicketID, booking.Quantity); err != nil {
+			return err
 		}
+	default:
+		return errors.New("invalid status")
+	}
 
-		return nil
-	})
+	return nil
 }
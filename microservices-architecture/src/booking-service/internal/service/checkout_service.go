@@ -0,0 +1,718 @@
+package service
+
+import (
+	"booking-service/internal/client"
+	"booking-service/internal/metrics"
+	"booking-service/internal/model"
+	"booking-service/internal/repository"
+	"context"
+	"errors"
+	"log/slog"
+	"sdk"
+	"time"
+
+	"platform/eventbus"
+	"platform/logging"
+	"platform/tracing"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// resumeBatchSize bounds the sagas picked up per ResumeDue call
+const resumeBatchSize = 50
+
+// maxRetryDelay caps the backoff between attempts at a step
+const maxRetryDelay = 5 * time.Minute
+
+// Failure reasons recorded on compensated sagas
+const (
+	reasonHoldExpired   = "booking hold expired"
+	reasonPaymentFailed = "payment failed"
+)
+
+var (
+	ErrSagaNotFound         = errors.New("checkout not found")
+	ErrSagaBusy             = errors.New("checkout is being processed, please try again")
+	ErrCheckoutStarted      = errors.New("checkout has already started for this booking")
+	ErrHoldExpired          = errors.New("booking hold has expired")
+	ErrInvalidPaymentMethod = errors.New("invalid payment method. Allowed: VA, EWALLET, QRIS")
+)
+
+var validPaymentMethods = map[string]bool{"VA": true, "EWALLET": true, "QRIS": true}
+
+// previousStep is the step compensated after each step, walking the saga
+// backwards
+var previousStep = map[string]string{
+	model.SagaStepConfirm:       model.SagaStepAwaitPayment,
+	model.SagaStepAwaitPayment:  model.SagaStepCreatePayment,
+	model.SagaStepCreatePayment: model.SagaStepReserveQuota,
+}
+
+type CheckoutConfig struct {
+	HoldDuration   time.Duration // how long tickets are held for payment
+	MaxAttempts    int           // attempts at creating the payment before giving up
+	RetryDelay     time.Duration // doubled after every failed attempt
+	LockDuration   time.Duration // how long an instance may work on a saga
+	ResumeInterval time.Duration // how often ResumeDue should run
+}
+
+// LoadCheckoutConfig reads the SAGA_* settings from the environment
+func LoadCheckoutConfig() CheckoutConfig {
+	return CheckoutConfig{
+		HoldDuration:   getEnvDuration("SAGA_HOLD_DURATION", 15*time.Minute),
+		MaxAttempts:    getEnvInt("SAGA_MAX_ATTEMPTS", 5),
+		RetryDelay:     getEnvDuration("SAGA_RETRY_DELAY", 5*time.Second),
+		LockDuration:   getEnvDuration("SAGA_LOCK_DURATION", time.Minute),
+		ResumeInterval: getEnvDuration("SAGA_RESUME_INTERVAL", 10*time.Second),
+	}
+}
+
+// CheckoutService runs the checkout saga: reserve quota, create payment,
+// await payment, confirm. A failed step is compensated together with every
+// step before it, so an unpaid booking always gives its tickets back.
+type CheckoutService interface {
+	// Start reserves the tickets and creates the booking. With a payment
+	// method the payment is created right away, otherwise the saga waits
+	// for Checkout.
+	Start(ctx context.Context, userID, eventID, ticketID uuid.UUID, quantity int, paymentMethod string) (*model.CheckoutSaga, error)
+	Checkout(ctx context.Context, bookingID, userID uuid.UUID, paymentMethod string) (*model.CheckoutSaga, error)
+	GetPayment(ctx context.Context, saga *model.CheckoutSaga) (*sdk.Payment, error)
+	HandlePaymentResult(ctx context.Context, eventID, bookingID, paymentID uuid.UUID, paid bool) error
+	ResumeDue(ctx context.Context) (int, error)
+	GetSaga(ctx context.Context, id uuid.UUID) (*model.CheckoutSaga, error)
+	ListSagas(ctx context.Context, filter repository.CheckoutSagaFilter) ([]model.CheckoutSaga, error)
+}
+
+type checkoutService struct {
+	db            *gorm.DB
+	bookingRepo   repository.BookingRepository
+	ticketRepo    repository.TicketRepository
+	eventRepo     repository.EventRepository
+	sagaRepo      repository.CheckoutSagaRepository
+	processedRepo repository.ProcessedMessageRepository
+	paymentClient client.PaymentClient
+	bus           DeliveryService
+	config        CheckoutConfig
+}
+
+func NewCheckoutService(
+	db *gorm.DB,
+	bookingRepo repository.BookingRepository,
+	ticketRepo repository.TicketRepository,
+	eventRepo repository.EventRepository,
+	sagaRepo repository.CheckoutSagaRepository,
+	processedRepo repository.ProcessedMessageRepository,
+	paymentClient client.PaymentClient,
+	bus DeliveryService,
+	config CheckoutConfig,
+) CheckoutService {
+	return &checkoutService{
+		db:            db,
+		bookingRepo:   bookingRepo,
+		ticketRepo:    ticketRepo,
+		eventRepo:     eventRepo,
+		sagaRepo:      sagaRepo,
+		processedRepo: processedRepo,
+		paymentClient: paymentClient,
+		bus:           bus,
+		config:        config,
+	}
+}
+
+func (s *checkoutService) Start(ctx context.Context, userID, eventID, ticketID uuid.UUID, quantity int, paymentMethod string) (*model.CheckoutSaga, error) {
+	if quantity <= 0 {
+		return nil, errors.New("quantity must be greater than 0")
+	}
+
+	if paymentMethod != "" && !validPaymentMethods[paymentMethod] {
+		return nil, ErrInvalidPaymentMethod
+	}
+
+	if _, err := s.eventRepo.FindByID(ctx, eventID); err != nil {
+		return nil, errors.New("event not found")
+	}
+
+	now := time.Now()
+	saga := &model.CheckoutSaga{
+		ID:            uuid.New(),
+		BookingID:     uuid.New(),
+		UserID:        userID,
+		PaymentMethod: paymentMethod,
+		Status:        model.SagaStatusWaiting,
+		Step:          model.SagaStepCreatePayment,
+		NextAttemptAt: now,
+		ExpiresAt:     now.Add(s.config.HoldDuration),
+	}
+	if paymentMethod != "" {
+		// Claimed from the start, so no other instance runs it before we do
+		lockedUntil := now.Add(s.config.LockDuration)
+		saga.Status = model.SagaStatusRunning
+		saga.LockedUntil = &lockedUntil
+	}
+
+	// Reserving the quota commits together with the saga, so a crash
+	// cannot leave tickets held by a booking nobody is checking out
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		ticketRepoTx := s.ticketRepo.WithTx(tx)
+		bookingRepoTx := s.bookingRepo.WithTx(tx)
+		sagaRepoTx := s.sagaRepo.WithTx(tx)
+
+		ticket, err := ticketRepoTx.FindByIDForUpdate(ctx, ticketID)
+		if err != nil {
+			return errors.New("ticket not found")
+		}
+
+		if ticket.EventID != eventID {
+			return errors.New("ticket does not belong to the specified event")
+		}
+
+		if ticket.Quota < quantity {
+			return errors.New("insufficient ticket quota")
+		}
+
+		saga.Amount = ticket.Price * float64(quantity)
+
+		booking := &model.Booking{
+			ID:          saga.BookingID,
+			UserID:      userID,
+			EventID:     eventID,
+			TicketID:    ticketID,
+			Quantity:    quantity,
+			TotalAmount: saga.Amount,
+			Status:      "PENDING",
+			ExpiredAt:   &saga.ExpiresAt,
+		}
+
+		if err := bookingRepoTx.Create(ctx, booking); err != nil {
+			return err
+		}
+
+		if err := ticketRepoTx.ReduceQuota(ctx, ticketID, quantity); err != nil {
+			return err
+		}
+
+		if err := sagaRepoTx.Create(ctx, saga); err != nil {
+			return err
+		}
+
+		return s.record(ctx, sagaRepoTx, saga, model.SagaStepReserveQuota, model.SagaActionExecute, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+	metrics.BookingTransition("", "PENDING", metrics.ReasonCreated)
+
+	if saga.Status == model.SagaStatusRunning {
+		if err := s.run(ctx, saga); err != nil {
+			// The saga is saved; ResumeDue picks it up again
+			slog.WarnContext(ctx, "checkout interrupted", "saga_id", saga.ID, "error", err)
+		}
+	}
+
+	return saga, nil
+}
+
+// Checkout creates the payment of a saga started without a payment method.
+// Repeating it with the same method once the payment exists returns the
+// saga unchanged.
+func (s *checkoutService) Checkout(ctx context.Context, bookingID, userID uuid.UUID, paymentMethod string) (*model.CheckoutSaga, error) {
+	if !validPaymentMethods[paymentMethod] {
+		return nil, ErrInvalidPaymentMethod
+	}
+
+	saga, err := s.claim(ctx, bookingID)
+	if err != nil {
+		return nil, err
+	}
+
+	if saga.UserID != userID {
+		s.release(ctx, saga)
+		return nil, ErrSagaNotFound
+	}
+
+	if saga.Step == model.SagaStepAwaitPayment && saga.Status == model.SagaStatusWaiting &&
+		saga.PaymentMethod == paymentMethod {
+		s.release(ctx, saga)
+		return saga, nil
+	}
+
+	if saga.Step != model.SagaStepCreatePayment || saga.Status != model.SagaStatusWaiting {
+		s.release(ctx, saga)
+		return nil, ErrCheckoutStarted
+	}
+
+	if !time.Now().Before(saga.ExpiresAt) {
+		// run compensates the saga
+		if err := s.run(ctx, saga); err != nil {
+			slog.WarnContext(ctx, "checkout interrupted", "saga_id", saga.ID, "error", err)
+		}
+		return nil, ErrHoldExpired
+	}
+
+	saga.PaymentMethod = paymentMethod
+	saga.Status = model.SagaStatusRunning
+	saga.NextAttemptAt = time.Now()
+
+	if err := s.run(ctx, saga); err != nil {
+		slog.WarnContext(ctx, "checkout interrupted", "saga_id", saga.ID, "error", err)
+	}
+
+	return saga, nil
+}
+
+// GetPayment returns the payment created by the saga, or nil if it has not
+// been created yet
+func (s *checkoutService) GetPayment(ctx context.Context, saga *model.CheckoutSaga) (*sdk.Payment, error) {
+	if saga.PaymentID == nil {
+		return nil, nil
+	}
+	return s.paymentClient.GetPaymentStatus(ctx, saga.PaymentID.String())
+}
+
+// HandlePaymentResult moves the saga on once payment service reports the
+// outcome of its payment. It returns ErrSagaNotFound for bookings made
+// before checkouts ran as sagas, and ErrSagaBusy while another instance
+// works on the saga, in which case the event should be redelivered. An
+// event handled before gets ErrDuplicateEvent.
+func (s *checkoutService) HandlePaymentResult(ctx context.Context, eventID, bookingID, paymentID uuid.UUID, paid bool) error {
+	saga, err := s.claim(ctx, bookingID)
+	if err != nil {
+		return err
+	}
+
+	subject := eventbus.SubjectPaymentFailed
+	if paid {
+		subject = eventbus.SubjectPaymentSuccess
+	}
+
+	// The event is marked processed together with its effect on the saga.
+	// Work on a copy so a rolled back transaction leaves saga untouched.
+	updated := *saga
+	var refund *model.OutboundDelivery
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		sagaRepoTx := s.sagaRepo.WithTx(tx)
+
+		if err := markProcessed(ctx, s.processedRepo.WithTx(tx), eventID, subject); err != nil {
+			return err
+		}
+
+		switch {
+		case updated.Status == model.SagaStatusCompensating || updated.Status == model.SagaStatusCompensated:
+			if paid {
+				// Paid after the checkout gave up: cancel again so the
+				// payment is refunded. The event is only sent if the
+				// transaction commits.
+				event := eventbus.BookingEvent{
+					EventID:   uuid.New(),
+					BookingID: updated.BookingID,
+					RequestID: logging.RequestID(ctx),
+					Trace:     tracing.Inject(ctx),
+				}
+				delivery, err := s.bus.Record(ctx, tx, eventbus.SubjectBookingCancelled, event)
+				if err != nil {
+					return err
+				}
+				refund = delivery
+			}
+
+		case updated.Step == model.SagaStepCreatePayment || updated.Step == model.SagaStepAwaitPayment:
+			// The event may overtake the response of create_payment
+			if updated.PaymentID == nil {
+				updated.PaymentID = &paymentID
+			}
+			if !paid {
+				if err := s.record(ctx, sagaRepoTx, &updated, model.SagaStepAwaitPayment, model.SagaActionExecute, errors.New(reasonPaymentFailed)); err != nil {
+					return err
+				}
+				s.startCompensation(&updated, model.SagaStepAwaitPayment, reasonPaymentFailed)
+				return sagaRepoTx.Save(ctx, &updated)
+			}
+			return s.advance(ctx, sagaRepoTx, &updated, model.SagaStepAwaitPayment, model.SagaStepConfirm, model.SagaStatusRunning)
+		}
+
+		return nil
+	})
+	if err != nil {
+		s.release(ctx, saga)
+		return err
+	}
+	*saga = updated
+
+	if refund != nil {
+		s.bus.Send(ctx, refund)
+	}
+	return s.run(ctx, saga)
+}
+
+// ResumeDue runs every saga that has a step due, is waiting past its hold
+// or was left behind by a crashed instance. It returns the number of sagas
+// run.
+func (s *checkoutService) ResumeDue(ctx context.Context) (int, error) {
+	sagas, err := s.sagaRepo.FindDue(ctx, time.Now(), resumeBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	resumed := 0
+	for _, due := range sagas {
+		saga, err := s.claim(ctx, due.BookingID)
+		if errors.Is(err, ErrSagaBusy) {
+			continue
+		}
+		if err != nil {
+			return resumed, err
+		}
+
+		if err := s.run(ctx, saga); err != nil {
+			slog.WarnContext(ctx, "checkout interrupted", "saga_id", saga.ID, "error", err)
+			continue
+		}
+		resumed++
+	}
+
+	return resumed, nil
+}
+
+func (s *checkoutService) GetSaga(ctx context.Context, id uuid.UUID) (*model.CheckoutSaga, error) {
+	saga, err := s.sagaRepo.FindByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSagaNotFound
+		}
+		return nil, err
+	}
+	return saga, nil
+}
+
+func (s *checkoutService) ListSagas(ctx context.Context, filter repository.CheckoutSagaFilter) ([]model.CheckoutSaga, error) {
+	return s.sagaRepo.FindAll(ctx, filter)
+}
+
+// claim locks the booking's saga for this instance and returns its current
+// state
+func (s *checkoutService) claim(ctx context.Context, bookingID uuid.UUID) (*model.CheckoutSaga, error) {
+	saga, err := s.sagaRepo.FindByBookingID(ctx, bookingID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSagaNotFound
+		}
+		return nil, err
+	}
+
+	now := time.Now()
+	claimed, err := s.sagaRepo.Claim(ctx, saga.ID, now, now.Add(s.config.LockDuration))
+	if err != nil {
+		return nil, err
+	}
+	if !claimed {
+		return nil, ErrSagaBusy
+	}
+
+	// Read again, the saga may have moved on before it was claimed
+	return s.sagaRepo.FindByBookingID(ctx, bookingID)
+}
+
+// release gives up the claim on the saga without storing any of its state
+func (s *checkoutService) release(ctx context.Context, saga *model.CheckoutSaga) {
+	saga.LockedUntil = nil
+	if err := s.sagaRepo.Release(context.WithoutCancel(ctx), saga.ID); err != nil {
+		slog.ErrorContext(ctx, "failed to release checkout", "saga_id", saga.ID, "error", err)
+	}
+}
+
+// unlock stores the saga's state and gives up the claim on it
+func (s *checkoutService) unlock(ctx context.Context, saga *model.CheckoutSaga) error {
+	saga.LockedUntil = nil
+	return s.sagaRepo.Save(ctx, saga)
+}
+
+// run advances a claimed saga until it finishes, waits for something or
+// backs off after a failure, saving it after every step. The claim is
+// given up when it returns.
+func (s *checkoutService) run(ctx context.Context, saga *model.CheckoutSaga) error {
+	// Carry on when the caller goes away, but not past the claim
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), s.config.LockDuration)
+	defer cancel()
+
+	for {
+		now := time.Now()
+
+		switch {
+		case saga.IsFinished():
+			return s.unlock(ctx, saga)
+		case saga.Status == model.SagaStatusWaiting && now.Before(saga.ExpiresAt):
+			return s.unlock(ctx, saga)
+		case saga.Status == model.SagaStatusWaiting:
+			if err := s.record(ctx, s.sagaRepo, saga, saga.Step, model.SagaActionExecute, ErrHoldExpired); err != nil {
+				s.release(ctx, saga)
+				return err
+			}
+			s.startCompensation(saga, saga.Step, reasonHoldExpired)
+		case saga.NextAttemptAt.After(now):
+			return s.unlock(ctx, saga)
+		case saga.Status == model.SagaStatusRunning:
+			if err := s.execute(ctx, saga); err != nil {
+				s.release(ctx, saga)
+				return err
+			}
+		case saga.Status == model.SagaStatusCompensating:
+			if err := s.compensate(ctx, saga); err != nil {
+				s.release(ctx, saga)
+				return err
+			}
+		}
+
+		if err := s.sagaRepo.Save(ctx, saga); err != nil {
+			s.release(ctx, saga)
+			return err
+		}
+	}
+}
+
+// execute runs the saga's current step. Failures of the step itself are
+// recorded on the saga; the returned error means the saga could not be
+// stored.
+func (s *checkoutService) execute(ctx context.Context, saga *model.CheckoutSaga) error {
+	switch saga.Step {
+	case model.SagaStepCreatePayment:
+		payment, err := s.paymentClient.CreatePayment(ctx, saga.BookingID, saga.Amount, saga.PaymentMethod)
+		if err != nil {
+			return s.stepFailed(ctx, saga, err, isRetryable(err))
+		}
+		saga.PaymentID = &payment.ID
+		return s.advance(ctx, s.sagaRepo, saga, model.SagaStepCreatePayment, model.SagaStepAwaitPayment, model.SagaStatusWaiting)
+
+	case model.SagaStepConfirm:
+		// Work on a copy so a rolled back transaction leaves saga untouched
+		confirmed := *saga
+		transitioned := false
+		err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			bookingRepoTx := s.bookingRepo.WithTx(tx)
+
+			booking, err := bookingRepoTx.FindByIDForUpdate(ctx, saga.BookingID)
+			if err != nil {
+				return err
+			}
+
+			switch booking.Status {
+			case "CONFIRMED":
+			case "PENDING":
+				if err := bookingRepoTx.UpdateStatus(ctx, booking.ID, "CONFIRMED"); err != nil {
+					return err
+				}
+				transitioned = true
+			default:
+				return ErrBookingNotPending
+			}
+
+			return s.advance(ctx, s.sagaRepo.WithTx(tx), &confirmed, model.SagaStepConfirm, model.SagaStepConfirm, model.SagaStatusCompleted)
+		})
+		if err != nil {
+			// A booking cancelled some other way cannot be confirmed any
+			// more; compensating refunds the payment
+			return s.stepFailed(ctx, saga, err, !errors.Is(err, ErrBookingNotPending))
+		}
+		if transitioned {
+			metrics.BookingTransition("PENDING", "CONFIRMED", metrics.ReasonPaid)
+		}
+		*saga = confirmed
+		return nil
+	}
+
+	// await_payment only moves on through HandlePaymentResult
+	saga.Status = model.SagaStatusWaiting
+	return nil
+}
+
+// compensate undoes the saga's current step and moves on to the one before
+func (s *checkoutService) compensate(ctx context.Context, saga *model.CheckoutSaga) error {
+	switch saga.Step {
+	case model.SagaStepCreatePayment:
+		// Payment service expires or refunds the payment, if it was created
+		subject := eventbus.SubjectBookingCancelled
+		if saga.FailureReason == reasonHoldExpired {
+			subject = eventbus.SubjectBookingExpired
+		}
+		event := eventbus.BookingEvent{
+			EventID:   uuid.New(),
+			BookingID: saga.BookingID,
+			RequestID: logging.RequestID(ctx),
+			Trace:     tracing.Inject(ctx),
+		}
+		if err := s.bus.Publish(ctx, subject, event); err != nil {
+			return s.compensationFailed(ctx, saga, err)
+		}
+
+	case model.SagaStepReserveQuota:
+		compensated := *saga
+		transitioned := false
+		err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			bookingRepoTx := s.bookingRepo.WithTx(tx)
+			ticketRepoTx := s.ticketRepo.WithTx(tx)
+			sagaRepoTx := s.sagaRepo.WithTx(tx)
+
+			booking, err := bookingRepoTx.FindByIDForUpdate(ctx, saga.BookingID)
+			if err != nil {
+				return err
+			}
+
+			if booking.Status == "PENDING" {
+				if _, err := ticketRepoTx.FindByIDForUpdate(ctx, booking.TicketID); err != nil {
+					return err
+				}
+
+				if err := bookingRepoTx.UpdateStatus(ctx, booking.ID, "CANCELLED"); err != nil {
+					return err
+				}
+
+				if err := ticketRepoTx.IncreaseQuota(ctx, booking.TicketID, booking.Quantity); err != nil {
+					return err
+				}
+				transitioned = true
+			}
+
+			if err := s.record(ctx, sagaRepoTx, &compensated, model.SagaStepReserveQuota, model.SagaActionCompensate, nil); err != nil {
+				return err
+			}
+			compensated.Status = model.SagaStatusCompensated
+			compensated.Attempts = 0
+			compensated.LastError = ""
+			return sagaRepoTx.Save(ctx, &compensated)
+		})
+		if err != nil {
+			return s.compensationFailed(ctx, saga, err)
+		}
+		if transitioned {
+			metrics.BookingTransition("PENDING", "CANCELLED", cancelReason(saga.FailureReason))
+		}
+		*saga = compensated
+		return nil
+	}
+
+	// await_payment and confirm have nothing to undo
+	if err := s.record(ctx, s.sagaRepo, saga, saga.Step, model.SagaActionCompensate, nil); err != nil {
+		return err
+	}
+	saga.Step = previousStep[saga.Step]
+	saga.Attempts = 0
+	saga.LastError = ""
+	saga.NextAttemptAt = time.Now()
+	return nil
+}
+
+// advance records step as done and moves the saga to next with the given
+// status, saving it through repo
+func (s *checkoutService) advance(ctx context.Context, repo repository.CheckoutSagaRepository, saga *model.CheckoutSaga, step, next, status string) error {
+	if err := s.record(ctx, repo, saga, step, model.SagaActionExecute, nil); err != nil {
+		return err
+	}
+	saga.Step = next
+	saga.Status = status
+	saga.Attempts = 0
+	saga.LastError = ""
+	saga.NextAttemptAt = time.Now()
+	return repo.Save(ctx, saga)
+}
+
+// stepFailed schedules another attempt at the current step, or starts
+// compensating once it is not worth retrying. Confirming is retried for as
+// long as it takes, since the payment has already been made.
+func (s *checkoutService) stepFailed(ctx context.Context, saga *model.CheckoutSaga, stepErr error, retryable bool) error {
+	if err := s.record(ctx, s.sagaRepo, saga, saga.Step, model.SagaActionExecute, stepErr); err != nil {
+		return err
+	}
+
+	saga.Attempts++
+	saga.LastError = stepErr.Error()
+
+	now := time.Now()
+	switch {
+	case retryable && saga.Step == model.SagaStepConfirm:
+	case retryable && saga.Attempts < s.config.MaxAttempts && now.Before(saga.ExpiresAt):
+	case !now.Before(saga.ExpiresAt):
+		s.startCompensation(saga, saga.Step, reasonHoldExpired)
+		return nil
+	default:
+		s.startCompensation(saga, saga.Step, stepErr.Error())
+		return nil
+	}
+
+	saga.NextAttemptAt = now.Add(s.retryDelay(saga.Attempts))
+	return nil
+}
+
+// compensationFailed schedules another attempt at compensating. Compensations
+// never give up, or tickets would stay held forever.
+func (s *checkoutService) compensationFailed(ctx context.Context, saga *model.CheckoutSaga, compensateErr error) error {
+	if err := s.record(ctx, s.sagaRepo, saga, saga.Step, model.SagaActionCompensate, compensateErr); err != nil {
+		return err
+	}
+
+	saga.Attempts++
+	saga.LastError = compensateErr.Error()
+	saga.NextAttemptAt = time.Now().Add(s.retryDelay(saga.Attempts))
+	return nil
+}
+
+// startCompensation turns the saga around, compensating from step. The step
+// that failed is compensated too, as it may have partly happened.
+func (s *checkoutService) startCompensation(saga *model.CheckoutSaga, step, reason string) {
+	saga.Step = step
+	saga.Status = model.SagaStatusCompensating
+	saga.FailureReason = reason
+	saga.Attempts = 0
+	saga.NextAttemptAt = time.Now()
+}
+
+func (s *checkoutService) record(ctx context.Context, repo repository.CheckoutSagaRepository, saga *model.CheckoutSaga, step, action string, stepErr error) error {
+	entry := &model.CheckoutSagaLog{
+		SagaID:  saga.ID,
+		Step:    step,
+		Action:  action,
+		Outcome: model.SagaOutcomeSuccess,
+	}
+	if stepErr != nil {
+		entry.Outcome = model.SagaOutcomeFailure
+		entry.Error = stepErr.Error()
+	}
+	return repo.AddLog(ctx, entry)
+}
+
+// cancelReason maps the failure reason of a compensated saga to the reason
+// its booking cancellation is counted under
+func cancelReason(failureReason string) string {
+	switch failureReason {
+	case reasonHoldExpired:
+		return metrics.ReasonExpired
+	case reasonPaymentFailed:
+		return metrics.ReasonPaymentFailed
+	}
+	return metrics.ReasonCheckoutFailed
+}
+
+// retryDelay doubles the configured delay with every attempt made
+func (s *checkoutService) retryDelay(attempts int) time.Duration {
+	delay := s.config.RetryDelay
+	for i := 1; i < attempts && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+	return delay
+}
+
+// isRetryable reports whether a failed call may succeed when repeated.
+// Payment service rejecting the request outright will not change its mind.
+func isRetryable(err error) bool {
+	var apiErr *sdk.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Temporary()
+	}
+	var rpcErr *client.RPCError
+	if errors.As(err, &rpcErr) {
+		return errors.Is(err, client.ErrUnavailable) || errors.Is(err, client.ErrTimeout)
+	}
+	return true
+}
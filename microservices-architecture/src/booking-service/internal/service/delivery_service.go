@@ -0,0 +1,254 @@
+package service
+
+import (
+	"booking-service/internal/model"
+	"booking-service/internal/repository"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"platform/eventbus"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// maxDeliveryRetryDelay caps the backoff between automatic retries
+const maxDeliveryRetryDelay = time.Hour
+
+// lastErrorExcerptLength bounds the error text kept per delivery
+const lastErrorExcerptLength = 500
+
+// deliveryBatchSize bounds the deliveries one RetryDue call claims
+const deliveryBatchSize = 50
+
+var (
+	ErrDeliveryNotFound   = errors.New("delivery not found")
+	ErrDeliveryInProgress = errors.New("delivery is being published, try again shortly")
+)
+
+// DeliveryConfig tunes the retries of outbound deliveries
+type DeliveryConfig struct {
+	MaxAttempts   int           // automatic attempts before a delivery is dead
+	RetryDelay    time.Duration // doubled with every failed attempt
+	LockDuration  time.Duration // how long a claimed retry is kept from others
+	RetryInterval time.Duration // how often due deliveries are looked for
+}
+
+// LoadDeliveryConfig reads the DELIVERY_* settings from the environment
+func LoadDeliveryConfig() DeliveryConfig {
+	return DeliveryConfig{
+		MaxAttempts:   getEnvInt("DELIVERY_MAX_ATTEMPTS", 8),
+		RetryDelay:    getEnvDuration("DELIVERY_RETRY_DELAY", 10*time.Second),
+		LockDuration:  getEnvDuration("DELIVERY_LOCK_DURATION", time.Minute),
+		RetryInterval: getEnvDuration("DELIVERY_RETRY_INTERVAL", 15*time.Second),
+	}
+}
+
+// DeliveryService publishes events for other services through a delivery
+// log. Every event is recorded before it is published; one the event bus
+// does not accept is retried in the background with backoff and can be
+// replayed by an administrator.
+type DeliveryService interface {
+	// Publish records the event and publishes it. A failed publish is left
+	// to the retrier, so only failing to record the event is an error.
+	Publish(ctx context.Context, subject string, event interface{}) error
+	// Record records the event within tx without publishing it, for events
+	// that must only go out if tx commits. Pass the delivery to Send once it
+	// has; should that not happen, the retrier publishes it when its claim
+	// runs out.
+	Record(ctx context.Context, tx *gorm.DB, subject string, event interface{}) (*model.OutboundDelivery, error)
+	Send(ctx context.Context, delivery *model.OutboundDelivery)
+	RetryDue(ctx context.Context) (int, error)
+	Replay(ctx context.Context, id uuid.UUID) (*model.OutboundDeliveryResponse, error)
+	ReplayAll(ctx context.Context, filter repository.OutboundDeliveryFilter) ([]model.OutboundDeliveryResponse, error)
+	ListDeliveries(ctx context.Context, filter repository.OutboundDeliveryFilter) ([]model.OutboundDeliveryResponse, error)
+}
+
+type deliveryService struct {
+	repo   repository.OutboundDeliveryRepository
+	bus    eventbus.Publisher
+	config DeliveryConfig
+}
+
+func NewDeliveryService(repo repository.OutboundDeliveryRepository, bus eventbus.Publisher, config DeliveryConfig) DeliveryService {
+	return &deliveryService{repo: repo, bus: bus, config: config}
+}
+
+func (s *deliveryService) Publish(ctx context.Context, subject string, event interface{}) error {
+	delivery, err := s.newDelivery(subject, event)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.Create(ctx, delivery); err != nil {
+		return fmt.Errorf("failed to record %s event: %w", subject, err)
+	}
+
+	s.attempt(ctx, delivery)
+	return nil
+}
+
+func (s *deliveryService) Record(ctx context.Context, tx *gorm.DB, subject string, event interface{}) (*model.OutboundDelivery, error) {
+	delivery, err := s.newDelivery(subject, event)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.WithTx(tx).Create(ctx, delivery); err != nil {
+		return nil, fmt.Errorf("failed to record %s event: %w", subject, err)
+	}
+	return delivery, nil
+}
+
+func (s *deliveryService) Send(ctx context.Context, delivery *model.OutboundDelivery) {
+	s.attempt(ctx, delivery)
+}
+
+// newDelivery returns a pending delivery of event. Until the outcome is
+// saved the delivery counts as claimed, so it is retried if this instance
+// dies mid-publish.
+func (s *deliveryService) newDelivery(subject string, event interface{}) (*model.OutboundDelivery, error) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal %s event: %w", subject, err)
+	}
+
+	claimedUntil := time.Now().Add(s.config.LockDuration)
+	return &model.OutboundDelivery{
+		Subject:     subject,
+		Payload:     payload,
+		Status:      model.DeliveryStatusPending,
+		NextRetryAt: &claimedUntil,
+	}, nil
+}
+
+// RetryDue publishes failed deliveries whose retry is due and returns how
+// many were attempted
+func (s *deliveryService) RetryDue(ctx context.Context) (int, error) {
+	now := time.Now()
+	deliveries, err := s.repo.ClaimDue(ctx, now, now.Add(s.config.LockDuration), deliveryBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	for i := range deliveries {
+		s.attempt(ctx, &deliveries[i])
+	}
+	return len(deliveries), nil
+}
+
+// Replay publishes a delivery again whatever its status, for instance to
+// resend a dead one once its cause has been fixed. It is claimed first, so
+// a delivery that is being published or retried right now is not sent
+// twice; that one gets ErrDeliveryInProgress.
+func (s *deliveryService) Replay(ctx context.Context, id uuid.UUID) (*model.OutboundDeliveryResponse, error) {
+	delivery, err := s.claim(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		if _, err := s.repo.FindByID(ctx, id); err != nil {
+			return nil, ErrDeliveryNotFound
+		}
+		return nil, ErrDeliveryInProgress
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.attempt(ctx, delivery)
+	return delivery.ToResponse(), nil
+}
+
+// ReplayAll replays every delivery matching filter, skipping those being
+// published right now
+func (s *deliveryService) ReplayAll(ctx context.Context, filter repository.OutboundDeliveryFilter) ([]model.OutboundDeliveryResponse, error) {
+	deliveries, err := s.repo.FindAll(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	response := make([]model.OutboundDeliveryResponse, 0, len(deliveries))
+	for _, found := range deliveries {
+		delivery, err := s.claim(ctx, found.ID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			continue
+		}
+		if err != nil {
+			return response, err
+		}
+
+		s.attempt(ctx, delivery)
+		response = append(response, *delivery.ToResponse())
+	}
+	return response, nil
+}
+
+func (s *deliveryService) ListDeliveries(ctx context.Context, filter repository.OutboundDeliveryFilter) ([]model.OutboundDeliveryResponse, error) {
+	deliveries, err := s.repo.FindAll(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	response := make([]model.OutboundDeliveryResponse, 0, len(deliveries))
+	for i := range deliveries {
+		response = append(response, *deliveries[i].ToResponse())
+	}
+	return response, nil
+}
+
+// claim keeps RetryDue and other replays away from the delivery while it is
+// published
+func (s *deliveryService) claim(ctx context.Context, id uuid.UUID) (*model.OutboundDelivery, error) {
+	now := time.Now()
+	return s.repo.Claim(ctx, id, now, now.Add(s.config.LockDuration))
+}
+
+// attempt publishes the delivery once and saves the outcome
+func (s *deliveryService) attempt(ctx context.Context, delivery *model.OutboundDelivery) {
+	delivery.Attempts++
+	err := s.bus.Publish(ctx, delivery.Subject, json.RawMessage(delivery.Payload))
+
+	now := time.Now()
+	switch {
+	case err == nil:
+		delivery.Status = model.DeliveryStatusDelivered
+		delivery.LastError = ""
+		delivery.NextRetryAt = nil
+		delivery.DeliveredAt = &now
+	case delivery.Attempts >= s.config.MaxAttempts:
+		slog.ErrorContext(ctx, "Giving up on delivery", "subject", delivery.Subject, "delivery_id", delivery.ID, "attempts", delivery.Attempts, "error", err)
+		delivery.Status = model.DeliveryStatusDead
+		delivery.LastError = excerpt(err.Error())
+		delivery.NextRetryAt = nil
+	default:
+		slog.WarnContext(ctx, "Failed to deliver event", "subject", delivery.Subject, "delivery_id", delivery.ID, "attempt", delivery.Attempts, "error", err)
+		next := now.Add(s.retryDelay(delivery.Attempts))
+		delivery.Status = model.DeliveryStatusFailed
+		delivery.LastError = excerpt(err.Error())
+		delivery.NextRetryAt = &next
+	}
+
+	// The request may be gone by now, the outcome must be kept regardless
+	if err := s.repo.Save(context.WithoutCancel(ctx), delivery); err != nil {
+		slog.ErrorContext(ctx, "Failed to record delivery outcome", "subject", delivery.Subject, "delivery_id", delivery.ID, "error", err)
+	}
+}
+
+// retryDelay doubles the configured delay with every attempt made
+func (s *deliveryService) retryDelay(attempts int) time.Duration {
+	delay := s.config.RetryDelay
+	for i := 1; i < attempts && delay < maxDeliveryRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxDeliveryRetryDelay {
+		delay = maxDeliveryRetryDelay
+	}
+	return delay
+}
+
+func excerpt(text string) string {
+	if len(text) <= lastErrorExcerptLength {
+		return text
+	}
+	return text[:lastErrorExcerptLength] + "..."
+}
@@ -2,7 +2,9 @@ package migrations
 
 import (
 	"booking-service/internal/model"
-	"log"
+	"log/slog"
+
+	"platform/logging"
 
 	"gorm.io/gorm"
 )
@@ -12,11 +14,15 @@ func RunMigrations(db *gorm.DB) {
 		&model.Event{},
 		&model.Ticket{},
 		&model.Booking{},
+		&model.CheckoutSaga{},
+		&model.CheckoutSagaLog{},
+		&model.OutboundDelivery{},
+		&model.ProcessedMessage{},
 	)
 	if err != nil {
-		log.Fatal("Failed to run migrations:", err)
+		logging.Fatal("Failed to run migrations", "error", err)
 	}
-	log.Println("Migrations completed successfully")
+	slog.Info("Migrations completed successfully")
 
 	// Run seeders
 	SeedData(db)
@@ -2,7 +2,7 @@ package migrations
 
 import (
 	"booking-service/internal/model"
-	"log"
+	"log/slog"
 	"time"
 
 	"github.com/google/uuid"
@@ -14,7 +14,7 @@ func SeedData(db *gorm.DB) {
 	var eventCount int64
 	db.Model(&model.Event{}).Count(&eventCount)
 	if eventCount > 0 {
-		log.Println("Data already seeded, skipping...")
+		slog.Info("Data already seeded, skipping...")
 		return
 	}
 
@@ -54,10 +54,10 @@ func SeedData(db *gorm.DB) {
 
 	for _, event := range events {
 		if err := db.Create(&event).Error; err != nil {
-			log.Printf("Failed to seed event %s: %v", event.Name, err)
+			slog.Error("Failed to seed event", "event", event.Name, "error", err)
 		}
 	}
-	log.Println("Events seeded successfully")
+	slog.Info("Events seeded successfully")
 
 	// Seed Tickets for each event
 	tickets := []model.Ticket{
@@ -140,8 +140,8 @@ func SeedData(db *gorm.DB) {
 
 	for _, ticket := range tickets {
 		if err := db.Create(&ticket).Error; err != nil {
-			log.Printf("Failed to seed ticket for event %s: %v", ticket.EventID, err)
+			slog.Error("Failed to seed ticket", "event_id", ticket.EventID, "error", err)
 		}
 	}
-	log.Println("Tickets seeded successfully")
+	slog.Info("Tickets seeded successfully")
 }
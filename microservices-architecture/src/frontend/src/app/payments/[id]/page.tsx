@@ -93,12 +93,12 @@ export default function PaymentPage() {
 
     setIsProcessing(true);
     try {
-      const response = await paymentAPI.createPayment({
-        booking_id: bookingId,
-        amount: booking.total_amount,
-        payment_method: selectedMethod,
-      });
-      setPayment(response.data);
+      const response = await bookingAPI.checkout(bookingId, selectedMethod);
+      if (!response.data.payment) {
+        toast.info('Your payment is being set up, please try again shortly.');
+        return;
+      }
+      setPayment(response.data.payment);
       toast.success('Payment created! Please complete the payment.');
     } catch (err) {
       toast.error(err instanceof Error ? err.message : 'Failed to create payment');
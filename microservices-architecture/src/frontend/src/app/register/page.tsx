@@ -20,6 +20,7 @@ import { Loader2, Ticket } from 'lucide-react';
 
 export default function RegisterPage() {
   const [username, setUsername] = useState('');
+  const [email, setEmail] = useState('');
   const [password, setPassword] = useState('');
   const [confirmPassword, setConfirmPassword] = useState('');
   const [isLoading, setIsLoading] = useState(false);
@@ -29,7 +30,7 @@ export default function RegisterPage() {
   const handleSubmit = async (e: React.FormEvent) => {
     e.preventDefault();
     
-    if (!username || !password || !confirmPassword) {
+    if (!username || !email || !password || !confirmPassword) {
       toast.error('Please fill in all fields');
       return;
     }
@@ -46,7 +47,7 @@ export default function RegisterPage() {
 
     setIsLoading(true);
     try {
-      await register(username, password);
+      await register(username, email, password);
       toast.success('Registration successful!');
       router.push('/events');
     } catch (error) {
@@ -81,6 +82,17 @@ export default function RegisterPage() {
                 disabled={isLoading}
               />
             </div>
+            <div className="space-y-2">
+              <Label htmlFor="email">Email</Label>
+              <Input
+                id="email"
+                type="email"
+                placeholder="you@example.com"
+                value={email}
+                onChange={(e) => setEmail(e.target.value)}
+                disabled={isLoading}
+              />
+            </div>
             <div className="space-y-2">
               <Label htmlFor="password">Password</Label>
               <Input
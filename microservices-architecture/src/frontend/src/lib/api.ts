@@ -9,6 +9,7 @@ import type {
   CreateBookingRequest,
   BookingResponse,
   BookingsResponse,
+  CheckoutResponse,
   CreatePaymentRequest,
   PaymentResponse,
 } from "@/types";
@@ -118,6 +119,15 @@ export const bookingAPI = {
 
   getBookingById: (id: string) =>
     fetchAPI<BookingResponse>(`${BOOKING_SERVICE_URL}/api/v1/bookings/${id}`),
+
+  checkout: (bookingId: string, paymentMethod: string) =>
+    fetchAPI<CheckoutResponse>(
+      `${BOOKING_SERVICE_URL}/api/v1/bookings/${bookingId}/checkout`,
+      {
+        method: "POST",
+        body: JSON.stringify({ payment_method: paymentMethod }),
+      }
+    ),
 };
 
 // ==================== Payment Service API ====================
@@ -2,6 +2,8 @@
 export interface User {
   id: string;
   username: string;
+  email: string;
+  email_verified: boolean;
 }
 
 export interface LoginRequest {
@@ -11,6 +13,7 @@ export interface LoginRequest {
 
 export interface RegisterRequest {
   username: string;
+  email: string;
   password: string;
 }
 
@@ -96,7 +99,14 @@ export interface Payment {
   amount: number;
   currency: string;
   payment_method: string;
-  status: 'PENDING' | 'PAID' | 'FAILED' | 'EXPIRED';
+  status:
+    | 'PENDING'
+    | 'PAID'
+    | 'FAILED'
+    | 'EXPIRED'
+    | 'CANCELLED'
+    | 'REFUND_PENDING'
+    | 'REFUNDED';
   expired_at?: string;
   paid_at?: string;
   created_at: string;
@@ -119,6 +129,34 @@ export interface PaymentsResponse {
   data: Payment[];
 }
 
+// Checkout types
+export interface CheckoutSaga {
+  id: string;
+  booking_id: string;
+  user_id: string;
+  amount: number;
+  payment_method?: string;
+  payment_id?: string;
+  status: 'RUNNING' | 'WAITING' | 'COMPENSATING' | 'COMPLETED' | 'COMPENSATED';
+  step: 'reserve_quota' | 'create_payment' | 'await_payment' | 'confirm';
+  failure_reason?: string;
+  expires_at: string;
+  created_at: string;
+  updated_at: string;
+}
+
+export interface CheckoutRequest {
+  payment_method: string;
+}
+
+export interface CheckoutResponse {
+  message: string;
+  data: {
+    checkout: CheckoutSaga;
+    payment?: Payment; // missing while payment creation is being retried
+  };
+}
+
 // API Error
 export interface APIError {
   error: string;
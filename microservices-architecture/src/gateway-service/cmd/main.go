@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"gateway-service/config"
+	"gateway-service/internal/auth"
+	"gateway-service/internal/handler"
+	"gateway-service/internal/metrics"
+	"gateway-service/internal/middleware"
+	"gateway-service/internal/proxy"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"platform/health"
+	"platform/logging"
+	"platform/tracing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/cors"
+)
+
+func main() {
+	// Log JSON lines at LOG_LEVEL
+	logging.Setup("gateway-service")
+
+	ctx := context.Background()
+
+	// Trace requests and forwarded calls to OTEL_TRACES_EXPORTER (default none)
+	shutdownTracing, err := tracing.Setup(ctx, "gateway-service")
+	if err != nil {
+		logging.Fatal("Failed to set up tracing", "error", err)
+	}
+	defer shutdownTracing(context.Background())
+
+	// Load routes
+	gatewayConfig, err := config.LoadGatewayConfig()
+	if err != nil {
+		logging.Fatal("Failed to load gateway config", "error", err)
+	}
+
+	// Access tokens are verified with the secret shared with user-service
+	secret := os.Getenv("JWT_SECRET")
+	if secret == "" {
+		logging.Fatal("JWT_SECRET environment variable is not set")
+	}
+
+	userService, ok := gatewayConfig.Upstreams["user"]
+	if !ok {
+		logging.Fatal("Gateway config has no user upstream to verify tokens against")
+	}
+	revocations := auth.NewRevocationList(userService.URL)
+	revocations.Start(ctx, 5*time.Second)
+	verifier := auth.NewTokenVerifier(secret, revocations)
+
+	healthHandler := handler.NewHealthHandler(gatewayConfig.Upstreams)
+
+	// The gateway has no dependencies of its own. With
+	// HEALTH_CHECK_DOWNSTREAM=true readiness also checks that every upstream
+	// is up.
+	checker := health.NewChecker("gateway-service", health.LoadConfig())
+	for name, upstream := range gatewayConfig.Upstreams {
+		checker.AddDownstream(name, health.HTTP(http.DefaultClient, strings.TrimSuffix(upstream.URL, "/")+"/livez"))
+	}
+
+	// Initialize Fiber app
+	app := fiber.New(fiber.Config{
+		AppName:               "API Gateway",
+		DisableStartupMessage: true, // "Gateway starting" is logged instead
+	})
+
+	// Middleware
+	app.Use(middleware.RequestID())
+	app.Use(tracing.HTTP())
+	app.Use(middleware.AccessLog())
+	if metrics.Enabled() {
+		app.Use(metrics.HTTP())
+	}
+	app.Use(cors.New(cors.Config{
+		AllowOrigins:  gatewayConfig.CORS.AllowOrigins,
+		AllowHeaders:  "Origin, Content-Type, Accept, Authorization, X-API-Key, X-Request-ID, traceparent, tracestate",
+		ExposeHeaders: "X-Request-ID, Retry-After",
+	}))
+
+	// Probes of the gateway itself, and the readiness of every upstream
+	app.Get("/livez", checker.Livez)
+	app.Get("/readyz", checker.Readyz)
+	app.Get("/health", healthHandler.GetHealth)
+
+	notFound := func(c *fiber.Ctx) error {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Route not found",
+		})
+	}
+
+	// Routes from the config, most specific first
+	for _, route := range gatewayConfig.Routes {
+		upstream := gatewayConfig.Upstreams[route.Upstream]
+		forward := []fiber.Handler{
+			middleware.Authenticate(verifier, route),
+			middleware.RateLimit(*route.RateLimit),
+			proxy.Forward(route, upstream.URL),
+		}
+		if route.Internal {
+			forward = []fiber.Handler{notFound}
+		}
+
+		// A prefix matches itself and anything below it, but not /users for /user
+		if route.Prefix == "/" {
+			app.All("/*", forward...)
+		} else {
+			app.All(route.Prefix, forward...)
+			app.All(route.Prefix+"/*", forward...)
+		}
+		if !route.Internal {
+			slog.Info("Routing", "prefix", route.Prefix, "upstream", route.Upstream, "rewrite", route.Rewrite, "auth", route.Auth)
+		}
+	}
+
+	app.Use(notFound)
+
+	// Metrics are served on their own port, which is not published, so that
+	// clients cannot read them
+	if metrics.Enabled() {
+		metricsPort := os.Getenv("METRICS_PORT")
+		if metricsPort == "" {
+			metricsPort = "9090"
+		}
+		metricsApp := fiber.New(fiber.Config{DisableStartupMessage: true})
+		metricsApp.Get("/metrics", metrics.Handler())
+		go func() {
+			slog.Info("Metrics server starting", "port", metricsPort)
+			if err := metricsApp.Listen(":" + metricsPort); err != nil {
+				logging.Fatal("Failed to start metrics server", "error", err)
+			}
+		}()
+	}
+
+	// On SIGINT or SIGTERM, report not ready, give load balancers time to
+	// drain the gateway and finish the requests in flight
+	stopped := checker.OnShutdown(func(ctx context.Context) {
+		if err := app.ShutdownWithContext(ctx); err != nil {
+			slog.Error("Failed to shut down gateway", "error", err)
+		}
+	})
+
+	// Start server
+	port := os.Getenv("PORT")
+	if port == "" {
+		port = "8080"
+	}
+	slog.Info("Gateway starting", "port", port)
+	if err := app.Listen(":" + port); err != nil {
+		logging.Fatal("Failed to start gateway", "error", err)
+	}
+	<-stopped
+	slog.Info("Gateway stopped")
+}
@@ -0,0 +1,166 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Auth modes of a route
+const (
+	AuthNone     = "none"     // forwarded as is, without a user ID
+	AuthOptional = "optional" // a bearer token, if present, must be valid
+	AuthRequired = "required" // a valid bearer token is required
+)
+
+// GatewayConfig is the declarative description of the gateway, read from
+// GATEWAY_CONFIG
+type GatewayConfig struct {
+	Upstreams map[string]Upstream `yaml:"upstreams"`
+	Routes    []Route             `yaml:"routes"`
+	RateLimit RateLimit           `yaml:"rate_limit"` // default for routes without their own
+	CORS      CORS                `yaml:"cors"`
+	Timeout   time.Duration       `yaml:"timeout"` // default for routes without their own
+}
+
+// Upstream is a backend service. Its /health is part of the gateway's.
+type Upstream struct {
+	URL string `yaml:"url"`
+}
+
+// Route forwards requests whose path starts with Prefix to an upstream,
+// replacing Prefix with Rewrite. The longest matching prefix wins.
+type Route struct {
+	Prefix    string        `yaml:"prefix"`
+	Upstream  string        `yaml:"upstream"`
+	Rewrite   string        `yaml:"rewrite"`
+	Auth      string        `yaml:"auth"`
+	APIKeys   bool          `yaml:"api_keys"` // X-API-Key may stand in for a required bearer token
+	Internal  bool          `yaml:"internal"` // only for services, never forwarded
+	RateLimit *RateLimit    `yaml:"rate_limit"`
+	Timeout   time.Duration `yaml:"timeout"`
+}
+
+// RateLimit allows Requests per Window for each user, or each client IP for
+// anonymous requests
+type RateLimit struct {
+	Requests int           `yaml:"requests"`
+	Window   time.Duration `yaml:"window"`
+}
+
+type CORS struct {
+	AllowOrigins string `yaml:"allow_origins"`
+}
+
+// LoadGatewayConfig reads the file at GATEWAY_CONFIG, gateway.yaml by
+// default. ${VAR} and ${VAR:-default} in the file are replaced with
+// environment variables.
+func LoadGatewayConfig() (*GatewayConfig, error) {
+	path := getEnv("GATEWAY_CONFIG", "gateway.yaml")
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &GatewayConfig{
+		RateLimit: RateLimit{Requests: 120, Window: time.Minute},
+		Timeout:   30 * time.Second,
+	}
+	if err := yaml.Unmarshal([]byte(os.Expand(string(raw), expandEnv)), cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if err := cfg.validate(); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", path, err)
+	}
+
+	// Longest prefix first, so the first match is the most specific
+	sort.SliceStable(cfg.Routes, func(i, j int) bool {
+		return len(cfg.Routes[i].Prefix) > len(cfg.Routes[j].Prefix)
+	})
+	return cfg, nil
+}
+
+func (cfg *GatewayConfig) validate() error {
+	if len(cfg.Upstreams) == 0 {
+		return fmt.Errorf("no upstreams")
+	}
+	for name, upstream := range cfg.Upstreams {
+		parsed, err := url.Parse(upstream.URL)
+		if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+			return fmt.Errorf("upstream %s: invalid url %q", name, upstream.URL)
+		}
+	}
+
+	if err := cfg.RateLimit.validate(); err != nil {
+		return fmt.Errorf("rate_limit: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	for i := range cfg.Routes {
+		route := &cfg.Routes[i]
+		if !strings.HasPrefix(route.Prefix, "/") {
+			return fmt.Errorf("route %q: prefix must start with /", route.Prefix)
+		}
+		if route.Prefix != "/" {
+			route.Prefix = strings.TrimSuffix(route.Prefix, "/")
+		}
+		if seen[route.Prefix] {
+			return fmt.Errorf("route %q: duplicate prefix", route.Prefix)
+		}
+		seen[route.Prefix] = true
+
+		if _, ok := cfg.Upstreams[route.Upstream]; !ok {
+			return fmt.Errorf("route %q: unknown upstream %q", route.Prefix, route.Upstream)
+		}
+		if route.Rewrite == "" {
+			route.Rewrite = route.Prefix
+		}
+
+		switch route.Auth {
+		case "":
+			route.Auth = AuthOptional
+		case AuthNone, AuthOptional, AuthRequired:
+		default:
+			return fmt.Errorf("route %q: unknown auth mode %q", route.Prefix, route.Auth)
+		}
+
+		if route.RateLimit == nil {
+			route.RateLimit = &cfg.RateLimit
+		} else if err := route.RateLimit.validate(); err != nil {
+			return fmt.Errorf("route %q: rate_limit: %w", route.Prefix, err)
+		}
+		if route.Timeout <= 0 {
+			route.Timeout = cfg.Timeout
+		}
+	}
+	return nil
+}
+
+func (l RateLimit) validate() error {
+	if l.Requests <= 0 || l.Window <= 0 {
+		return fmt.Errorf("requests and window must be positive")
+	}
+	return nil
+}
+
+// expandEnv resolves VAR and VAR:-default
+func expandEnv(name string) string {
+	if key, fallback, ok := strings.Cut(name, ":-"); ok {
+		return getEnv(key, fallback)
+	}
+	return os.Getenv(name)
+}
+
+func getEnv(key, defaultValue string) string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	return value
+}
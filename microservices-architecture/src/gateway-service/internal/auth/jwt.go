@@ -0,0 +1,66 @@
+package auth
+
+import (
+	"errors"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	ErrInvalidToken = errors.New("invalid token")
+	ErrExpiredToken = errors.New("token has expired")
+	ErrRevokedToken = errors.New("token has been revoked")
+)
+
+// Claims mirrors the access token claims issued by user-service
+type Claims struct {
+	UserID        string `json:"user_id"`
+	Username      string `json:"username"`
+	EmailVerified bool   `json:"email_verified"`
+	Role          string `json:"role"`
+	MFA           bool   `json:"mfa"`
+	SessionID     string `json:"sid,omitempty"`
+	Purpose       string `json:"purpose,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// TokenVerifier checks access tokens at the edge: the HS256 signature with
+// the secret shared with user-service, expiry, and the revocation list.
+// Access tokens user-service hands to OAuth clients are RS256, so they are
+// never accepted on gateway routes.
+type TokenVerifier struct {
+	secret      []byte
+	revocations *RevocationList
+}
+
+func NewTokenVerifier(secret string, revocations *RevocationList) *TokenVerifier {
+	return &TokenVerifier{secret: []byte(secret), revocations: revocations}
+}
+
+func (v *TokenVerifier) Verify(tokenString string) (*Claims, error) {
+	claims := &Claims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		return v.secret, nil
+	},
+		jwt.WithValidMethods([]string{"HS256"}),
+		jwt.WithIssuer("user-service"),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return nil, ErrExpiredToken
+		}
+		return nil, ErrInvalidToken
+	}
+
+	// Purpose-bound tokens such as MFA challenges are not access tokens
+	if !token.Valid || claims.Purpose != "" || claims.UserID == "" {
+		return nil, ErrInvalidToken
+	}
+
+	if v.revocations.IsRevoked(claims.ID, claims.SessionID) {
+		return nil, ErrRevokedToken
+	}
+
+	return claims, nil
+}
@@ -0,0 +1,151 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Kinds of revocation entries published by user-service
+const (
+	revokedKindToken   = "jti" // a single access token
+	revokedKindSession = "sid" // every access token issued for a session
+)
+
+// RevocationList mirrors the access token denylist of user-service by
+// polling its /api/v1/auth/revocations feed, which requires a service token
+// with the revocations:read scope
+type RevocationList struct {
+	feedURL string
+	client  *http.Client
+	tokens  *serviceTokenSource
+
+	mu      sync.RWMutex
+	entries map[string]time.Time // kind:value -> expiry
+	since   int64                // server time of the last successful poll
+}
+
+type revocationEntry struct {
+	Kind      string    `json:"kind"`
+	Value     string    `json:"value"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type revocationFeed struct {
+	Data       []revocationEntry `json:"data"`
+	ServerTime int64             `json:"server_time"`
+}
+
+func NewRevocationList(userServiceURL string) *RevocationList {
+	client := &http.Client{Timeout: 5 * time.Second}
+	return &RevocationList{
+		feedURL: strings.TrimSuffix(userServiceURL, "/") + "/api/v1/auth/revocations",
+		client:  client,
+		tokens:  newServiceTokenSource(userServiceURL, "user-service", client),
+		entries: make(map[string]time.Time),
+	}
+}
+
+func (l *RevocationList) IsRevoked(jti, sessionID string) bool {
+	now := time.Now()
+
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	if expiry, ok := l.entries[revokedKindToken+":"+jti]; ok && jti != "" && now.Before(expiry) {
+		return true
+	}
+	if expiry, ok := l.entries[revokedKindSession+":"+sessionID]; ok && sessionID != "" && now.Before(expiry) {
+		return true
+	}
+	return false
+}
+
+// Start polls the feed every interval until ctx is cancelled. The first
+// poll fetches every entry that is still active.
+func (l *RevocationList) Start(ctx context.Context, interval time.Duration) {
+	go func() {
+		l.poll(ctx)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				l.poll(ctx)
+			}
+		}
+	}()
+}
+
+func (l *RevocationList) poll(ctx context.Context) {
+	// Overlap the window slightly so rows committed out of order are not missed
+	since := l.since - 5
+	if since < 0 {
+		since = 0
+	}
+
+	feed, err := l.fetch(ctx, since)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to sync revoked tokens", "error", err)
+		return
+	}
+
+	now := time.Now()
+	l.mu.Lock()
+	for _, entry := range feed.Data {
+		l.entries[entry.Kind+":"+entry.Value] = entry.ExpiresAt
+	}
+	for key, expiry := range l.entries {
+		if now.After(expiry) {
+			delete(l.entries, key)
+		}
+	}
+	l.mu.Unlock()
+
+	l.since = feed.ServerTime
+}
+
+func (l *RevocationList) fetch(ctx context.Context, since int64) (*revocationFeed, error) {
+	token, err := l.tokens.Token(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to obtain service token: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, l.feedURL+"?since="+strconv.FormatInt(since, 10), nil)
+	if err != nil {
+		return nil, err
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := l.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("user service returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var feed revocationFeed
+	if err := json.Unmarshal(body, &feed); err != nil {
+		return nil, err
+	}
+	return &feed, nil
+}
@@ -0,0 +1,103 @@
+package auth
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// serviceTokenSource fetches client credentials tokens from the user-service
+// token endpoint using SERVICE_CLIENT_ID and SERVICE_CLIENT_SECRET, and
+// caches them until shortly before they expire
+type serviceTokenSource struct {
+	tokenURL     string
+	audience     string
+	clientID     string
+	clientSecret string
+	client       *http.Client
+
+	mu        sync.Mutex
+	token     string
+	expiresAt time.Time
+}
+
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+func newServiceTokenSource(userServiceURL, audience string, client *http.Client) *serviceTokenSource {
+	clientID := os.Getenv("SERVICE_CLIENT_ID")
+	if clientID == "" {
+		slog.Warn("SERVICE_CLIENT_ID is not set, calls to internal APIs will be unauthenticated")
+	}
+
+	return &serviceTokenSource{
+		tokenURL:     strings.TrimSuffix(userServiceURL, "/") + "/api/v1/oauth/token",
+		audience:     audience,
+		clientID:     clientID,
+		clientSecret: os.Getenv("SERVICE_CLIENT_SECRET"),
+		client:       client,
+	}
+}
+
+// Token returns a bearer token, or an empty string when no service
+// credentials are configured
+func (s *serviceTokenSource) Token(ctx context.Context) (string, error) {
+	if s.clientID == "" {
+		return "", nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token != "" && time.Now().Before(s.expiresAt) {
+		return s.token, nil
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "client_credentials")
+	form.Set("audience", s.audience)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	// Credentials are form encoded before going into the header
+	credentials := url.QueryEscape(s.clientID) + ":" + url.QueryEscape(s.clientSecret)
+	req.Header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(credentials)))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("token endpoint returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var tokenResp tokenResponse
+	if err := json.Unmarshal(body, &tokenResp); err != nil {
+		return "", err
+	}
+
+	// Renew a little early so that a token never expires in flight
+	s.token = tokenResp.AccessToken
+	s.expiresAt = time.Now().Add(time.Duration(tokenResp.ExpiresIn)*time.Second - 30*time.Second)
+	return s.token, nil
+}
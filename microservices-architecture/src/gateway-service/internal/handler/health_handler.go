@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"gateway-service/config"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// healthTimeout bounds each upstream's health check
+const healthTimeout = 3 * time.Second
+
+type HealthHandler struct {
+	upstreams map[string]config.Upstream
+	client    *http.Client
+}
+
+func NewHealthHandler(upstreams map[string]config.Upstream) *HealthHandler {
+	return &HealthHandler{
+		upstreams: upstreams,
+		client:    &http.Client{Timeout: healthTimeout},
+	}
+}
+
+type upstreamHealth struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// GetHealth checks every upstream's /readyz in parallel. The gateway is
+// reported as degraded, with status 503, when any of them is not ready.
+func (h *HealthHandler) GetHealth(c *fiber.Ctx) error {
+	results := make(map[string]upstreamHealth, len(h.upstreams))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for name, upstream := range h.upstreams {
+		wg.Add(1)
+		go func(name string, upstream config.Upstream) {
+			defer wg.Done()
+			result := upstreamHealth{Status: "ok"}
+			if err := h.check(c.UserContext(), upstream.URL); err != nil {
+				result = upstreamHealth{Status: "unavailable", Error: err.Error()}
+			}
+			mu.Lock()
+			results[name] = result
+			mu.Unlock()
+		}(name, upstream)
+	}
+	wg.Wait()
+
+	status := "ok"
+	code := fiber.StatusOK
+	for _, result := range results {
+		if result.Status != "ok" {
+			status = "degraded"
+			code = fiber.StatusServiceUnavailable
+		}
+	}
+
+	return c.Status(code).JSON(fiber.Map{
+		"status":    status,
+		"service":   "gateway-service",
+		"upstreams": results,
+	})
+}
+
+func (h *HealthHandler) check(ctx context.Context, baseURL string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(baseURL, "/")+"/readyz", nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("returned status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Outcomes of calls to other services
+const (
+	OutcomeOK          = "ok"
+	OutcomeError       = "error"       // answered with an error
+	OutcomeTimeout     = "timeout"     // no answer in time
+	OutcomeUnavailable = "unavailable" // unreachable, failing or behind an open circuit
+)
+
+var (
+	clientRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "client_requests_total",
+		Help: "Calls to other services, by target, method and outcome. Retries are part of one call.",
+	}, []string{"target", "method", "outcome"})
+
+	clientDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "client_request_duration_seconds",
+		Help:    "Time taken by calls to other services, including retries, by target and method.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"target", "method"})
+)
+
+func init() {
+	Registry.MustRegister(clientRequests, clientDuration)
+}
+
+// ObserveClient records a call to target that started at start. method is
+// the HTTP method or the full gRPC method name.
+func ObserveClient(target, method, outcome string, start time.Time) {
+	clientRequests.WithLabelValues(target, method, outcome).Inc()
+	clientDuration.WithLabelValues(target, method).Observe(time.Since(start).Seconds())
+}
@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"errors"
+	"gateway-service/config"
+	"gateway-service/internal/auth"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Headers the gateway sets for the services behind it. Values sent by
+// clients are always dropped, so services can trust them.
+const (
+	HeaderUserID   = "X-User-ID"
+	HeaderUserRole = "X-User-Role"
+)
+
+// Authenticate verifies the bearer token according to the route's auth mode
+// and forwards the user's ID and role. The Authorization header is passed
+// on unchanged. The claims are stored in the "claims" local.
+func Authenticate(verifier *auth.TokenVerifier, route config.Route) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.Request().Header.Del(HeaderUserID)
+		c.Request().Header.Del(HeaderUserRole)
+
+		if route.Auth == config.AuthNone {
+			return c.Next()
+		}
+
+		authHeader := c.Get(fiber.HeaderAuthorization)
+		if authHeader == "" {
+			if route.Auth == config.AuthRequired && !(route.APIKeys && c.Get("X-API-Key") != "") {
+				return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+					"error": "Authorization header is required",
+				})
+			}
+			return c.Next()
+		}
+
+		if !strings.HasPrefix(authHeader, "Bearer ") {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Invalid authorization format. Use: Bearer <token>",
+			})
+		}
+
+		claims, err := verifier.Verify(strings.TrimPrefix(authHeader, "Bearer "))
+		if err != nil {
+			message := "Invalid token"
+			switch {
+			case errors.Is(err, auth.ErrExpiredToken):
+				message = "Token has expired"
+			case errors.Is(err, auth.ErrRevokedToken):
+				message = "Token has been revoked"
+			}
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": message,
+			})
+		}
+
+		c.Request().Header.Set(HeaderUserID, claims.UserID)
+		c.Request().Header.Set(HeaderUserRole, claims.Role)
+		c.Locals("claims", claims)
+		return c.Next()
+	}
+}
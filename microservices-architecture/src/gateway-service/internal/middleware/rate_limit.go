@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"gateway-service/config"
+	"gateway-service/internal/auth"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/limiter"
+)
+
+// RateLimit counts requests per user, or per client IP for anonymous
+// requests, in fixed windows. Rejected requests get a Retry-After. It must
+// run after Authenticate. Each call keeps its own counters, so every route
+// has a separate budget.
+func RateLimit(limit config.RateLimit) fiber.Handler {
+	return limiter.New(limiter.Config{
+		Max:        limit.Requests,
+		Expiration: limit.Window,
+		KeyGenerator: func(c *fiber.Ctx) string {
+			if claims, ok := c.Locals("claims").(*auth.Claims); ok {
+				return "user:" + claims.UserID
+			}
+			return "ip:" + c.IP()
+		},
+		LimitReached: func(c *fiber.Ctx) error {
+			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+				"error": "Too many requests, please try again later",
+			})
+		},
+	})
+}
@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"log/slog"
+	"time"
+
+	"platform/logging"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RequestID passes on the client's X-Request-ID, or assigns a new one, and
+// echoes it in the response. The ID is forwarded upstream and stored in the
+// request context and the "requestID" local.
+func RequestID() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id := logging.ResolveRequestID(c.Get(logging.HeaderRequestID))
+
+		c.Request().Header.Set(logging.HeaderRequestID, id)
+		c.Locals("requestID", id)
+		c.SetUserContext(logging.WithRequestID(c.UserContext(), id))
+		err := c.Next()
+
+		// Set afterwards, as proxied responses replace the headers
+		c.Set(logging.HeaderRequestID, id)
+		return err
+	}
+}
+
+// AccessLog logs a line for every request once it has been answered. It
+// goes after RequestID.
+func AccessLog() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+		ctx := c.UserContext()
+
+		// Let the error handler write the response first so its status is logged
+		if err := c.Next(); err != nil {
+			if err := c.App().ErrorHandler(c, err); err != nil {
+				_ = c.SendStatus(fiber.StatusInternalServerError)
+			}
+		}
+
+		status := c.Response().StatusCode()
+		level := slog.LevelInfo
+		if status >= fiber.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		slog.LogAttrs(ctx, level, "request",
+			slog.String("method", c.Method()),
+			slog.String("path", c.Path()),
+			slog.Int("status", status),
+			slog.Float64("duration_ms", float64(time.Since(start).Microseconds())/1000),
+			slog.String("ip", c.IP()),
+		)
+		return nil
+	}
+}
@@ -0,0 +1,89 @@
+package proxy
+
+import (
+	"errors"
+	"fmt"
+	"gateway-service/config"
+	"gateway-service/internal/metrics"
+	"log/slog"
+	"strings"
+	"time"
+
+	"platform/tracing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/proxy"
+	"github.com/valyala/fasthttp"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Forward sends the request to the route's upstream, with the route prefix
+// replaced by its rewrite, and relays the response. The upstream continues
+// the trace from a client span around the call.
+func Forward(route config.Route, upstreamURL string) fiber.Handler {
+	upstreamURL = strings.TrimSuffix(upstreamURL, "/")
+
+	return func(c *fiber.Ctx) error {
+		target := upstreamURL + rewritePath(c.OriginalURL(), route.Prefix, route.Rewrite)
+
+		c.Request().Header.Set(fiber.HeaderXForwardedFor, c.IP())
+		c.Request().Header.Set(fiber.HeaderXForwardedHost, c.Hostname())
+		c.Request().Header.Set(fiber.HeaderXForwardedProto, c.Protocol())
+
+		ctx, span := tracing.Tracer().Start(c.UserContext(), c.Method()+" "+route.Upstream,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(semconv.HTTPRequestMethodKey.String(c.Method()), semconv.ServerAddress(route.Upstream)),
+		)
+		defer span.End()
+		tracing.InjectRequest(ctx, c)
+
+		start := time.Now()
+		if err := proxy.DoTimeout(c, target, route.Timeout); err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			slog.ErrorContext(c.UserContext(), "failed to forward request", "method", c.Method(), "path", c.Path(), "upstream", route.Upstream, "error", err)
+			if errors.Is(err, fasthttp.ErrTimeout) {
+				metrics.ObserveClient(route.Upstream, c.Method(), metrics.OutcomeTimeout, start)
+				return c.Status(fiber.StatusGatewayTimeout).JSON(fiber.Map{
+					"error": "Upstream service timed out",
+				})
+			}
+			metrics.ObserveClient(route.Upstream, c.Method(), metrics.OutcomeUnavailable, start)
+			return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+				"error": "Upstream service is unavailable",
+			})
+		}
+
+		outcome := metrics.OutcomeOK
+		status := c.Response().StatusCode()
+		span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+		if status >= fiber.StatusInternalServerError {
+			outcome = metrics.OutcomeUnavailable
+			span.SetStatus(codes.Error, fmt.Sprintf("upstream answered %d", status))
+		} else if status >= fiber.StatusBadRequest {
+			outcome = metrics.OutcomeError
+		}
+		metrics.ObserveClient(route.Upstream, c.Method(), outcome, start)
+
+		c.Response().Header.Del(fiber.HeaderServer)
+		return nil
+	}
+}
+
+// rewritePath replaces prefix at the start of uri, which may carry a query
+// string, with rewrite. Routes match regardless of case, so the prefix is
+// cut by length rather than by comparison; otherwise /USER/... would reach
+// the upstream with its prefix intact.
+func rewritePath(uri, prefix, rewrite string) string {
+	rest := uri
+	if prefix != "/" && len(uri) >= len(prefix) {
+		rest = uri[len(prefix):]
+	}
+	rewrite = strings.TrimSuffix(rewrite, "/")
+	if rewrite == "" && (rest == "" || rest[0] == '?') {
+		return "/" + rest
+	}
+	return rewrite + rest
+}
@@ -1,71 +1,210 @@
 package main
 
 import (
-	"log"
+	"context"
+	"log/slog"
+	"net"
+	"os"
 	"payment-service/config"
 	"payment-service/internal/client"
+	"payment-service/internal/grpcapi"
 	"payment-service/internal/handler"
+	"payment-service/internal/metrics"
+	"payment-service/internal/middleware"
 	"payment-service/internal/repository"
 	"payment-service/internal/service"
 	"payment-service/migrations"
+	"time"
+
+	"platform/eventbus"
+	"platform/health"
+	"platform/logging"
+	"platform/tracing"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
-	"github.com/gofiber/fiber/v2/middleware/logger"
 )
 
 func main() {
+	// Log JSON lines at LOG_LEVEL
+	logging.Setup("payment-service")
+
+	ctx := context.Background()
+
+	// Trace requests, queries and calls to OTEL_TRACES_EXPORTER (default none)
+	shutdownTracing, err := tracing.Setup(ctx, "payment-service")
+	if err != nil {
+		logging.Fatal("Failed to set up tracing", "error", err)
+	}
+	defer shutdownTracing(context.Background())
+
 	// Connect to database
 	config.ConnectDatabase()
 
 	// Run migrations
 	migrations.RunMigrations(config.DB)
 
+	if err := config.DB.Use(tracing.GormPlugin()); err != nil {
+		logging.Fatal("Failed to trace database", "error", err)
+	}
+
+	// Expose metrics on /metrics unless METRICS_ENABLED=false
+	metricsEnabled := metrics.Enabled()
+	if metricsEnabled {
+		if err := config.DB.Use(metrics.GormPlugin()); err != nil {
+			logging.Fatal("Failed to instrument database", "error", err)
+		}
+	}
+
 	// Initialize clients
-	userClient := client.NewUserClient()
-	bookingClient := client.NewBookingClient()
-	webhookClient := client.NewWebhookClient()
+	transport := client.NewTransport(client.LoadTransportConfig())
+	userAPI := client.NewSDKClient(transport)
+	serviceTokens := client.NewServiceTokenSource(userAPI)
+	userClient, err := client.NewUserClient(transport, serviceTokens)
+	if err != nil {
+		logging.Fatal("Failed to create user service client", "error", err)
+	}
+	bookingClient, err := client.NewBookingClient(transport, serviceTokens)
+	if err != nil {
+		logging.Fatal("Failed to create booking service client", "error", err)
+	}
 
 	// Initialize repositories
 	paymentRepo := repository.NewPaymentRepository(config.DB)
+	deliveryRepo := repository.NewOutboundDeliveryRepository(config.DB)
+	processedRepo := repository.NewProcessedMessageRepository(config.DB)
+
+	// Connect to the event bus
+	bus, err := eventbus.New(ctx, eventbus.LoadConfig(), config.ConnectEventBusDatabase())
+	if err != nil {
+		logging.Fatal("Failed to connect to event bus", "error", err)
+	}
+	defer bus.Close()
 
 	// Initialize services
-	paymentService := service.NewPaymentService(paymentRepo, bookingClient, userClient, webhookClient)
+	deliveryConfig := service.LoadDeliveryConfig()
+	deliveryService := service.NewDeliveryService(deliveryRepo, bus, deliveryConfig)
+	paymentService := service.NewPaymentService(config.DB, paymentRepo, processedRepo, bookingClient, userClient, deliveryService)
 
 	// Initialize handlers
+	serviceTokenVerifier := middleware.NewServiceTokenVerifier(userAPI)
+	paymentServer := grpcapi.NewPaymentServer(paymentService)
 	paymentHandler := handler.NewPaymentHandler(paymentService, userClient)
+	bookingEventHandler := handler.NewBookingEventHandler(paymentService)
+	deliveryHandler := handler.NewDeliveryHandler(deliveryService)
+
+	// Expire payments of bookings that ran out unpaid
+	if err := bookingEventHandler.Subscribe(bus); err != nil {
+		logging.Fatal("Failed to subscribe to booking events", "error", err)
+	}
+
+	// Re-send events the event bus did not accept
+	go func() {
+		ticker := time.NewTicker(deliveryConfig.RetryInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if _, err := deliveryService.RetryDue(ctx); err != nil {
+				slog.Error("Failed to retry deliveries", "error", err)
+			}
+		}
+	}()
+
+	// Readiness checks the database and, with HEALTH_CHECK_DOWNSTREAM=true,
+	// that the services called can be reached
+	healthConfig := health.LoadConfig()
+	checker := health.NewChecker("payment-service", healthConfig)
+	checker.Add("database", health.Database(config.DB, healthConfig.PoolSaturation))
+	checker.AddDownstream("user-service", health.Reachable(client.UserServiceAddr()))
+	checker.AddDownstream("booking-service", health.Reachable(client.BookingServiceAddr()))
 
 	// Initialize Fiber app
 	app := fiber.New(fiber.Config{
-		AppName: "Payment Service",
+		AppName:               "Payment Service",
+		DisableStartupMessage: true, // "Server starting" is logged instead
+		// Requests arrive through the gateway, which passes the client
+		// address on in X-Forwarded-For
+		ProxyHeader:             fiber.HeaderXForwardedFor,
+		EnableTrustedProxyCheck: true,
+		TrustedProxies:          config.TrustedProxies(),
+		EnableIPValidation:      true,
 	})
 
 	// Middleware
-	app.Use(logger.New())
+	app.Use(middleware.RequestID())
+	app.Use(tracing.HTTP())
+	app.Use(middleware.AccessLog())
+	if metricsEnabled {
+		app.Use(metrics.HTTP())
+		app.Get("/metrics", metrics.Handler())
+	}
 	app.Use(cors.New())
+	app.Use(middleware.Deadline("REQUEST_TIMEOUT", 10*time.Second))
 
 	// Routes
 	api := app.Group("/api/v1")
 
 	// Payment routes
 	payments := api.Group("/payments")
-	payments.Post("/webhook/payment-gateway", paymentHandler.HandlePaymentGatewayWebhook)
-	// payments.Post("/webhook/booking", paymentHandler.HandleBookingWebhook) // Webhook from booking service
+	payments.Post("/webhook/payment-gateway",
+		middleware.Deadline("REQUEST_TIMEOUT_GATEWAY_WEBHOOK", 20*time.Second),
+		paymentHandler.HandlePaymentGatewayWebhook,
+	)
 	payments.Post("/", paymentHandler.CreatePayment)
 	payments.Get("/", paymentHandler.GetAllPayments)
-	payments.Get("/:id", paymentHandler.GetPaymentByID)
 	payments.Put("/:id/status", paymentHandler.UpdatePaymentStatus)
 
-	app.Get("/health", func(c *fiber.Ctx) error {
-		return c.JSON(fiber.Map{
-			"status":  "ok",
-			"service": "payment-service",
-		})
+	// Internal routes, callable by other services and by partners with an API key
+	payments.Get("/:id",
+		middleware.ServiceTokenOrAPIKey(
+			middleware.RequireServiceToken(serviceTokenVerifier, "payments:read"),
+			middleware.RequireAPIKey(userClient, "payments:read"),
+		),
+		paymentHandler.GetPaymentByID,
+	)
+
+	// Admin routes
+	admin := api.Group("/admin", middleware.Authenticate(userClient), middleware.RequireRole("admin"))
+	admin.Get("/deliveries", deliveryHandler.GetDeliveries)
+	admin.Post("/deliveries/replay", deliveryHandler.ReplayDeliveries)
+	admin.Post("/deliveries/:id/replay", deliveryHandler.ReplayDelivery)
+
+	// Probes: /livez while the process is up, /readyz while its dependencies
+	// are usable. /health is kept for existing callers.
+	app.Get("/livez", checker.Livez)
+	app.Get("/readyz", checker.Readyz)
+	app.Get("/health", checker.Readyz)
+
+	// Start the internal gRPC API on its own port
+	grpcPort := os.Getenv("GRPC_PORT")
+	if grpcPort == "" {
+		grpcPort = "50053"
+	}
+	grpcListener, err := net.Listen("tcp", ":"+grpcPort)
+	if err != nil {
+		logging.Fatal("Failed to listen for gRPC", "error", err)
+	}
+	grpcServer := grpcapi.NewServer(serviceTokenVerifier, paymentServer)
+	go func() {
+		slog.Info("gRPC server starting", "port", grpcPort)
+		if err := grpcServer.Serve(grpcListener); err != nil {
+			logging.Fatal("Failed to start gRPC server", "error", err)
+		}
+	}()
+
+	// On SIGINT or SIGTERM, report not ready, give load balancers time to
+	// drain the service and finish the requests in flight
+	stopped := checker.OnShutdown(func(ctx context.Context) {
+		if err := app.ShutdownWithContext(ctx); err != nil {
+			slog.Error("Failed to shut down server", "error", err)
+		}
+		grpcServer.GracefulStop()
 	})
 
 	port := ":3003"
-	log.Printf("Server starting on port %s", port)
+	slog.Info("Server starting", "port", port)
 	if err := app.Listen(port); err != nil {
-		log.Fatal("Failed to start server:", err)
+		logging.Fatal("Failed to start server", "error", err)
 	}
+	<-stopped
+	slog.Info("Server stopped")
 }
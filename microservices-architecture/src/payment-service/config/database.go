@@ -2,8 +2,11 @@ package config
 
 import (
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
+	"strconv"
+
+	"platform/logging"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
@@ -21,13 +24,44 @@ func ConnectDatabase() {
 		getEnv("DB_PORT", "5432"),
 	)
 
-	database, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	database, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logging.GormLogger()})
+	if err != nil {
+		logging.Fatal("Failed to connect to database", "error", err)
+	}
+
+	// Bounded, so that readiness checks can tell when the pool is saturated
+	sqlDB, err := database.DB()
 	if err != nil {
-		log.Fatal("Failed to connect to database:", err)
+		logging.Fatal("Failed to configure database pool", "error", err)
 	}
+	sqlDB.SetMaxOpenConns(getEnvInt("DB_MAX_OPEN_CONNS", 50))
 
 	DB = database
-	log.Println("Database connected successfully")
+	slog.Info("Database connected successfully")
+}
+
+// ConnectEventBusDatabase returns the database holding the postgres event
+// bus, EVENT_BUS_DATABASE_URL, which every service must share. It is nil
+// when the variable is unset, which the postgres driver refuses.
+func ConnectEventBusDatabase() *gorm.DB {
+	dsn := os.Getenv("EVENT_BUS_DATABASE_URL")
+	if dsn == "" {
+		return nil
+	}
+
+	database, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logging.GormLogger()})
+	if err != nil {
+		logging.Fatal("Failed to connect to event bus database", "error", err)
+	}
+	return database
+}
+
+func getEnvInt(key string, defaultValue int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+	return value
 }
 
 func getEnv(key, defaultValue string) string {
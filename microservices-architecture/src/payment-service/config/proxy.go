@@ -0,0 +1,19 @@
+package config
+
+import (
+	"os"
+	"strings"
+)
+
+// TrustedProxies returns the addresses or CIDR ranges listed in the comma
+// separated TRUSTED_PROXIES, normally just the gateway. The client address
+// is only taken from X-Forwarded-For on requests coming from one of them.
+func TrustedProxies() []string {
+	var proxies []string
+	for _, proxy := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+	return proxies
+}
@@ -1,70 +1,95 @@
 package client
 
 import (
-	"encoding/json"
-	"fmt"
-	"io"
-	"net/http"
+	"context"
+	"errors"
 	"os"
 	"time"
 
+	bookingv1 "proto/booking/v1"
+	"sdk"
+
 	"github.com/google/uuid"
 )
 
 type BookingClient interface {
-	GetBookingByID(bookingID uuid.UUID) (*BookingResponse, error)
+	GetBookingByID(ctx context.Context, bookingID uuid.UUID) (*sdk.Booking, error)
 }
 
 type bookingClient struct {
-	baseURL string
+	rpc    bookingv1.BookingServiceClient
+	tokens TokenSource
 }
 
-type BookingResponse struct {
-	ID          uuid.UUID  `json:"id"`
-	UserID      uuid.UUID  `json:"user_id"`
-	EventID     uuid.UUID  `json:"event_id"`
-	TicketID    uuid.UUID  `json:"ticket_id"`
-	Quantity    int        `json:"quantity"`
-	TotalAmount float64    `json:"total_amount"`
-	Status      string     `json:"status"`
-	ExpiredAt   *time.Time `json:"expired_at"`
-}
+// BookingServiceAudience is the audience of service tokens for booking-service
+const BookingServiceAudience = "booking-service"
 
-type BookingServiceResponse struct {
-	Message string          `json:"message"`
-	Data    BookingResponse `json:"data"`
+// BookingServiceAddr returns the BookingService gRPC target,
+// BOOKING_SERVICE_GRPC_ADDR
+func BookingServiceAddr() string {
+	if addr := os.Getenv("BOOKING_SERVICE_GRPC_ADDR"); addr != "" {
+		return addr
+	}
+	return "localhost:50052"
 }
 
-func NewBookingClient() BookingClient {
-	baseURL := os.Getenv("BOOKING_SERVICE_URL")
-	if baseURL == "" {
-		baseURL = "http://localhost:3001"
+// NewBookingClient calls the BookingService gRPC API at
+// BOOKING_SERVICE_GRPC_ADDR
+func NewBookingClient(transport *Transport, tokens TokenSource) (BookingClient, error) {
+	conn, err := transport.DialGRPC(BookingServiceAddr())
+	if err != nil {
+		return nil, err
 	}
-	return &bookingClient{baseURL: baseURL}
+	return &bookingClient{rpc: bookingv1.NewBookingServiceClient(conn), tokens: tokens}, nil
 }
 
-func (c *bookingClient) GetBookingByID(bookingID uuid.UUID) (*BookingResponse, error) {
-	url := fmt.Sprintf("%s/api/v1/bookings/%s", c.baseURL, bookingID.String())
-
-	resp, err := http.Get(url)
+func (c *bookingClient) GetBookingByID(ctx context.Context, bookingID uuid.UUID) (*sdk.Booking, error) {
+	ctx, err := withServiceToken(ctx, c.tokens, BookingServiceAudience)
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to booking service: %v", err)
+		return nil, err
 	}
-	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
+	booking, err := c.rpc.GetBooking(ctx, &bookingv1.GetBookingRequest{Id: bookingID.String()})
 	if err != nil {
 		return nil, err
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("booking service returned status %d: %s", resp.StatusCode, string(body))
+	return fromBookingProto(booking)
+}
+
+func fromBookingProto(booking *bookingv1.Booking) (*sdk.Booking, error) {
+	id, err := uuid.Parse(booking.GetId())
+	if err != nil {
+		return nil, errors.New("invalid booking data received")
+	}
+	userID, err := uuid.Parse(booking.GetUserId())
+	if err != nil {
+		return nil, errors.New("invalid booking data received")
+	}
+	eventID, err := uuid.Parse(booking.GetEventId())
+	if err != nil {
+		return nil, errors.New("invalid booking data received")
+	}
+	ticketID, err := uuid.Parse(booking.GetTicketId())
+	if err != nil {
+		return nil, errors.New("invalid booking data received")
 	}
 
-	var bookingResp BookingServiceResponse
-	if err := json.Unmarshal(body, &bookingResp); err != nil {
-		return nil, err
+	var expiredAt *time.Time
+	if booking.GetExpiredAt() != nil {
+		t := booking.GetExpiredAt().AsTime()
+		expiredAt = &t
 	}
 
-	return &bookingResp.Data, nil
+	return &sdk.Booking{
+		ID:          id,
+		UserID:      userID,
+		EventID:     eventID,
+		TicketID:    ticketID,
+		Quantity:    int(booking.GetQuantity()),
+		TotalAmount: booking.GetTotalAmount(),
+		Status:      booking.GetStatus(),
+		ExpiredAt:   expiredAt,
+		CreatedAt:   booking.GetCreatedAt().AsTime(),
+	}, nil
 }
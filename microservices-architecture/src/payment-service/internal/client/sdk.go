@@ -0,0 +1,21 @@
+package client
+
+import (
+	"os"
+
+	"sdk"
+)
+
+// NewSDKClient returns a client for the public HTTP APIs of user service at
+// USER_SERVICE_URL. Its requests go through transport.
+func NewSDKClient(transport *Transport) *sdk.Client {
+	baseURL := os.Getenv("USER_SERVICE_URL")
+	if baseURL == "" {
+		baseURL = "http://localhost:3000"
+	}
+
+	return sdk.New(sdk.Config{
+		UserServiceURL: baseURL,
+		HTTPClient:     transport.HTTPClient(),
+	})
+}
@@ -0,0 +1,97 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"sdk"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// TokenSource provides service access tokens for calls to internal APIs
+type TokenSource interface {
+	// Token returns a bearer token for audience, or an empty string when no
+	// service credentials are configured
+	Token(ctx context.Context, audience string) (string, error)
+}
+
+type cachedToken struct {
+	value     string
+	expiresAt time.Time
+}
+
+type serviceTokenSource struct {
+	api          *sdk.Client
+	clientID     string
+	clientSecret string
+
+	mu     sync.Mutex
+	tokens map[string]cachedToken
+}
+
+// NewServiceTokenSource fetches client credentials tokens from user-service
+// using SERVICE_CLIENT_ID and SERVICE_CLIENT_SECRET, and caches them until
+// shortly before they expire
+func NewServiceTokenSource(api *sdk.Client) TokenSource {
+	clientID := os.Getenv("SERVICE_CLIENT_ID")
+	if clientID == "" {
+		slog.Warn("SERVICE_CLIENT_ID is not set, calls to internal APIs will be unauthenticated")
+	}
+
+	return &serviceTokenSource{
+		api:          api,
+		clientID:     clientID,
+		clientSecret: os.Getenv("SERVICE_CLIENT_SECRET"),
+		tokens:       make(map[string]cachedToken),
+	}
+}
+
+func (s *serviceTokenSource) Token(ctx context.Context, audience string) (string, error) {
+	if s.clientID == "" {
+		return "", nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if cached, ok := s.tokens[audience]; ok && time.Now().Before(cached.expiresAt) {
+		return cached.value, nil
+	}
+
+	tokenResp, err := s.api.Users.Token(ctx, sdk.TokenRequest{
+		GrantType:    sdk.GrantClientCredentials,
+		Audience:     audience,
+		ClientID:     s.clientID,
+		ClientSecret: s.clientSecret,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	// Renew a little early so that a token never expires in flight
+	lifetime := time.Duration(tokenResp.ExpiresIn)*time.Second - 30*time.Second
+	s.tokens[audience] = cachedToken{
+		value:     tokenResp.AccessToken,
+		expiresAt: time.Now().Add(lifetime),
+	}
+
+	return tokenResp.AccessToken, nil
+}
+
+// withServiceToken attaches a service token for audience to the outgoing
+// metadata of ctx
+func withServiceToken(ctx context.Context, tokens TokenSource, audience string) (context.Context, error) {
+	token, err := tokens.Token(ctx, audience)
+	if err != nil {
+		return nil, fmt.Errorf("failed to obtain service token: %w", err)
+	}
+	if token != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token)
+	}
+	return ctx, nil
+}
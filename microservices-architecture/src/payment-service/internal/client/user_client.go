@@ -1,76 +1,97 @@
 package client
 
 import (
-	"encoding/json"
+	"context"
 	"errors"
-	"fmt"
-	"io"
-	"net/http"
 	"os"
+
+	userauthv1 "proto/userauth/v1"
+	"sdk"
+
+	"github.com/google/uuid"
 )
 
 type UserClient interface {
-	GetAuthenticatedUser(authToken string) (*UserResponse, error)
+	GetAuthenticatedUser(ctx context.Context, authToken string) (*sdk.User, error)
+	GetAPIKeyUser(ctx context.Context, apiKey string) (*sdk.APIKeyIntrospection, error)
 }
 
 type userClient struct {
-	baseURL string
+	rpc    userauthv1.UserAuthServiceClient
+	tokens TokenSource
 }
 
-type UserResponse struct {
-	ID       string `json:"id"`
-	Username string `json:"username"`
-}
+// UserServiceAudience is the audience of service tokens for user-service
+const UserServiceAudience = "user-service"
 
-type UserServiceResponse struct {
-	Message string       `json:"message"`
-	Data    UserResponse `json:"data"`
+// UserServiceAddr returns the UserAuthService gRPC target,
+// USER_SERVICE_GRPC_ADDR
+func UserServiceAddr() string {
+	if addr := os.Getenv("USER_SERVICE_GRPC_ADDR"); addr != "" {
+		return addr
+	}
+	return "localhost:50051"
 }
 
-func NewUserClient() UserClient {
-	baseURL := os.Getenv("USER_SERVICE_URL")
-	if baseURL == "" {
-		baseURL = "http://localhost:3000"
+// NewUserClient calls the UserAuthService gRPC API at USER_SERVICE_GRPC_ADDR
+func NewUserClient(transport *Transport, tokens TokenSource) (UserClient, error) {
+	conn, err := transport.DialGRPC(UserServiceAddr())
+	if err != nil {
+		return nil, err
 	}
-	return &userClient{baseURL: baseURL}
+	return &userClient{rpc: userauthv1.NewUserAuthServiceClient(conn), tokens: tokens}, nil
 }
 
-func (c *userClient) GetAuthenticatedUser(authToken string) (*UserResponse, error) {
-	url := fmt.Sprintf("%s/api/v1/users/auth", c.baseURL)
-
-	req, err := http.NewRequest("GET", url, nil)
+func (c *userClient) GetAuthenticatedUser(ctx context.Context, authToken string) (*sdk.User, error) {
+	user, err := c.rpc.AuthenticateUser(ctx, &userauthv1.AuthenticateUserRequest{AccessToken: authToken})
 	if err != nil {
 		return nil, err
 	}
 
-	if authToken != "" {
-		req.Header.Set("Authorization", authToken)
-	}
+	return fromUserProto(user)
+}
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+// GetAPIKeyUser resolves an X-API-Key header through user service. Each call
+// is counted as one request made with the key.
+func (c *userClient) GetAPIKeyUser(ctx context.Context, apiKey string) (*sdk.APIKeyIntrospection, error) {
+	ctx, err := withServiceToken(ctx, c.tokens, UserServiceAudience)
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to user service: %v", err)
+		return nil, err
 	}
-	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
+	key, err := c.rpc.IntrospectAPIKey(ctx, &userauthv1.IntrospectAPIKeyRequest{ApiKey: apiKey})
 	if err != nil {
 		return nil, err
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("user service returned status %d: %s", resp.StatusCode, string(body))
+	keyID, err := uuid.Parse(key.GetKeyId())
+	if err != nil {
+		return nil, errors.New("invalid API key data received")
 	}
-
-	var userResp UserServiceResponse
-	if err := json.Unmarshal(body, &userResp); err != nil {
-		return nil, err
+	user, err := fromUserProto(key.GetUser())
+	if err != nil {
+		return nil, errors.New("invalid API key data received")
 	}
 
-	if userResp.Data.ID == "" {
+	return &sdk.APIKeyIntrospection{
+		KeyID:  keyID,
+		Scopes: key.GetScopes(),
+		User:   *user,
+	}, nil
+}
+
+func fromUserProto(user *userauthv1.User) (*sdk.User, error) {
+	id, err := uuid.Parse(user.GetId())
+	if err != nil {
 		return nil, errors.New("invalid user data received")
 	}
 
-	return &userResp.Data, nil
+	return &sdk.User{
+		ID:            id,
+		Username:      user.GetUsername(),
+		Email:         user.GetEmail(),
+		EmailVerified: user.GetEmailVerified(),
+		Role:          user.GetRole(),
+		MFAEnabled:    user.GetMfaEnabled(),
+	}, nil
 }
@@ -0,0 +1,111 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"payment-service/internal/middleware"
+	"payment-service/internal/model"
+	"payment-service/internal/service"
+	"time"
+
+	"platform/tracing"
+	paymentv1 "proto/payment/v1"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// paymentScopes lists the service token scopes each RPC requires
+var paymentScopes = map[string][]string{
+	paymentv1.PaymentService_CreatePayment_FullMethodName: {"payments:write"},
+	paymentv1.PaymentService_GetPayment_FullMethodName:    {"payments:read"},
+}
+
+type PaymentServer struct {
+	paymentv1.UnimplementedPaymentServiceServer
+	service service.PaymentService
+}
+
+func NewPaymentServer(service service.PaymentService) *PaymentServer {
+	return &PaymentServer{service: service}
+}
+
+// NewServer returns a gRPC server exposing PaymentService to callers with a
+// service token
+func NewServer(verifier *middleware.ServiceTokenVerifier, paymentServer *PaymentServer) *grpc.Server {
+	server := grpc.NewServer(grpc.ChainUnaryInterceptor(
+		tracing.UnaryServer(),
+		middleware.UnaryRequestID(),
+		middleware.UnaryServiceToken(verifier, paymentScopes),
+	))
+	paymentv1.RegisterPaymentServiceServer(server, paymentServer)
+	return server
+}
+
+// CreatePayment returns the existing payment when the booking already has
+// one, so that retried calls pick up the payment they created
+func (s *PaymentServer) CreatePayment(ctx context.Context, req *paymentv1.CreatePaymentRequest) (*paymentv1.Payment, error) {
+	bookingID, err := uuid.Parse(req.GetBookingId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid booking ID format")
+	}
+
+	payment, err := s.service.CreatePayment(ctx, bookingID, req.GetAmount(), req.GetPaymentMethod())
+	switch {
+	case errors.Is(err, service.ErrPaymentExists):
+	case errors.Is(err, service.ErrBookingNotFound):
+		return nil, status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, service.ErrBookingServiceUnavailable):
+		return nil, status.Error(codes.Unavailable, err.Error())
+	case err != nil:
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	return toPaymentProto(payment), nil
+}
+
+func (s *PaymentServer) GetPayment(ctx context.Context, req *paymentv1.GetPaymentRequest) (*paymentv1.Payment, error) {
+	id, err := uuid.Parse(req.GetId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid payment ID")
+	}
+
+	payment, err := s.service.GetPaymentByID(ctx, id)
+	switch {
+	case errors.Is(err, service.ErrPaymentNotFound):
+		return nil, status.Error(codes.NotFound, err.Error())
+	case err != nil:
+		slog.ErrorContext(ctx, "Failed to get payment", "payment_id", id, "error", err)
+		return nil, status.Error(codes.Internal, "failed to get payment")
+	}
+
+	return toPaymentProto(payment), nil
+}
+
+func toPaymentProto(payment *model.PaymentResponse) *paymentv1.Payment {
+	return &paymentv1.Payment{
+		Id:            payment.ID.String(),
+		BookingId:     payment.BookingID.String(),
+		UserId:        payment.UserID.String(),
+		Amount:        payment.Amount,
+		Currency:      payment.Currency,
+		PaymentMethod: payment.PaymentMethod,
+		Status:        payment.Status,
+		ExpiredAt:     optionalTimestamp(payment.ExpiredAt),
+		PaidAt:        optionalTimestamp(payment.PaidAt),
+		CreatedAt:     timestamppb.New(payment.CreatedAt),
+		UpdatedAt:     timestamppb.New(payment.UpdatedAt),
+	}
+}
+
+// optionalTimestamp leaves the field unset for a nil time
+func optionalTimestamp(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"payment-service/internal/service"
+
+	"platform/eventbus"
+
+	"github.com/google/uuid"
+)
+
+// ConsumerGroup is the event bus consumer group of payment service
+const ConsumerGroup = "payment-service"
+
+// BookingEventHandler closes the payments of bookings that expired or were
+// cancelled, marking paid ones for refund
+type BookingEventHandler struct {
+	service service.PaymentService
+}
+
+func NewBookingEventHandler(service service.PaymentService) *BookingEventHandler {
+	return &BookingEventHandler{service: service}
+}
+
+// Subscribe registers the handler for booking.expired and booking.cancelled
+func (h *BookingEventHandler) Subscribe(bus eventbus.EventBus) error {
+	if err := bus.Subscribe(eventbus.SubjectBookingExpired, ConsumerGroup, h.HandleBookingExpired); err != nil {
+		return err
+	}
+	return bus.Subscribe(eventbus.SubjectBookingCancelled, ConsumerGroup, h.HandleBookingCancelled)
+}
+
+func (h *BookingEventHandler) HandleBookingExpired(ctx context.Context, msg eventbus.Message) error {
+	return h.handleBooking(ctx, msg, h.service.HandleBookingExpired)
+}
+
+func (h *BookingEventHandler) HandleBookingCancelled(ctx context.Context, msg eventbus.Message) error {
+	return h.handleBooking(ctx, msg, h.service.HandleBookingCancelled)
+}
+
+func (h *BookingEventHandler) handleBooking(ctx context.Context, msg eventbus.Message, handle func(ctx context.Context, eventID, bookingID uuid.UUID) error) error {
+	var event eventbus.BookingEvent
+	if err := msg.Decode(&event); err != nil || event.BookingID == uuid.Nil {
+		return eventbus.Permanent(errors.New("invalid booking event payload"))
+	}
+
+	eventID, err := msg.EventID(event.EventID)
+	if err != nil {
+		return eventbus.Permanent(errors.New("invalid booking event payload"))
+	}
+
+	err = handle(ctx, eventID, event.BookingID)
+	if errors.Is(err, service.ErrDuplicateEvent) {
+		slog.InfoContext(ctx, "Event ignored: already processed", "subject", msg.Subject, "event_id", eventID, "booking_id", event.BookingID)
+		return nil
+	}
+	return err
+}
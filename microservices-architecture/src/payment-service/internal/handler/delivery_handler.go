@@ -0,0 +1,171 @@
+package handler
+
+import (
+	"errors"
+	"payment-service/internal/model"
+	"payment-service/internal/repository"
+	"payment-service/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type DeliveryHandler struct {
+	service service.DeliveryService
+}
+
+func NewDeliveryHandler(service service.DeliveryService) *DeliveryHandler {
+	return &DeliveryHandler{service: service}
+}
+
+// ReplayDeliveriesRequest selects deliveries to replay, either by ID or by
+// status and optionally subject
+type ReplayDeliveriesRequest struct {
+	IDs     []string `json:"ids"`
+	Status  string   `json:"status"`
+	Subject string   `json:"subject"`
+	Limit   int      `json:"limit"`
+}
+
+var deliveryStatuses = map[string]bool{
+	model.DeliveryStatusPending:   true,
+	model.DeliveryStatusDelivered: true,
+	model.DeliveryStatusFailed:    true,
+	model.DeliveryStatusDead:      true,
+}
+
+// GetDeliveries lists outbound deliveries, newest first
+func (h *DeliveryHandler) GetDeliveries(c *fiber.Ctx) error {
+	filter := repository.OutboundDeliveryFilter{
+		Status:  c.Query("status"),
+		Subject: c.Query("subject"),
+		Limit:   c.QueryInt("limit", 100),
+	}
+	if filter.Status != "" && !deliveryStatuses[filter.Status] {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid status",
+		})
+	}
+
+	deliveries, err := h.service.ListDeliveries(c.UserContext(), filter)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve deliveries",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Deliveries retrieved successfully",
+		"data":    deliveries,
+	})
+}
+
+// ReplayDelivery publishes one delivery again
+func (h *DeliveryHandler) ReplayDelivery(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid delivery ID",
+		})
+	}
+
+	delivery, err := h.service.Replay(c.UserContext(), id)
+	if err != nil {
+		if errors.Is(err, service.ErrDeliveryNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		if errors.Is(err, service.ErrDeliveryInProgress) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to replay delivery",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Delivery replayed",
+		"data":    delivery,
+	})
+}
+
+// ReplayDeliveries publishes the listed deliveries, or every delivery with
+// the given status, again
+func (h *DeliveryHandler) ReplayDeliveries(c *fiber.Ctx) error {
+	var req ReplayDeliveriesRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if len(req.IDs) == 0 {
+		if !deliveryStatuses[req.Status] {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "ids or a valid status is required",
+			})
+		}
+		if req.Limit <= 0 {
+			req.Limit = 100
+		}
+
+		deliveries, err := h.service.ReplayAll(c.UserContext(), repository.OutboundDeliveryFilter{
+			Status:  req.Status,
+			Subject: req.Subject,
+			Limit:   req.Limit,
+		})
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to replay deliveries",
+			})
+		}
+
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{
+			"message": "Deliveries replayed",
+			"data":    deliveries,
+		})
+	}
+
+	ids := make([]uuid.UUID, 0, len(req.IDs))
+	for _, raw := range req.IDs {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid delivery ID " + raw,
+			})
+		}
+		ids = append(ids, id)
+	}
+
+	deliveries := make([]model.OutboundDeliveryResponse, 0, len(ids))
+	for _, id := range ids {
+		delivery, err := h.service.Replay(c.UserContext(), id)
+		if err != nil {
+			if errors.Is(err, service.ErrDeliveryNotFound) {
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+					"error": "delivery " + id.String() + " not found",
+					"data":  deliveries,
+				})
+			}
+			if errors.Is(err, service.ErrDeliveryInProgress) {
+				return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+					"error": "delivery " + id.String() + " is being published, try again shortly",
+					"data":  deliveries,
+				})
+			}
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to replay deliveries",
+				"data":  deliveries,
+			})
+		}
+		deliveries = append(deliveries, *delivery)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Deliveries replayed",
+		"data":    deliveries,
+	})
+}
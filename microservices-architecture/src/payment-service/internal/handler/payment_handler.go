@@ -1,8 +1,12 @@
 package handler
 
 import (
+	"errors"
 	"payment-service/internal/client"
 	"payment-service/internal/service"
+	"sdk"
+
+	"platform/tracing"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
@@ -35,13 +39,9 @@ type UpdatePaymentStatusRequest struct {
 }
 
 type PaymentGatewayWebhookRequest struct {
-	PaymentID string `json:"payment_id" validate:"required"`
-	Status    string `json:"status" validate:"required"`
-}
-type BookingWebhookRequest struct {
-	Event     string `json:"event"`      // booking.expired, booking.cancelled
-	BookingID string `json:"booking_id"`
-	Status    string `json:"status"`
+	PaymentID   string `json:"payment_id" validate:"required"`
+	Status      string `json:"status" validate:"required"`
+	Traceparent string `json:"traceparent"` // optional W3C trace context to continue
 }
 
 func (h *PaymentHandler) CreatePayment(c *fiber.Ctx) error {
@@ -66,9 +66,22 @@ func (h *PaymentHandler) CreatePayment(c *fiber.Ctx) error {
 		})
 	}
 
-	payment, err := h.service.CreatePayment(bookingID, req.Amount, req.PaymentMethod)
+	payment, err := h.service.CreatePayment(c.UserContext(), bookingID, req.Amount, req.PaymentMethod)
+	if errors.Is(err, service.ErrPaymentExists) {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": err.Error(),
+			"data":  payment,
+		})
+	}
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+		status := fiber.StatusBadRequest
+		switch {
+		case errors.Is(err, service.ErrBookingNotFound):
+			status = fiber.StatusNotFound
+		case errors.Is(err, service.ErrBookingServiceUnavailable):
+			status = fiber.StatusServiceUnavailable
+		}
+		return c.Status(status).JSON(fiber.Map{
 			"error": err.Error(),
 		})
 	}
@@ -88,12 +101,24 @@ func (h *PaymentHandler) GetPaymentByID(c *fiber.Ctx) error {
 		})
 	}
 
-	payment, err := h.service.GetPaymentByID(id)
-	if err != nil {
+	payment, err := h.service.GetPaymentByID(c.UserContext(), id)
+	if errors.Is(err, service.ErrPaymentNotFound) {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"error": err.Error(),
 		})
 	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get payment",
+		})
+	}
+
+	// Partners using an API key may only see their own payments
+	if user, ok := c.Locals("user").(*sdk.User); ok && payment.UserID != user.ID {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "payment not found",
+		})
+	}
 
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
 		"message": "Payment retrieved successfully",
@@ -102,7 +127,7 @@ func (h *PaymentHandler) GetPaymentByID(c *fiber.Ctx) error {
 }
 
 func (h *PaymentHandler) GetAllPayments(c *fiber.Ctx) error {
-	payments, err := h.service.GetAllPayments()
+	payments, err := h.service.GetAllPayments(c.UserContext())
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": err.Error(),
@@ -137,7 +162,7 @@ func (h *PaymentHandler) UpdatePaymentStatus(c *fiber.Ctx) error {
 		})
 	}
 
-	if err := h.service.UpdatePaymentStatus(id, req.Status); err != nil {
+	if err := h.service.UpdatePaymentStatus(c.UserContext(), id, req.Status); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": err.Error(),
 		})
@@ -176,7 +201,12 @@ func (h *PaymentHandler) HandlePaymentGatewayWebhook(c *fiber.Ctx) error {
 		})
 	}
 
-	if err := h.service.HandlePaymentGatewayWebhook(paymentID, req.Status); err != nil {
+	// Events published for the payment join the trace named in the payload,
+	// if any, linked to this request
+	ctx, span := tracing.StartFromPayload(c.UserContext(), "payment-gateway webhook", req.Traceparent)
+	err = h.service.HandlePaymentGatewayWebhook(ctx, paymentID, req.Status)
+	tracing.End(span, err)
+	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": err.Error(),
 		})
@@ -186,44 +216,3 @@ func (h *PaymentHandler) HandlePaymentGatewayWebhook(c *fiber.Ctx) error {
 		"message": "Webhook processed successfully",
 	})
 }
-
-func (h *PaymentHandler) HandleBookingWebhook(c *fiber.Ctx) error {
-	var req BookingWebhookRequest
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request body",
-		})
-	}
-
-	if req.BookingID == "" || req.Event == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "booking_id and event are required",
-		})
-	}
-
-	// Parse booking ID
-	bookingID, err := uuid.Parse(req.BookingID)
-	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid booking ID format",
-		})
-	}
-
-	// Handle different booking events
-	switch req.Event {
-	case "booking.expired", "booking.cancelled":
-		if err := h.service.HandleBookingExpired(bookingID); err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": err.Error(),
-			})
-		}
-	default:
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Unknown event type",
-		})
-	}
-
-	return c.Status(fiber.StatusOK).JSON(fiber.Map{
-		"message": "Booking webhook processed successfully",
-	})
-}
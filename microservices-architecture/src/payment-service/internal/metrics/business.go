@@ -0,0 +1,18 @@
+package metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var payments = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "payments_total",
+	Help: "Payments reaching each status, by payment method. New payments are counted as PENDING.",
+}, []string{"method", "status"})
+
+func init() {
+	Registry.MustRegister(payments)
+}
+
+// PaymentStatus counts a payment of method reaching status once the change
+// is stored
+func PaymentStatus(method, status string) {
+	payments.WithLabelValues(method, status).Inc()
+}
@@ -0,0 +1,102 @@
+package middleware
+
+import (
+	"errors"
+	"payment-service/internal/client"
+	"sdk"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Authenticate requires a user's bearer token. The user is stored in the
+// "user" local.
+func Authenticate(userClient client.UserClient) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		authToken := c.Get("Authorization")
+		if authToken == "" {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Authorization token is required",
+			})
+		}
+
+		user, err := userClient.GetAuthenticatedUser(c.UserContext(), authToken)
+		if err != nil {
+			if errors.Is(err, client.ErrUnavailable) || errors.Is(err, client.ErrTimeout) {
+				return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+					"error": "Authentication service is unavailable, please try again later",
+				})
+			}
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Failed to authenticate user: " + err.Error(),
+			})
+		}
+
+		c.Locals("user", user)
+		return c.Next()
+	}
+}
+
+// RequireRole only lets through users whose role is one of roles. It must
+// run after Authenticate.
+func RequireRole(roles ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if user, ok := c.Locals("user").(*sdk.User); ok {
+			for _, allowed := range roles {
+				if user.Role == allowed {
+					return c.Next()
+				}
+			}
+		}
+
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Insufficient permissions",
+		})
+	}
+}
+
+// RequireAPIKey accepts a partner API key in X-API-Key that carries scope.
+// The key's owner is stored in the "user" local.
+func RequireAPIKey(userClient client.UserClient, scope string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		apiKey := c.Get("X-API-Key")
+		if apiKey == "" {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "API key is required",
+			})
+		}
+
+		key, err := userClient.GetAPIKeyUser(c.UserContext(), apiKey)
+		if err != nil {
+			if errors.Is(err, client.ErrUnavailable) || errors.Is(err, client.ErrTimeout) {
+				return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+					"error": "Authentication service is unavailable, please try again later",
+				})
+			}
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Invalid or revoked API key",
+			})
+		}
+
+		if !containsScope(key.Scopes, scope) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": "API key is missing scope " + scope,
+			})
+		}
+
+		c.Locals("user", &key.User)
+		c.Locals("apiKeyID", key.KeyID)
+		return c.Next()
+	}
+}
+
+// ServiceTokenOrAPIKey routes requests carrying X-API-Key to apiKey and
+// everything else to serviceToken, so a route can serve both internal
+// callers and partners
+func ServiceTokenOrAPIKey(serviceToken, apiKey fiber.Handler) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if c.Get("X-API-Key") != "" {
+			return apiKey(c)
+		}
+		return serviceToken(c)
+	}
+}
@@ -0,0 +1,185 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"log/slog"
+	"math/big"
+	"os"
+	"sdk"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// jwksRefreshInterval limits how often an unknown key ID triggers a refetch
+const jwksRefreshInterval = 30 * time.Second
+
+// ServiceTokenVerifier verifies client credentials tokens issued by
+// user-service against its published JWKS
+type ServiceTokenVerifier struct {
+	api      *sdk.Client
+	issuer   string
+	audience string
+
+	mu          sync.RWMutex
+	keys        map[string]*rsa.PublicKey
+	lastFetched time.Time
+}
+
+type serviceClaims struct {
+	ClientID string `json:"client_id"`
+	Scope    string `json:"scope"`
+	jwt.RegisteredClaims
+}
+
+// NewServiceTokenVerifier accepts tokens from SERVICE_TOKEN_ISSUER whose
+// audience is SERVICE_AUDIENCE
+func NewServiceTokenVerifier(api *sdk.Client) *ServiceTokenVerifier {
+	issuer := os.Getenv("SERVICE_TOKEN_ISSUER")
+	if issuer == "" {
+		issuer = "http://localhost:3001"
+	}
+
+	audience := os.Getenv("SERVICE_AUDIENCE")
+	if audience == "" {
+		audience = "payment-service"
+	}
+
+	return &ServiceTokenVerifier{
+		api:      api,
+		issuer:   issuer,
+		audience: audience,
+		keys:     make(map[string]*rsa.PublicKey),
+	}
+}
+
+// Verify parses a service token and checks its signature, issuer, audience
+// and expiry
+func (v *ServiceTokenVerifier) Verify(ctx context.Context, tokenString string) (*serviceClaims, error) {
+	claims := &serviceClaims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		// id_tokens share the signing key, only access tokens are accepted
+		if typ, _ := token.Header["typ"].(string); typ != "at+jwt" {
+			return nil, errors.New("not an access token")
+		}
+		kid, _ := token.Header["kid"].(string)
+		return v.key(ctx, kid)
+	},
+		jwt.WithValidMethods([]string{"RS256"}),
+		jwt.WithIssuer(v.issuer),
+		jwt.WithAudience(v.audience),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil || !token.Valid {
+		return nil, errors.New("invalid service token")
+	}
+	return claims, nil
+}
+
+func (v *ServiceTokenVerifier) key(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	v.mu.RLock()
+	key, ok := v.keys[kid]
+	v.mu.RUnlock()
+	if ok {
+		return key, nil
+	}
+
+	// Unknown key IDs usually mean user-service rotated its key
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if key, ok := v.keys[kid]; ok {
+		return key, nil
+	}
+	if time.Since(v.lastFetched) < jwksRefreshInterval {
+		return nil, errors.New("unknown signing key")
+	}
+
+	keys, err := fetchJWKS(ctx, v.api)
+	v.lastFetched = time.Now()
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to fetch JWKS", "error", err)
+		return nil, err
+	}
+	v.keys = keys
+
+	key, ok = v.keys[kid]
+	if !ok {
+		return nil, errors.New("unknown signing key")
+	}
+	return key, nil
+}
+
+func fetchJWKS(ctx context.Context, api *sdk.Client) (map[string]*rsa.PublicKey, error) {
+	set, err := api.Users.JWKS(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]*rsa.PublicKey)
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" {
+			continue
+		}
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			continue
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			continue
+		}
+		keys[k.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+	return keys, nil
+}
+
+// RequireServiceToken only lets through callers presenting a service token
+// for this service that carries every one of scopes
+func RequireServiceToken(verifier *ServiceTokenVerifier, scopes ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		authHeader := c.Get("Authorization")
+		if !strings.HasPrefix(authHeader, "Bearer ") {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Service token is required",
+			})
+		}
+
+		claims, err := verifier.Verify(c.UserContext(), strings.TrimPrefix(authHeader, "Bearer "))
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Invalid or expired service token",
+			})
+		}
+
+		granted := strings.Fields(claims.Scope)
+		for _, scope := range scopes {
+			if !containsScope(granted, scope) {
+				return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+					"error": "Service token is missing scope " + scope,
+				})
+			}
+		}
+
+		c.Locals("serviceClientID", claims.ClientID)
+		return c.Next()
+	}
+}
+
+func containsScope(scopes []string, scope string) bool {
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
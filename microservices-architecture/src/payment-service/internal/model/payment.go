@@ -14,7 +14,7 @@ type Payment struct {
 	Amount        float64    `gorm:"type:decimal(12,2);not null" json:"amount"`
 	Currency      string     `gorm:"type:varchar(10);default:IDR" json:"currency"`
 	PaymentMethod string     `gorm:"type:varchar(50);not null" json:"payment_method"` // VA, EWALLET, QRIS
-	Status        string     `gorm:"type:varchar(30);not null" json:"status"`         // PENDING, PAID, FAILED, EXPIRED
+	Status        string     `gorm:"type:varchar(30);not null" json:"status"`         // PENDING, PAID, FAILED, EXPIRED, CANCELLED, REFUND_PENDING, REFUNDED
 	ExpiredAt     *time.Time `gorm:"type:timestamp" json:"expired_at"`
 	PaidAt        *time.Time `gorm:"type:timestamp" json:"paid_at"`
 	CreatedAt     time.Time  `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
@@ -0,0 +1,16 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ProcessedMessage marks an incoming event as handled. It is written in
+// the same transaction as the event's state change, so a redelivered event
+// finds it and is skipped.
+type ProcessedMessage struct {
+	EventID     uuid.UUID `gorm:"type:uuid;primaryKey"`
+	Subject     string    `gorm:"type:varchar(255);not null"`
+	ProcessedAt time.Time `gorm:"not null;index"`
+}
@@ -1,19 +1,24 @@
 package repository
 
 import (
+	"context"
 	"payment-service/internal/model"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type PaymentRepository interface {
-	Create(payment *model.Payment) error
-	FindByID(id uuid.UUID) (*model.Payment, error)
-	FindByBookingID(bookingID uuid.UUID) (*model.Payment, error)
-	FindAll() ([]model.Payment, error)
-	Update(payment *model.Payment) error
-	UpdateStatus(id uuid.UUID, status string) error
+	Create(ctx context.Context, payment *model.Payment) error
+	FindByID(ctx context.Context, id uuid.UUID) (*model.Payment, error)
+	FindByIDForUpdate(ctx context.Context, id uuid.UUID) (*model.Payment, error)
+	FindByBookingID(ctx context.Context, bookingID uuid.UUID) (*model.Payment, error)
+	FindByBookingIDForUpdate(ctx context.Context, bookingID uuid.UUID) (*model.Payment, error)
+	FindAll(ctx context.Context) ([]model.Payment, error)
+	Update(ctx context.Context, payment *model.Payment) error
+	UpdateStatus(ctx context.Context, id uuid.UUID, status string) error
+	WithTx(tx *gorm.DB) PaymentRepository
 }
 
 type paymentRepository struct {
@@ -24,38 +29,62 @@ func NewPaymentRepository(db *gorm.DB) PaymentRepository {
 	return &paymentRepository{db: db}
 }
 
-func (r *paymentRepository) Create(payment *model.Payment) error {
-	return r.db.Create(payment).Error
+func (r *paymentRepository) Create(ctx context.Context, payment *model.Payment) error {
+	return r.db.WithContext(ctx).Create(payment).Error
 }
 
-func (r *paymentRepository) FindByID(id uuid.UUID) (*model.Payment, error) {
+func (r *paymentRepository) FindByID(ctx context.Context, id uuid.UUID) (*model.Payment, error) {
 	var payment model.Payment
-	err := r.db.First(&payment, "id = ?", id).Error
+	err := r.db.WithContext(ctx).First(&payment, "id = ?", id).Error
 	if err != nil {
 		return nil, err
 	}
 	return &payment, nil
 }
 
-func (r *paymentRepository) FindByBookingID(bookingID uuid.UUID) (*model.Payment, error) {
+func (r *paymentRepository) FindByIDForUpdate(ctx context.Context, id uuid.UUID) (*model.Payment, error) {
 	var payment model.Payment
-	err := r.db.First(&payment, "booking_id = ?", bookingID).Error
+	err := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).
+		First(&payment, "id = ?", id).Error
 	if err != nil {
 		return nil, err
 	}
 	return &payment, nil
 }
 
-func (r *paymentRepository) FindAll() ([]model.Payment, error) {
+func (r *paymentRepository) FindByBookingID(ctx context.Context, bookingID uuid.UUID) (*model.Payment, error) {
+	var payment model.Payment
+	err := r.db.WithContext(ctx).First(&payment, "booking_id = ?", bookingID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
+
+func (r *paymentRepository) FindByBookingIDForUpdate(ctx context.Context, bookingID uuid.UUID) (*model.Payment, error) {
+	var payment model.Payment
+	err := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).
+		First(&payment, "booking_id = ?", bookingID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
+
+func (r *paymentRepository) FindAll(ctx context.Context) ([]model.Payment, error) {
 	var payments []model.Payment
-	err := r.db.Find(&payments).Error
+	err := r.db.WithContext(ctx).Find(&payments).Error
 	return payments, err
 }
 
-func (r *paymentRepository) Update(payment *model.Payment) error {
-	return r.db.Save(payment).Error
+func (r *paymentRepository) Update(ctx context.Context, payment *model.Payment) error {
+	return r.db.WithContext(ctx).Save(payment).Error
+}
+
+func (r *paymentRepository) UpdateStatus(ctx context.Context, id uuid.UUID, status string) error {
+	return r.db.WithContext(ctx).Model(&model.Payment{}).Where("id = ?", id).Update("status", status).Error
 }
 
-func (r *paymentRepository) UpdateStatus(id uuid.UUID, status string) error {
-	return r.db.Model(&model.Payment{}).Where("id = ?", id).Update("status", status).Error
+func (r *paymentRepository) WithTx(tx *gorm.DB) PaymentRepository {
+	return &paymentRepository{db: tx}
 }
@@ -0,0 +1,36 @@
+package repository
+
+import (
+	"context"
+	"payment-service/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ProcessedMessageRepository interface {
+	// Create records the message and reports false if it was recorded
+	// before
+	Create(ctx context.Context, message *model.ProcessedMessage) (bool, error)
+	WithTx(tx *gorm.DB) ProcessedMessageRepository
+}
+
+type processedMessageRepository struct {
+	db *gorm.DB
+}
+
+func NewProcessedMessageRepository(db *gorm.DB) ProcessedMessageRepository {
+	return &processedMessageRepository{db: db}
+}
+
+func (r *processedMessageRepository) Create(ctx context.Context, message *model.ProcessedMessage) (bool, error) {
+	result := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(message)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *processedMessageRepository) WithTx(tx *gorm.DB) ProcessedMessageRepository {
+	return &processedMessageRepository{db: tx}
+}
@@ -0,0 +1,34 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"payment-service/internal/model"
+	"payment-service/internal/repository"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrDuplicateEvent is returned for incoming events that were processed
+// before. They should be acknowledged without doing anything.
+var ErrDuplicateEvent = errors.New("event has already been processed")
+
+// markProcessed records the event in the inbox. Called through a
+// transaction's repository, a redelivery blocks on the row until the first
+// delivery commits or rolls back, and then either goes ahead or gets
+// ErrDuplicateEvent.
+func markProcessed(ctx context.Context, repo repository.ProcessedMessageRepository, eventID uuid.UUID, subject string) error {
+	created, err := repo.Create(ctx, &model.ProcessedMessage{
+		EventID:     eventID,
+		Subject:     subject,
+		ProcessedAt: time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	if !created {
+		return ErrDuplicateEvent
+	}
+	return nil
+}
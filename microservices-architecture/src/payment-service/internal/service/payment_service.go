@@ -1,46 +1,69 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"payment-service/internal/client"
+	"payment-service/internal/metrics"
 	"payment-service/internal/model"
 	"payment-service/internal/repository"
 	"time"
 
+	"platform/eventbus"
+	"platform/logging"
+	"platform/tracing"
+
 	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrBookingNotFound           = errors.New("booking not found")
+	ErrBookingServiceUnavailable = errors.New("booking service is unavailable, please try again later")
+	ErrPaymentExists             = errors.New("payment already exists for this booking")
+	ErrPaymentNotFound           = errors.New("payment not found")
 )
 
 type PaymentService interface {
-	CreatePayment(bookingID uuid.UUID, amount float64, paymentMethod string) (*model.PaymentResponse, error)
-	GetPaymentByID(id uuid.UUID) (*model.PaymentResponse, error)
-	GetAllPayments() ([]model.PaymentResponse, error)
-	UpdatePaymentStatus(id uuid.UUID, status string) error
-	HandlePaymentGatewayWebhook(paymentID uuid.UUID, status string) error
-	HandleBookingExpired(bookingID uuid.UUID) error
+	CreatePayment(ctx context.Context, bookingID uuid.UUID, amount float64, paymentMethod string) (*model.PaymentResponse, error)
+	GetPaymentByID(ctx context.Context, id uuid.UUID) (*model.PaymentResponse, error)
+	GetAllPayments(ctx context.Context) ([]model.PaymentResponse, error)
+	UpdatePaymentStatus(ctx context.Context, id uuid.UUID, status string) error
+	HandlePaymentGatewayWebhook(ctx context.Context, paymentID uuid.UUID, status string) error
+	// HandleBookingExpired and HandleBookingCancelled return
+	// ErrDuplicateEvent for events handled before
+	HandleBookingExpired(ctx context.Context, eventID, bookingID uuid.UUID) error
+	HandleBookingCancelled(ctx context.Context, eventID, bookingID uuid.UUID) error
 }
 
 type paymentService struct {
+	db            *gorm.DB
 	paymentRepo   repository.PaymentRepository
+	processedRepo repository.ProcessedMessageRepository
 	bookingClient client.BookingClient
 	userClient    client.UserClient
-	webhookClient client.WebhookClient
+	bus           DeliveryService
 }
 
 func NewPaymentService(
+	db *gorm.DB,
 	paymentRepo repository.PaymentRepository,
+	processedRepo repository.ProcessedMessageRepository,
 	bookingClient client.BookingClient,
 	userClient client.UserClient,
-	webhookClient client.WebhookClient,
+	bus DeliveryService,
 ) PaymentService {
 	return &paymentService{
+		db:            db,
 		paymentRepo:   paymentRepo,
+		processedRepo: processedRepo,
 		bookingClient: bookingClient,
 		userClient:    userClient,
-		webhookClient: webhookClient,
+		bus:           bus,
 	}
 }
 
-func (s *paymentService) CreatePayment(bookingID uuid.UUID, amount float64, paymentMethod string) (*model.PaymentResponse, error) {
+func (s *paymentService) CreatePayment(ctx context.Context, bookingID uuid.UUID, amount float64, paymentMethod string) (*model.PaymentResponse, error) {
 	if amount <= 0 {
 		return nil, errors.New("amount must be greater than 0")
 	}
@@ -50,14 +73,31 @@ func (s *paymentService) CreatePayment(bookingID uuid.UUID, amount float64, paym
 		return nil, errors.New("invalid payment method. Allowed: VA, EWALLET, QRIS")
 	}
 
-	booking, err := s.bookingClient.GetBookingByID(bookingID)
+	booking, err := s.bookingClient.GetBookingByID(ctx, bookingID)
 	if err != nil {
-		return nil, errors.New("booking not found: " + err.Error())
+		if errors.Is(err, client.ErrUnavailable) || errors.Is(err, client.ErrTimeout) {
+			return nil, ErrBookingServiceUnavailable
+		}
+		return nil, ErrBookingNotFound
 	}
 
-	existingPayment, _ := s.paymentRepo.FindByBookingID(bookingID)
+	// Returned along with ErrPaymentExists so that retried requests can
+	// pick up the payment they created
+	existingPayment, _ := s.paymentRepo.FindByBookingID(ctx, bookingID)
 	if existingPayment != nil {
-		return nil, errors.New("payment already exists for this booking")
+		return &model.PaymentResponse{
+			ID:            existingPayment.ID,
+			BookingID:     existingPayment.BookingID,
+			UserID:        existingPayment.UserID,
+			Amount:        existingPayment.Amount,
+			Currency:      existingPayment.Currency,
+			PaymentMethod: existingPayment.PaymentMethod,
+			Status:        existingPayment.Status,
+			ExpiredAt:     existingPayment.ExpiredAt,
+			PaidAt:        existingPayment.PaidAt,
+			CreatedAt:     existingPayment.CreatedAt,
+			UpdatedAt:     existingPayment.UpdatedAt,
+		}, ErrPaymentExists
 	}
 
 	paymentExpiry := *booking.ExpiredAt
@@ -72,9 +112,10 @@ func (s *paymentService) CreatePayment(bookingID uuid.UUID, amount float64, paym
 		ExpiredAt:     &paymentExpiry,
 	}
 
-	if err := s.paymentRepo.Create(payment); err != nil {
+	if err := s.paymentRepo.Create(ctx, payment); err != nil {
 		return nil, err
 	}
+	metrics.PaymentStatus(payment.PaymentMethod, payment.Status)
 
 	return &model.PaymentResponse{
 		ID:            payment.ID,
@@ -91,10 +132,13 @@ func (s *paymentService) CreatePayment(bookingID uuid.UUID, amount float64, paym
 	}, nil
 }
 
-func (s *paymentService) GetPaymentByID(id uuid.UUID) (*model.PaymentResponse, error) {
-	payment, err := s.paymentRepo.FindByID(id)
+func (s *paymentService) GetPaymentByID(ctx context.Context, id uuid.UUID) (*model.PaymentResponse, error) {
+	payment, err := s.paymentRepo.FindByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrPaymentNotFound
+	}
 	if err != nil {
-		return nil, errors.New("payment not found")
+		return nil, err
 	}
 
 	return &model.PaymentResponse{
@@ -112,8 +156,8 @@ func (s *paymentService) GetPaymentByID(id uuid.UUID) (*model.PaymentResponse, e
 	}, nil
 }
 
-func (s *paymentService) GetAllPayments() ([]model.PaymentResponse, error) {
-	payments, err := s.paymentRepo.FindAll()
+func (s *paymentService) GetAllPayments(ctx context.Context) ([]model.PaymentResponse, error) {
+	payments, err := s.paymentRepo.FindAll(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -138,13 +182,16 @@ func (s *paymentService) GetAllPayments() ([]model.PaymentResponse, error) {
 	return response, nil
 }
 
-func (s *paymentService) UpdatePaymentStatus(id uuid.UUID, status string) error {
-	payment, err := s.paymentRepo.FindByID(id)
+func (s *paymentService) UpdatePaymentStatus(ctx context.Context, id uuid.UUID, status string) error {
+	payment, err := s.paymentRepo.FindByID(ctx, id)
 	if err != nil {
 		return errors.New("payment not found")
 	}
 
-	validStatuses := map[string]bool{"PENDING": true, "PAID": true, "FAILED": true, "EXPIRED": true}
+	validStatuses := map[string]bool{
+		"PENDING": true, "PAID": true, "FAILED": true, "EXPIRED": true,
+		"CANCELLED": true, "REFUND_PENDING": true, "REFUNDED": true,
+	}
 	if !validStatuses[status] {
 		return errors.New("invalid status")
 	}
@@ -157,53 +204,126 @@ func (s *paymentService) UpdatePaymentStatus(id uuid.UUID, status string) error
 		payment.PaidAt = &now
 	}
 
-	return s.paymentRepo.Update(payment)
+	if err := s.paymentRepo.Update(ctx, payment); err != nil {
+		return err
+	}
+	metrics.PaymentStatus(payment.PaymentMethod, payment.Status)
+	return nil
 }
 
-func (s *paymentService) HandlePaymentGatewayWebhook(paymentID uuid.UUID, status string) error {
-	payment, err := s.paymentRepo.FindByID(paymentID)
+// HandlePaymentGatewayWebhook settles a pending payment. The payment is
+// updated and its event recorded in one transaction, so booking-service
+// hears of exactly the outcomes that were saved.
+func (s *paymentService) HandlePaymentGatewayWebhook(ctx context.Context, paymentID uuid.UUID, status string) error {
+	var payment *model.Payment
+	var delivery *model.OutboundDelivery
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		paymentRepoTx := s.paymentRepo.WithTx(tx)
+
+		found, err := paymentRepoTx.FindByIDForUpdate(ctx, paymentID)
+		if err != nil {
+			return errors.New("payment not found")
+		}
+		if found.Status != "PENDING" {
+			return errors.New("payment is not pending")
+		}
+
+		found.Status = status
+		found.UpdatedAt = time.Now()
+
+		var subject string
+		switch status {
+		case "PAID":
+			now := time.Now()
+			found.PaidAt = &now
+			subject = eventbus.SubjectPaymentSuccess
+		case "FAILED", "EXPIRED":
+			subject = eventbus.SubjectPaymentFailed
+		}
+
+		if err := paymentRepoTx.Update(ctx, found); err != nil {
+			return err
+		}
+
+		if subject != "" {
+			event := eventbus.PaymentEvent{
+				EventID:   uuid.New(),
+				PaymentID: found.ID,
+				BookingID: found.BookingID,
+				RequestID: logging.RequestID(ctx),
+				Trace:     tracing.Inject(ctx),
+			}
+			recorded, err := s.bus.Record(ctx, tx, subject, event)
+			if err != nil {
+				return errors.New("failed to notify booking service: " + err.Error())
+			}
+			delivery = recorded
+		}
+
+		payment = found
+		return nil
+	})
 	if err != nil {
-		return errors.New("payment not found")
+		return err
 	}
 
-	if payment.Status != "PENDING" {
-		return errors.New("payment is not pending")
+	if delivery != nil {
+		s.bus.Send(ctx, delivery)
 	}
+	metrics.PaymentStatus(payment.PaymentMethod, payment.Status)
+	return nil
+}
 
-	payment.Status = status
-	payment.UpdatedAt = time.Now()
+func (s *paymentService) HandleBookingExpired(ctx context.Context, eventID, bookingID uuid.UUID) error {
+	return s.closePayment(ctx, eventID, eventbus.SubjectBookingExpired, bookingID, "EXPIRED")
+}
 
-	switch status {
-	case "PAID":
-		now := time.Now()
-		payment.PaidAt = &now
-	
-		if err := s.webhookClient.NotifyBookingService("payment.success", payment.ID, payment.BookingID); err != nil {
-				return errors.New("failed to notify booking service: " + err.Error())
+func (s *paymentService) HandleBookingCancelled(ctx context.Context, eventID, bookingID uuid.UUID) error {
+	return s.closePayment(ctx, eventID, eventbus.SubjectBookingCancelled, bookingID, "CANCELLED")
+}
+
+// closePayment settles the payment of a booking that will not be confirmed.
+// A pending payment gets status; one already paid is marked for refund.
+// The event is marked processed in the same transaction.
+func (s *paymentService) closePayment(ctx context.Context, eventID uuid.UUID, subject string, bookingID uuid.UUID, status string) error {
+	var closed *model.Payment
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		paymentRepoTx := s.paymentRepo.WithTx(tx)
+
+		if err := markProcessed(ctx, s.processedRepo.WithTx(tx), eventID, subject); err != nil {
+			return err
 		}
-	case "FAILED", "EXPIRED":
-		if err := s.webhookClient.NotifyBookingService("payment.failed", payment.ID, payment.BookingID); err != nil {
-			return errors.New("failed to notify booking service: " + err.Error())
+
+		payment, err := paymentRepoTx.FindByBookingIDForUpdate(ctx, bookingID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				// No payment found for this booking, which is okay
+				return nil
+			}
+			return err
 		}
-	}
 
-	return s.paymentRepo.Update(payment)
-}
+		switch payment.Status {
+		case "PENDING":
+			payment.Status = status
+		case "PAID":
+			payment.Status = "REFUND_PENDING"
+		default:
+			return nil
+		}
+		payment.UpdatedAt = time.Now()
 
-func (s *paymentService) HandleBookingExpired(bookingID uuid.UUID) error {
-	payment, err := s.paymentRepo.FindByBookingID(bookingID)
-	if err != nil {
-		// No payment found for this booking, which is okay
+		if err := paymentRepoTx.Update(ctx, payment); err != nil {
+			return err
+		}
+		closed = payment
 		return nil
+	})
+	if err != nil {
+		return err
 	}
-
-	// Only expire pending payments
-	if payment.Status != "PENDING" {
-		return nil
+	if closed != nil {
+		metrics.PaymentStatus(closed.PaymentMethod, closed.Status)
 	}
-
-	payment.Status = "EXPIRED"
-	payment.UpdatedAt = time.Now()
-
-	return s.paymentRepo.Update(payment)
+	return nil
 }
@@ -1,18 +1,22 @@
 package migrations
 
 import (
-	"log"
+	"log/slog"
 	"payment-service/internal/model"
 
+	"platform/logging"
+
 	"gorm.io/gorm"
 )
 
 func RunMigrations(db *gorm.DB) {
 	err := db.AutoMigrate(
 		&model.Payment{},
+		&model.OutboundDelivery{},
+		&model.ProcessedMessage{},
 	)
 	if err != nil {
-		log.Fatal("Failed to run migrations:", err)
+		logging.Fatal("Failed to run migrations", "error", err)
 	}
-	log.Println("Migrations completed successfully")
+	slog.Info("Migrations completed successfully")
 }
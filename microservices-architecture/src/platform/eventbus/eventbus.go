@@ -0,0 +1,219 @@
+package eventbus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"platform/logging"
+	"platform/tracing"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+// Subjects shared by booking and payment service
+const (
+	SubjectPaymentSuccess   = "payment.success"
+	SubjectPaymentFailed    = "payment.failed"
+	SubjectBookingExpired   = "booking.expired"
+	SubjectBookingCancelled = "booking.cancelled"
+)
+
+// deadLetterPrefix is prepended to the subject of messages that exhausted
+// their deliveries or were rejected as permanent failures
+const deadLetterPrefix = "deadletter."
+
+var ErrClosed = errors.New("event bus is closed")
+
+// Message is one delivery of a published event
+type Message struct {
+	ID          string
+	Subject     string
+	Data        []byte
+	Delivery    int // 1 on the first delivery
+	PublishedAt time.Time
+}
+
+// Decode unmarshals the event payload into v
+func (m Message) Decode(v interface{}) error {
+	return json.Unmarshal(m.Data, v)
+}
+
+// handlerContext carries the request ID and trace context of the event
+// payload into ctx, so the handler's log lines, spans and calls join the
+// request that published it. Events published outside a request get a new
+// ID.
+func handlerContext(ctx context.Context, data []byte) context.Context {
+	var payload struct {
+		RequestID string            `json:"request_id"`
+		Trace     map[string]string `json:"trace"`
+	}
+	_ = json.Unmarshal(data, &payload)
+	ctx = tracing.Extract(ctx, payload.Trace)
+	return logging.WithRequestID(ctx, logging.ResolveRequestID(payload.RequestID))
+}
+
+// startProcessing starts the consumer span a delivery of subject is handled
+// in
+func startProcessing(ctx context.Context, system, subject string) (context.Context, trace.Span) {
+	return tracing.Tracer().Start(ctx, subject+" process",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			semconv.MessagingSystemKey.String(system),
+			semconv.MessagingDestinationName(subject),
+			semconv.MessagingOperationProcess,
+		),
+	)
+}
+
+// EventID returns the ID consumers de-duplicate the message by: the ID
+// carried in its payload, or the message ID for events published without
+// one, which stays the same across redeliveries
+func (m Message) EventID(payloadID uuid.UUID) (uuid.UUID, error) {
+	if payloadID != uuid.Nil {
+		return payloadID, nil
+	}
+	return uuid.Parse(m.ID)
+}
+
+// Handler processes a message. Returning nil acknowledges it; any other
+// error has it redelivered later, unless it is wrapped with Permanent.
+type Handler func(ctx context.Context, msg Message) error
+
+// Publisher publishes events. The payload is marshalled to JSON.
+type Publisher interface {
+	Publish(ctx context.Context, subject string, event interface{}) error
+}
+
+// EventBus publishes events and delivers them to consumer groups. Every
+// group subscribed to a subject receives each message once, shared between
+// the group's subscribers. Messages that keep failing are moved to the
+// subject's dead-letter subject.
+type EventBus interface {
+	Publisher
+	Subscribe(subject, group string, handler Handler) error
+	Close() error
+}
+
+// PaymentEvent is published on payment.success and payment.failed
+type PaymentEvent struct {
+	EventID   uuid.UUID         `json:"event_id"` // new for every event, kept when it is redelivered or replayed
+	PaymentID uuid.UUID         `json:"payment_id"`
+	BookingID uuid.UUID         `json:"booking_id"`
+	RequestID string            `json:"request_id,omitempty"` // of the request that caused the event
+	Trace     map[string]string `json:"trace,omitempty"`      // W3C trace context (traceparent) of that request
+}
+
+// BookingEvent is published on booking.expired and booking.cancelled
+type BookingEvent struct {
+	EventID   uuid.UUID         `json:"event_id"` // new for every event, kept when it is redelivered or replayed
+	BookingID uuid.UUID         `json:"booking_id"`
+	RequestID string            `json:"request_id,omitempty"` // of the request that caused the event
+	Trace     map[string]string `json:"trace,omitempty"`      // W3C trace context (traceparent) of that request
+}
+
+// DeadLetterSubject returns the subject failed messages of subject go to
+func DeadLetterSubject(subject string) string {
+	return deadLetterPrefix + subject
+}
+
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent marks a handler error as not worth retrying, such as a payload
+// that cannot be decoded. The message goes straight to the dead-letter
+// subject.
+func Permanent(err error) error {
+	return &permanentError{err: err}
+}
+
+func isPermanent(err error) bool {
+	var permanent *permanentError
+	return errors.As(err, &permanent)
+}
+
+// Config selects and tunes the event bus adapter
+type Config struct {
+	Driver       string // nats (default) or postgres
+	NATSURL      string
+	Stream       string
+	MaxDeliver   int           // deliveries before a message is dead-lettered
+	AckWait      time.Duration // time a handler has before redelivery
+	RetryDelay   time.Duration // multiplied by the delivery count
+	PollInterval time.Duration // postgres only
+}
+
+// LoadConfig reads the EVENT_BUS_* settings from the environment
+func LoadConfig() Config {
+	driver := os.Getenv("EVENT_BUS_DRIVER")
+	if driver == "" {
+		driver = "nats"
+	}
+
+	natsURL := os.Getenv("NATS_URL")
+	if natsURL == "" {
+		natsURL = "nats://localhost:4222"
+	}
+
+	stream := os.Getenv("EVENT_BUS_STREAM")
+	if stream == "" {
+		stream = "EVENTS"
+	}
+
+	return Config{
+		Driver:       driver,
+		NATSURL:      natsURL,
+		Stream:       stream,
+		MaxDeliver:   getEnvInt("EVENT_BUS_MAX_DELIVER", 5),
+		AckWait:      getEnvDuration("EVENT_BUS_ACK_WAIT", 30*time.Second),
+		RetryDelay:   getEnvDuration("EVENT_BUS_RETRY_DELAY", 2*time.Second),
+		PollInterval: getEnvDuration("EVENT_BUS_POLL_INTERVAL", time.Second),
+	}
+}
+
+// New opens the adapter named by cfg.Driver. db is only used by the
+// postgres adapter and must be the database every service shares; a
+// service's own database would keep its events to itself.
+func New(ctx context.Context, cfg Config, db *gorm.DB) (EventBus, error) {
+	switch cfg.Driver {
+	case "nats":
+		return NewNATSBus(ctx, cfg)
+	case "postgres":
+		if db == nil {
+			return nil, errors.New("the postgres event bus requires EVENT_BUS_DATABASE_URL")
+		}
+		return NewPostgresBus(db, cfg)
+	}
+	return nil, fmt.Errorf("unknown event bus driver %q", cfg.Driver)
+}
+
+// retryDelay backs off linearly with the number of deliveries so far
+func (c Config) retryDelay(delivery int) time.Duration {
+	return c.RetryDelay * time.Duration(delivery)
+}
+
+func getEnvInt(key string, defaultValue int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
@@ -0,0 +1,217 @@
+package eventbus
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"platform/tracing"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// Headers added to dead-lettered messages
+const (
+	headerDeadLetterError    = "Dead-Letter-Error"
+	headerDeadLetterConsumer = "Dead-Letter-Consumer"
+)
+
+// natsBus stores events in a JetStream stream. Each consumer group is a
+// durable pull consumer filtered on one subject, so replicas of a service
+// share its messages and nothing is lost while they are down.
+type natsBus struct {
+	cfg  Config
+	conn *nats.Conn
+	js   jetstream.JetStream
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu       sync.Mutex
+	closed   bool
+	consumes []jetstream.ConsumeContext
+}
+
+// NewNATSBus connects to cfg.NATSURL and creates or updates the stream
+// holding payment, booking and dead-letter subjects
+func NewNATSBus(ctx context.Context, cfg Config) (EventBus, error) {
+	conn, err := nats.Connect(cfg.NATSURL, nats.MaxReconnects(-1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to NATS: %w", err)
+	}
+
+	js, err := jetstream.New(conn)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	_, err = js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+		Name:       cfg.Stream,
+		Subjects:   []string{"payment.>", "booking.>", deadLetterPrefix + ">"},
+		Storage:    jetstream.FileStorage,
+		Retention:  jetstream.LimitsPolicy,
+		MaxAge:     7 * 24 * time.Hour,
+		Duplicates: 2 * time.Minute,
+	})
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to create stream %s: %w", cfg.Stream, err)
+	}
+
+	busCtx, cancel := context.WithCancel(context.Background())
+	return &natsBus{cfg: cfg, conn: conn, js: js, ctx: busCtx, cancel: cancel}, nil
+}
+
+func (b *natsBus) Publish(ctx context.Context, subject string, event interface{}) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s event: %w", subject, err)
+	}
+
+	// The event ID as message ID lets JetStream drop a publish retried
+	// within the stream's duplicate window. Payloads without one get a new
+	// ID and are not de-duplicated.
+	msgID := payloadEventID(data)
+	if msgID == "" {
+		msgID = uuid.New().String()
+	}
+	if _, err := b.js.Publish(ctx, subject, data, jetstream.WithMsgID(msgID)); err != nil {
+		return fmt.Errorf("failed to publish %s: %w", subject, err)
+	}
+	return nil
+}
+
+func (b *natsBus) Subscribe(subject, group string, handler Handler) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return ErrClosed
+	}
+
+	consumer, err := b.js.CreateOrUpdateConsumer(b.ctx, b.cfg.Stream, jetstream.ConsumerConfig{
+		Durable:       consumerName(subject, group),
+		FilterSubject: subject,
+		AckPolicy:     jetstream.AckExplicitPolicy,
+		AckWait:       b.cfg.AckWait,
+		MaxDeliver:    b.cfg.MaxDeliver,
+		DeliverPolicy: jetstream.DeliverAllPolicy,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create consumer %s for %s: %w", group, subject, err)
+	}
+
+	consume, err := consumer.Consume(func(msg jetstream.Msg) {
+		b.handle(group, msg, handler)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to consume %s: %w", subject, err)
+	}
+
+	b.consumes = append(b.consumes, consume)
+	return nil
+}
+
+func (b *natsBus) handle(group string, msg jetstream.Msg, handler Handler) {
+	meta, err := msg.Metadata()
+	if err != nil {
+		slog.Error("event bus: dropping message without metadata", "subject", msg.Subject(), "error", err)
+		_ = msg.Term()
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(handlerContext(b.ctx, msg.Data()), b.cfg.AckWait)
+	defer cancel()
+	ctx, span := startProcessing(ctx, "nats", msg.Subject())
+
+	delivery := int(meta.NumDelivered)
+	err = handler(ctx, Message{
+		ID:          msg.Headers().Get(jetstream.MsgIDHeader),
+		Subject:     msg.Subject(),
+		Data:        msg.Data(),
+		Delivery:    delivery,
+		PublishedAt: meta.Timestamp,
+	})
+	tracing.End(span, err)
+	if err == nil {
+		if err := msg.Ack(); err != nil {
+			slog.ErrorContext(ctx, "event bus: failed to ack", "subject", msg.Subject(), "error", err)
+		}
+		return
+	}
+
+	if !isPermanent(err) && delivery < b.cfg.MaxDeliver {
+		slog.WarnContext(ctx, "event bus: failed to handle message", "group", group, "subject", msg.Subject(), "delivery", delivery, "error", err)
+		if err := msg.NakWithDelay(b.cfg.retryDelay(delivery)); err != nil {
+			slog.ErrorContext(ctx, "event bus: failed to nak", "subject", msg.Subject(), "error", err)
+		}
+		return
+	}
+
+	slog.ErrorContext(ctx, "event bus: dead-lettering message", "group", group, "subject", msg.Subject(), "deliveries", delivery, "error", err)
+	if err := b.deadLetter(ctx, group, msg, err); err != nil {
+		// Leave the message to be redelivered rather than lose it
+		slog.ErrorContext(ctx, "event bus: failed to dead-letter", "subject", msg.Subject(), "error", err)
+		_ = msg.Nak()
+		return
+	}
+	if err := msg.Term(); err != nil {
+		slog.ErrorContext(ctx, "event bus: failed to terminate", "subject", msg.Subject(), "error", err)
+	}
+}
+
+func (b *natsBus) deadLetter(ctx context.Context, group string, msg jetstream.Msg, cause error) error {
+	header := nats.Header{}
+	for k, v := range msg.Headers() {
+		header[k] = v
+	}
+	header.Set(headerDeadLetterError, cause.Error())
+	header.Set(headerDeadLetterConsumer, group)
+
+	dead := &nats.Msg{
+		Subject: DeadLetterSubject(msg.Subject()),
+		Header:  header,
+		Data:    msg.Data(),
+	}
+	// Reuse the original ID per group so a retried dead-letter is not stored twice
+	_, err := b.js.PublishMsg(ctx, dead, jetstream.WithMsgID(group+":"+header.Get(jetstream.MsgIDHeader)))
+	return err
+}
+
+func (b *natsBus) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return nil
+	}
+	b.closed = true
+
+	for _, consume := range b.consumes {
+		consume.Stop()
+	}
+	b.cancel()
+	return b.conn.Drain()
+}
+
+// consumerName derives a durable name from subject and group. Durable names
+// may not contain dots.
+func consumerName(subject, group string) string {
+	return strings.NewReplacer(".", "_", ">", "all", "*", "any").Replace(group + "_" + subject)
+}
+
+// payloadEventID returns the event_id of an event payload, or an empty
+// string if it has none
+func payloadEventID(data []byte) string {
+	var payload struct {
+		EventID uuid.UUID `json:"event_id"`
+	}
+	if err := json.Unmarshal(data, &payload); err != nil || payload.EventID == uuid.Nil {
+		return ""
+	}
+	return payload.EventID.String()
+}
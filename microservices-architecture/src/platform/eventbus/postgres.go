@@ -0,0 +1,268 @@
+package eventbus
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"platform/tracing"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Delivery states of the postgres adapter
+const (
+	deliveryPending = "pending"
+	deliveryAcked   = "acked"
+	deliveryDead    = "dead"
+)
+
+// postgresBatchSize bounds the deliveries one subscriber claims per poll
+const postgresBatchSize = 20
+
+// BusMessage is a published event
+type BusMessage struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey"`
+	Subject   string    `gorm:"type:varchar(255);not null;index"`
+	Data      []byte    `gorm:"type:jsonb;not null"`
+	CreatedAt time.Time `gorm:"not null"`
+}
+
+func (BusMessage) TableName() string { return "event_bus_messages" }
+
+// BusSubscription registers a consumer group for a subject. Messages
+// published once it exists are delivered to the group.
+type BusSubscription struct {
+	ConsumerGroup string    `gorm:"type:varchar(100);primaryKey"`
+	Subject       string    `gorm:"type:varchar(255);primaryKey"`
+	CreatedAt     time.Time `gorm:"not null"`
+}
+
+func (BusSubscription) TableName() string { return "event_bus_subscriptions" }
+
+// BusDelivery tracks one message for one consumer group
+type BusDelivery struct {
+	ID            uint      `gorm:"primaryKey"`
+	MessageID     uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_event_bus_delivery"`
+	ConsumerGroup string    `gorm:"type:varchar(100);not null;uniqueIndex:idx_event_bus_delivery;index:idx_event_bus_due,priority:1"`
+	Subject       string    `gorm:"type:varchar(255);not null;index:idx_event_bus_due,priority:2"`
+	Status        string    `gorm:"type:varchar(20);not null;index:idx_event_bus_due,priority:3"`
+	Attempts      int       `gorm:"not null;default:0"`
+	NextAttemptAt time.Time `gorm:"not null;index:idx_event_bus_due,priority:4"`
+	LastError     string    `gorm:"type:text"`
+	UpdatedAt     time.Time
+}
+
+func (BusDelivery) TableName() string { return "event_bus_deliveries" }
+
+// postgresBus keeps messages and per-group delivery state in Postgres and
+// polls for due deliveries. It needs no extra infrastructure, which suits
+// single-node deployments and tests, but every service must share the
+// database.
+type postgresBus struct {
+	db  *gorm.DB
+	cfg Config
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu     sync.Mutex
+	closed bool
+}
+
+func NewPostgresBus(db *gorm.DB, cfg Config) (EventBus, error) {
+	if err := db.AutoMigrate(&BusMessage{}, &BusSubscription{}, &BusDelivery{}); err != nil {
+		return nil, fmt.Errorf("failed to migrate event bus tables: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &postgresBus{db: db, cfg: cfg, ctx: ctx, cancel: cancel}, nil
+}
+
+func (b *postgresBus) Publish(ctx context.Context, subject string, event interface{}) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s event: %w", subject, err)
+	}
+
+	return b.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return publish(tx, subject, data, "")
+	})
+}
+
+// publish stores the message and a pending delivery for every group
+// subscribed to subject. A non-empty group restricts delivery to it.
+func publish(tx *gorm.DB, subject string, data []byte, group string) error {
+	message := &BusMessage{ID: uuid.New(), Subject: subject, Data: data, CreatedAt: time.Now()}
+	if err := tx.Create(message).Error; err != nil {
+		return fmt.Errorf("failed to publish %s: %w", subject, err)
+	}
+
+	query := `INSERT INTO event_bus_deliveries (message_id, consumer_group, subject, status, attempts, next_attempt_at, updated_at)
+		SELECT CAST(? AS uuid), consumer_group, subject, CAST(? AS varchar), 0, CAST(? AS timestamptz), CAST(? AS timestamptz)
+		FROM event_bus_subscriptions WHERE subject = ?`
+	args := []interface{}{message.ID, deliveryPending, message.CreatedAt, message.CreatedAt, subject}
+	if group != "" {
+		query += " AND consumer_group = ?"
+		args = append(args, group)
+	}
+	if err := tx.Exec(query, args...).Error; err != nil {
+		return fmt.Errorf("failed to publish %s: %w", subject, err)
+	}
+	return nil
+}
+
+func (b *postgresBus) Subscribe(subject, group string, handler Handler) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return ErrClosed
+	}
+
+	subscription := &BusSubscription{ConsumerGroup: group, Subject: subject, CreatedAt: time.Now()}
+	err := b.db.WithContext(b.ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(subscription).Error
+	if err != nil {
+		return fmt.Errorf("failed to subscribe %s to %s: %w", group, subject, err)
+	}
+
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+		ticker := time.NewTicker(b.cfg.PollInterval)
+		defer ticker.Stop()
+		for {
+			// Keep draining while full batches come back
+			for claimed := postgresBatchSize; claimed == postgresBatchSize; {
+				claimed = b.poll(subject, group, handler)
+			}
+			select {
+			case <-b.ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return nil
+}
+
+type claimedDelivery struct {
+	ID        uint
+	MessageID uuid.UUID
+	Attempts  int
+}
+
+// poll claims due deliveries by pushing their next attempt out by AckWait,
+// so a subscriber that dies mid-batch has them picked up again later, then
+// handles them in publish order. It returns the number claimed.
+func (b *postgresBus) poll(subject, group string, handler Handler) int {
+	if b.ctx.Err() != nil {
+		return 0
+	}
+
+	now := time.Now()
+	var claimed []claimedDelivery
+	err := b.db.WithContext(b.ctx).Raw(`UPDATE event_bus_deliveries
+		SET attempts = attempts + 1, next_attempt_at = ?, updated_at = ?
+		WHERE id IN (
+			SELECT id FROM event_bus_deliveries
+			WHERE consumer_group = ? AND subject = ? AND status = ? AND next_attempt_at <= ?
+			ORDER BY id LIMIT ? FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, message_id, attempts`,
+		now.Add(b.cfg.AckWait), now, group, subject, deliveryPending, now, postgresBatchSize,
+	).Scan(&claimed).Error
+	if err != nil {
+		slog.Error("event bus: failed to poll", "group", group, "subject", subject, "error", err)
+		return 0
+	}
+	if len(claimed) == 0 {
+		return 0
+	}
+
+	ids := make([]uuid.UUID, 0, len(claimed))
+	for _, d := range claimed {
+		ids = append(ids, d.MessageID)
+	}
+	var messages []BusMessage
+	if err := b.db.WithContext(b.ctx).Where("id IN ?", ids).Find(&messages).Error; err != nil {
+		slog.Error("event bus: failed to load messages", "group", group, "error", err)
+		return 0
+	}
+	byID := make(map[uuid.UUID]BusMessage, len(messages))
+	for _, m := range messages {
+		byID[m.ID] = m
+	}
+
+	// RETURNING does not keep the subquery's order
+	sort.Slice(claimed, func(i, j int) bool { return claimed[i].ID < claimed[j].ID })
+	for _, d := range claimed {
+		b.deliver(group, d, byID[d.MessageID], handler)
+	}
+	return len(claimed)
+}
+
+func (b *postgresBus) deliver(group string, d claimedDelivery, message BusMessage, handler Handler) {
+	ctx, cancel := context.WithTimeout(handlerContext(b.ctx, message.Data), b.cfg.AckWait)
+	defer cancel()
+	ctx, span := startProcessing(ctx, "postgresql", message.Subject)
+
+	err := handler(ctx, Message{
+		ID:          message.ID.String(),
+		Subject:     message.Subject,
+		Data:        message.Data,
+		Delivery:    d.Attempts,
+		PublishedAt: message.CreatedAt,
+	})
+	tracing.End(span, err)
+	if b.ctx.Err() != nil {
+		// Shutting down, the claim expires and another subscriber retries
+		return
+	}
+
+	db := b.db.WithContext(b.ctx).Model(&BusDelivery{}).Where("id = ?", d.ID)
+	switch {
+	case err == nil:
+		err = db.Updates(map[string]interface{}{"status": deliveryAcked, "last_error": ""}).Error
+	case !isPermanent(err) && d.Attempts < b.cfg.MaxDeliver:
+		slog.WarnContext(ctx, "event bus: failed to handle message", "group", group, "subject", message.Subject, "delivery", d.Attempts, "error", err)
+		err = db.Updates(map[string]interface{}{
+			"next_attempt_at": time.Now().Add(b.cfg.retryDelay(d.Attempts)),
+			"last_error":      err.Error(),
+		}).Error
+	default:
+		slog.ErrorContext(ctx, "event bus: dead-lettering message", "group", group, "subject", message.Subject, "deliveries", d.Attempts, "error", err)
+		cause := err.Error()
+		err = b.db.WithContext(b.ctx).Transaction(func(tx *gorm.DB) error {
+			if err := tx.Model(&BusDelivery{}).Where("id = ?", d.ID).Updates(map[string]interface{}{
+				"status":     deliveryDead,
+				"last_error": cause,
+			}).Error; err != nil {
+				return err
+			}
+			return publish(tx, DeadLetterSubject(message.Subject), message.Data, group)
+		})
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "event bus: failed to record delivery", "group", group, "subject", message.Subject, "error", err)
+	}
+}
+
+func (b *postgresBus) Close() error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return nil
+	}
+	b.closed = true
+	b.mu.Unlock()
+
+	b.cancel()
+	b.wg.Wait()
+	return nil
+}
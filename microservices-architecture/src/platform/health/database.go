@@ -0,0 +1,30 @@
+package health
+
+import (
+	"context"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// Database checks that db answers a ping and that its connection pool is
+// not saturated, that is, fewer than saturation of its open connection
+// limit are in use. Pools without a limit are never saturated.
+func Database(db *gorm.DB, saturation float64) CheckFunc {
+	return func(ctx context.Context) error {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return err
+		}
+		if err := sqlDB.PingContext(ctx); err != nil {
+			return err
+		}
+
+		stats := sqlDB.Stats()
+		if stats.MaxOpenConnections > 0 && float64(stats.InUse) >= saturation*float64(stats.MaxOpenConnections) {
+			return fmt.Errorf("connection pool saturated: %d of %d connections in use, %d waits so far",
+				stats.InUse, stats.MaxOpenConnections, stats.WaitCount)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,229 @@
+// Package health answers liveness and readiness probes. /livez only says
+// the process is up. /readyz checks the service's dependencies and reports
+// the status and latency of each, and turns not ready as soon as the
+// service starts shutting down, so load balancers drain it first.
+package health
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Statuses reported by the probes
+const (
+	StatusOK           = "ok"
+	StatusFailed       = "failed"
+	StatusReady        = "ready"
+	StatusNotReady     = "not_ready"
+	StatusShuttingDown = "shutting_down"
+)
+
+// Config tunes readiness checks and shutdown
+type Config struct {
+	Timeout         time.Duration // per check
+	CacheTTL        time.Duration // how long a downstream result is reused
+	Downstream      bool          // also check that the services called are reachable
+	PoolSaturation  float64       // share of the open connection limit in use that counts as saturated
+	DrainDelay      time.Duration // not ready, but still serving, before shutting down
+	ShutdownTimeout time.Duration // for requests in flight once shutting down
+}
+
+// LoadConfig reads the HEALTH_* and SHUTDOWN_* settings from the environment
+func LoadConfig() Config {
+	return Config{
+		Timeout:         getEnvDuration("HEALTH_CHECK_TIMEOUT", 2*time.Second),
+		CacheTTL:        getEnvDuration("HEALTH_CACHE_TTL", 10*time.Second),
+		Downstream:      getEnvBool("HEALTH_CHECK_DOWNSTREAM", false),
+		PoolSaturation:  getEnvFloat("HEALTH_DB_POOL_SATURATION", 0.9),
+		DrainDelay:      getEnvDuration("SHUTDOWN_DRAIN_DELAY", 5*time.Second),
+		ShutdownTimeout: getEnvDuration("SHUTDOWN_TIMEOUT", 20*time.Second),
+	}
+}
+
+// CheckFunc reports whether a dependency is usable. It should return once
+// ctx is done.
+type CheckFunc func(ctx context.Context) error
+
+// Result is the outcome of one check
+type Result struct {
+	Status    string  `json:"status"`
+	LatencyMS float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+	Cached    bool    `json:"cached,omitempty"` // reused from an earlier probe
+}
+
+type check struct {
+	name string
+	fn   CheckFunc
+	ttl  time.Duration // 0 runs the check on every probe
+
+	mu        sync.Mutex // one probe runs the check, concurrent ones wait for its result
+	last      Result
+	checkedAt time.Time
+}
+
+// Checker runs the readiness checks of a service
+type Checker struct {
+	service      string
+	cfg          Config
+	checks       []*check
+	shuttingDown atomic.Bool
+}
+
+func NewChecker(service string, cfg Config) *Checker {
+	return &Checker{service: service, cfg: cfg}
+}
+
+// Add registers a dependency the service cannot work without, checked on
+// every probe. Register checks before serving probes.
+func (c *Checker) Add(name string, fn CheckFunc) {
+	c.checks = append(c.checks, &check{name: name, fn: fn})
+}
+
+// AddDownstream registers another service the service calls. It is only
+// checked with HEALTH_CHECK_DOWNSTREAM=true, and its result is reused for
+// HEALTH_CACHE_TTL so that probes do not flood it.
+func (c *Checker) AddDownstream(name string, fn CheckFunc) {
+	if !c.cfg.Downstream {
+		return
+	}
+	c.checks = append(c.checks, &check{name: name, fn: fn, ttl: c.cfg.CacheTTL})
+}
+
+// Livez answers 200 for as long as the process can serve requests
+func (c *Checker) Livez(ctx *fiber.Ctx) error {
+	return ctx.JSON(fiber.Map{
+		"status":  StatusOK,
+		"service": c.service,
+	})
+}
+
+// Readyz runs every check in parallel and answers 200 when all of them
+// pass, 503 otherwise or once the service is shutting down
+func (c *Checker) Readyz(ctx *fiber.Ctx) error {
+	if c.shuttingDown.Load() {
+		return ctx.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"status":  StatusShuttingDown,
+			"service": c.service,
+		})
+	}
+
+	results := make(map[string]Result, len(c.checks))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, chk := range c.checks {
+		wg.Add(1)
+		go func(chk *check) {
+			defer wg.Done()
+			result := c.run(ctx.UserContext(), chk)
+			mu.Lock()
+			results[chk.name] = result
+			mu.Unlock()
+		}(chk)
+	}
+	wg.Wait()
+
+	status := StatusReady
+	code := fiber.StatusOK
+	for _, result := range results {
+		if result.Status != StatusOK {
+			status = StatusNotReady
+			code = fiber.StatusServiceUnavailable
+		}
+	}
+
+	return ctx.Status(code).JSON(fiber.Map{
+		"status":  status,
+		"service": c.service,
+		"checks":  results,
+	})
+}
+
+func (c *Checker) run(ctx context.Context, chk *check) Result {
+	chk.mu.Lock()
+	defer chk.mu.Unlock()
+
+	if chk.ttl > 0 && !chk.checkedAt.IsZero() && time.Since(chk.checkedAt) < chk.ttl {
+		result := chk.last
+		result.Cached = true
+		return result
+	}
+
+	// A cached result must not depend on the probe that happened to run it
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), c.cfg.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := chk.fn(ctx)
+	result := Result{
+		Status:    StatusOK,
+		LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		result.Status = StatusFailed
+		result.Error = err.Error()
+		slog.WarnContext(ctx, "readiness check failed", "check", chk.name, "error", err)
+	}
+
+	chk.last, chk.checkedAt = result, time.Now()
+	return result
+}
+
+// OnShutdown waits for SIGINT or SIGTERM in the background. It then reports
+// not ready, keeps serving for SHUTDOWN_DRAIN_DELAY while load balancers
+// take the service out of rotation, and calls stop with SHUTDOWN_TIMEOUT
+// to finish requests in flight. The returned channel is closed once stop
+// has returned.
+func (c *Checker) OnShutdown(stop func(ctx context.Context)) <-chan struct{} {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		sig := <-signals
+		signal.Stop(signals)
+
+		c.shuttingDown.Store(true)
+		slog.Info("Shutting down", "signal", sig.String(), "drain_delay", c.cfg.DrainDelay.String())
+		time.Sleep(c.cfg.DrainDelay)
+
+		ctx, cancel := context.WithTimeout(context.Background(), c.cfg.ShutdownTimeout)
+		defer cancel()
+		stop(ctx)
+	}()
+	return stopped
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+func getEnvBool(key string, defaultValue bool) bool {
+	value, err := strconv.ParseBool(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+func getEnvFloat(key string, defaultValue float64) float64 {
+	value, err := strconv.ParseFloat(os.Getenv(key), 64)
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
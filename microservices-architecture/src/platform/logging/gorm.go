@@ -0,0 +1,72 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// slowQueryThreshold is the duration above which queries are logged
+const slowQueryThreshold = 200 * time.Millisecond
+
+// gormLogger sends GORM's logs through slog. Failed and slow queries are
+// logged without their SQL, which has the bound values (password hashes,
+// token hashes) inlined.
+type gormLogger struct {
+	level logger.LogLevel
+}
+
+// GormLogger returns a GORM logger for gorm.Config
+func GormLogger() logger.Interface {
+	return gormLogger{level: logger.Warn}
+}
+
+func (l gormLogger) LogMode(level logger.LogLevel) logger.Interface {
+	return gormLogger{level: level}
+}
+
+func (l gormLogger) Info(ctx context.Context, msg string, args ...interface{}) {
+	if l.level >= logger.Info {
+		slog.InfoContext(ctx, fmt.Sprintf(msg, args...))
+	}
+}
+
+func (l gormLogger) Warn(ctx context.Context, msg string, args ...interface{}) {
+	if l.level >= logger.Warn {
+		slog.WarnContext(ctx, fmt.Sprintf(msg, args...))
+	}
+}
+
+func (l gormLogger) Error(ctx context.Context, msg string, args ...interface{}) {
+	if l.level >= logger.Error {
+		slog.ErrorContext(ctx, fmt.Sprintf(msg, args...))
+	}
+}
+
+func (l gormLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	if l.level <= logger.Silent {
+		return
+	}
+
+	elapsed := time.Since(begin)
+	switch {
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound) && l.level >= logger.Error:
+		_, rows := fc()
+		slog.ErrorContext(ctx, "query failed",
+			"error", err,
+			"rows", rows,
+			"duration_ms", float64(elapsed.Microseconds())/1000,
+		)
+	case elapsed > slowQueryThreshold && l.level >= logger.Warn:
+		_, rows := fc()
+		slog.WarnContext(ctx, "slow query",
+			"rows", rows,
+			"duration_ms", float64(elapsed.Microseconds())/1000,
+		)
+	}
+}
@@ -0,0 +1,130 @@
+// Package logging writes structured JSON log lines through log/slog. Lines
+// logged with a context carry the request ID and trace stored in it, and
+// values of sensitive keys such as passwords, tokens and Authorization
+// headers are redacted.
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// HeaderRequestID carries the ID that ties together the log lines of one
+// request across services. MetadataRequestID is its gRPC metadata key.
+const (
+	HeaderRequestID   = "X-Request-ID"
+	MetadataRequestID = "x-request-id"
+)
+
+// maxRequestIDLength bounds IDs passed in by callers
+const maxRequestIDLength = 128
+
+const redacted = "[REDACTED]"
+
+// sensitiveKeys are matched against lower-cased attribute keys, with dashes
+// read as underscores
+var sensitiveKeys = []string{"password", "secret", "token", "authorization", "cookie", "api_key", "apikey", "otp", "recovery_code"}
+
+// Setup installs a JSON logger on stdout as the default for slog and the
+// log package. LOG_LEVEL picks the minimum level: debug, info (the
+// default), warn or error.
+func Setup(service string) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(os.Getenv("LOG_LEVEL"))); err != nil {
+		level = slog.LevelInfo
+	}
+
+	handler := slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
+		Level:       level,
+		ReplaceAttr: redact,
+	})
+	slog.SetDefault(slog.New(contextHandler{handler}).With("service", service))
+}
+
+// Fatal logs msg at error level and exits
+func Fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
+
+type requestIDKey struct{}
+
+// WithRequestID returns a copy of ctx carrying the request ID
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the request ID carried by ctx, or ""
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// ResolveRequestID returns id if it is a usable request ID, or a new one.
+// Only short IDs of printable ASCII are passed on, so caller supplied values
+// cannot break log lines or headers downstream.
+func ResolveRequestID(id string) string {
+	if id == "" || len(id) > maxRequestIDLength {
+		return uuid.NewString()
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return uuid.NewString()
+		}
+	}
+	return id
+}
+
+// contextHandler adds the request ID and the trace and span IDs of the
+// context to every record
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		r.AddAttrs(slog.String("trace_id", sc.TraceID().String()), slog.String("span_id", sc.SpanID().String()))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
+
+// redact hides the values of sensitive keys, and credentials logged under
+// any key
+func redact(_ []string, a slog.Attr) slog.Attr {
+	if isSensitive(a.Key) {
+		return slog.String(a.Key, redacted)
+	}
+	if a.Value.Kind() == slog.KindString {
+		value := a.Value.String()
+		if strings.HasPrefix(value, "Bearer ") || strings.HasPrefix(value, "Basic ") {
+			return slog.String(a.Key, redacted)
+		}
+	}
+	return a
+}
+
+func isSensitive(key string) bool {
+	key = strings.ReplaceAll(strings.ToLower(key), "-", "_")
+	for _, sensitive := range sensitiveKeys {
+		if strings.Contains(key, sensitive) {
+			return true
+		}
+	}
+	return false
+}
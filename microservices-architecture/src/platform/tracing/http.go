@@ -0,0 +1,111 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// HTTP starts a server span for every request, continuing the trace of an
+// incoming traceparent header. The span is named after the route pattern,
+// such as GET /api/v1/bookings/:id.
+func HTTP() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx := otel.GetTextMapPropagator().Extract(c.UserContext(), requestCarrier{c})
+		ctx, span := Tracer().Start(ctx, c.Method(),
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(c.Method()),
+				semconv.URLPath(c.Path()),
+			),
+		)
+		defer span.End()
+
+		c.SetUserContext(ctx)
+		err := c.Next()
+
+		status := c.Response().StatusCode()
+		if err != nil {
+			// Not written yet; the error handler answers later
+			status = fiber.StatusInternalServerError
+			var fiberErr *fiber.Error
+			if errors.As(err, &fiberErr) {
+				status = fiberErr.Code
+			}
+			span.RecordError(err)
+		}
+
+		route := c.Route().Path
+		span.SetName(c.Method() + " " + route)
+		span.SetAttributes(semconv.HTTPRoute(route), semconv.HTTPResponseStatusCode(status))
+		if status >= fiber.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+		return err
+	}
+}
+
+// StartClient starts a client span for an outgoing request and returns the
+// request with the span in its context. Finish the span with EndClient.
+func StartClient(req *http.Request) (*http.Request, trace.Span) {
+	ctx, span := Tracer().Start(req.Context(), req.Method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.HTTPRequestMethodKey.String(req.Method),
+			semconv.ServerAddress(req.URL.Host),
+			semconv.URLPath(req.URL.Path),
+		),
+	)
+	return req.WithContext(ctx), span
+}
+
+// EndClient ends a span started by StartClient. 5xx answers and transport
+// errors mark it failed.
+func EndClient(span trace.Span, resp *http.Response, err error) {
+	if resp != nil {
+		span.SetAttributes(semconv.HTTPResponseStatusCode(resp.StatusCode))
+		if err == nil && resp.StatusCode >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(resp.StatusCode))
+		}
+	}
+	End(span, err)
+}
+
+// InjectHeader adds the traceparent of ctx to header
+func InjectHeader(ctx context.Context, header http.Header) {
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(header))
+}
+
+// InjectRequest sets the traceparent of ctx on the request of c, so that
+// the upstream it is forwarded to continues the trace
+func InjectRequest(ctx context.Context, c *fiber.Ctx) {
+	otel.GetTextMapPropagator().Inject(ctx, requestCarrier{c})
+}
+
+// StartFromPayload starts a span named name in the trace of a traceparent
+// carried in a request body, such as a webhook echoing the trace it was
+// created in, and links it to the span of ctx. Without a valid traceparent
+// the span is a child of ctx.
+func StartFromPayload(ctx context.Context, name, traceparent string) (context.Context, trace.Span) {
+	parent := otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier{"traceparent": traceparent})
+	if !trace.SpanContextFromContext(parent).IsValid() {
+		return Tracer().Start(ctx, name)
+	}
+	return Tracer().Start(parent, name, trace.WithLinks(trace.LinkFromContext(ctx)))
+}
+
+// requestCarrier reads and writes trace headers of a Fiber request
+type requestCarrier struct {
+	c *fiber.Ctx
+}
+
+func (r requestCarrier) Get(key string) string { return r.c.Get(key) }
+func (r requestCarrier) Set(key, value string) { r.c.Request().Header.Set(key, value) }
+func (r requestCarrier) Keys() []string        { return nil }
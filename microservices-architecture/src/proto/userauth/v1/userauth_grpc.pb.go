@@ -0,0 +1,154 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: userauth/v1/userauth.proto
+
+package userauthv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	UserAuthService_AuthenticateUser_FullMethodName = "/userauth.v1.UserAuthService/AuthenticateUser"
+	UserAuthService_IntrospectAPIKey_FullMethodName = "/userauth.v1.UserAuthService/IntrospectAPIKey"
+)
+
+// UserAuthServiceClient is the client API for UserAuthService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type UserAuthServiceClient interface {
+	// AuthenticateUser returns the owner of a user access token
+	AuthenticateUser(ctx context.Context, in *AuthenticateUserRequest, opts ...grpc.CallOption) (*User, error)
+	// IntrospectAPIKey returns the owner and scopes of a partner API key. Each
+	// call is counted as one request made with the key. Callers need a
+	// service token with the api-keys:introspect scope.
+	IntrospectAPIKey(ctx context.Context, in *IntrospectAPIKeyRequest, opts ...grpc.CallOption) (*APIKey, error)
+}
+
+type userAuthServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewUserAuthServiceClient(cc grpc.ClientConnInterface) UserAuthServiceClient {
+	return &userAuthServiceClient{cc}
+}
+
+func (c *userAuthServiceClient) AuthenticateUser(ctx context.Context, in *AuthenticateUserRequest, opts ...grpc.CallOption) (*User, error) {
+	out := new(User)
+	err := c.cc.Invoke(ctx, UserAuthService_AuthenticateUser_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userAuthServiceClient) IntrospectAPIKey(ctx context.Context, in *IntrospectAPIKeyRequest, opts ...grpc.CallOption) (*APIKey, error) {
+	out := new(APIKey)
+	err := c.cc.Invoke(ctx, UserAuthService_IntrospectAPIKey_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// UserAuthServiceServer is the server API for UserAuthService service.
+// All implementations must embed UnimplementedUserAuthServiceServer
+// for forward compatibility
+type UserAuthServiceServer interface {
+	// AuthenticateUser returns the owner of a user access token
+	AuthenticateUser(context.Context, *AuthenticateUserRequest) (*User, error)
+	// IntrospectAPIKey returns the owner and scopes of a partner API key. Each
+	// call is counted as one request made with the key. Callers need a
+	// service token with the api-keys:introspect scope.
+	IntrospectAPIKey(context.Context, *IntrospectAPIKeyRequest) (*APIKey, error)
+	mustEmbedUnimplementedUserAuthServiceServer()
+}
+
+// UnimplementedUserAuthServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedUserAuthServiceServer struct {
+}
+
+func (UnimplementedUserAuthServiceServer) AuthenticateUser(context.Context, *AuthenticateUserRequest) (*User, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AuthenticateUser not implemented")
+}
+func (UnimplementedUserAuthServiceServer) IntrospectAPIKey(context.Context, *IntrospectAPIKeyRequest) (*APIKey, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method IntrospectAPIKey not implemented")
+}
+func (UnimplementedUserAuthServiceServer) mustEmbedUnimplementedUserAuthServiceServer() {}
+
+// UnsafeUserAuthServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to UserAuthServiceServer will
+// result in compilation errors.
+type UnsafeUserAuthServiceServer interface {
+	mustEmbedUnimplementedUserAuthServiceServer()
+}
+
+func RegisterUserAuthServiceServer(s grpc.ServiceRegistrar, srv UserAuthServiceServer) {
+	s.RegisterService(&UserAuthService_ServiceDesc, srv)
+}
+
+func _UserAuthService_AuthenticateUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AuthenticateUserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserAuthServiceServer).AuthenticateUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserAuthService_AuthenticateUser_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserAuthServiceServer).AuthenticateUser(ctx, req.(*AuthenticateUserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserAuthService_IntrospectAPIKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IntrospectAPIKeyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserAuthServiceServer).IntrospectAPIKey(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserAuthService_IntrospectAPIKey_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserAuthServiceServer).IntrospectAPIKey(ctx, req.(*IntrospectAPIKeyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// UserAuthService_ServiceDesc is the grpc.ServiceDesc for UserAuthService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var UserAuthService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "userauth.v1.UserAuthService",
+	HandlerType: (*UserAuthServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "AuthenticateUser",
+			Handler:    _UserAuthService_AuthenticateUser_Handler,
+		},
+		{
+			MethodName: "IntrospectAPIKey",
+			Handler:    _UserAuthService_IntrospectAPIKey_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "userauth/v1/userauth.proto",
+}
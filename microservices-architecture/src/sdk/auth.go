@@ -0,0 +1,105 @@
+package sdk
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// refreshMargin is how long before expiry the access token is renewed, so
+// that it never expires in flight
+const refreshMargin = 30 * time.Second
+
+// Tokens are the credentials of a logged in user
+type Tokens struct {
+	AccessToken  string
+	RefreshToken string    // used to renew AccessToken; empty disables renewal
+	ExpiresAt    time.Time // of AccessToken; zero if unknown
+}
+
+// SetTokens makes the client act as the user the tokens were issued to,
+// for example after restoring them from storage. Zero Tokens log out
+// locally.
+func (c *Client) SetTokens(tokens Tokens) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.tokens = tokens
+}
+
+// Tokens returns the current tokens, which change whenever the access
+// token is renewed
+func (c *Client) Tokens() Tokens {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.tokens
+}
+
+// accessToken returns the access token to send, renewing it first if it is
+// about to expire. It returns an empty string when not logged in.
+func (c *Client) accessToken(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expiring := !c.tokens.ExpiresAt.IsZero() && time.Until(c.tokens.ExpiresAt) < refreshMargin
+	if c.tokens.AccessToken != "" && expiring && c.tokens.RefreshToken != "" {
+		if err := c.refreshLocked(ctx); err != nil {
+			return "", err
+		}
+	}
+	return c.tokens.AccessToken, nil
+}
+
+// renewAccessToken replaces a rejected access token. Requests rejected
+// together renew it only once. It returns an empty string when the token
+// cannot be renewed.
+func (c *Client) renewAccessToken(ctx context.Context, rejected string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.tokens.AccessToken != rejected {
+		// Renewed by another request in the meantime
+		return c.tokens.AccessToken, nil
+	}
+	if c.tokens.RefreshToken == "" {
+		return "", nil
+	}
+	if err := c.refreshLocked(ctx); err != nil {
+		return "", err
+	}
+	return c.tokens.AccessToken, nil
+}
+
+// refreshLocked exchanges the refresh token for a new access token. c.mu
+// must be held.
+func (c *Client) refreshLocked(ctx context.Context) error {
+	var resp struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	err := c.do(ctx, request{
+		method:  http.MethodPost,
+		baseURL: c.userURL,
+		path:    "/api/v1/refresh",
+		body:    map[string]string{"refresh_token": c.tokens.RefreshToken},
+	}, &resp)
+	if err != nil {
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusUnauthorized {
+			// The session was revoked or has expired; log in again
+			c.tokens = Tokens{}
+		}
+		return err
+	}
+
+	c.tokens.AccessToken = resp.AccessToken
+	c.tokens.ExpiresAt = expiresAt(resp.ExpiresIn)
+	return nil
+}
+
+func expiresAt(expiresIn int) time.Time {
+	if expiresIn <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(time.Duration(expiresIn) * time.Second)
+}
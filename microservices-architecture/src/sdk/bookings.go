@@ -0,0 +1,285 @@
+package sdk
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// BookingService calls the event, ticket and booking endpoints of booking
+// service. Events and tickets can be read anonymously.
+type BookingService struct {
+	c *Client
+}
+
+type Event struct {
+	ID          uuid.UUID `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	EventDate   time.Time `json:"event_date"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type Ticket struct {
+	ID       uuid.UUID `json:"id"`
+	EventID  uuid.UUID `json:"event_id"`
+	Category string    `json:"category"` // VIP, Regular
+	Price    float64   `json:"price"`
+	Quota    int       `json:"quota"`
+}
+
+// Booking statuses
+const (
+	BookingStatusPending   = "PENDING"
+	BookingStatusConfirmed = "CONFIRMED"
+	BookingStatusCancelled = "CANCELLED"
+)
+
+type Booking struct {
+	ID          uuid.UUID  `json:"id"`
+	UserID      uuid.UUID  `json:"user_id"`
+	EventID     uuid.UUID  `json:"event_id"`
+	TicketID    uuid.UUID  `json:"ticket_id"`
+	Quantity    int        `json:"quantity"`
+	TotalAmount float64    `json:"total_amount"`
+	Status      string     `json:"status"`
+	ExpiredAt   *time.Time `json:"expired_at,omitempty"` // end of the hold on the tickets
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// Payment methods
+const (
+	PaymentMethodVA      = "VA"
+	PaymentMethodEWallet = "EWALLET"
+	PaymentMethodQRIS    = "QRIS"
+)
+
+type CreateBookingRequest struct {
+	EventID       uuid.UUID `json:"event_id"`
+	TicketID      uuid.UUID `json:"ticket_id"`
+	Quantity      int       `json:"quantity"`
+	PaymentMethod string    `json:"payment_method,omitempty"` // pays straight away; otherwise call Checkout
+}
+
+// Checkout statuses
+const (
+	CheckoutStatusRunning      = "RUNNING"
+	CheckoutStatusWaiting      = "WAITING"
+	CheckoutStatusCompensating = "COMPENSATING"
+	CheckoutStatusCompleted    = "COMPLETED"
+	CheckoutStatusCompensated  = "COMPENSATED"
+)
+
+// Checkout follows a booking from reserving its tickets to confirming it
+// once paid. A failed checkout is compensated: the payment is cancelled and
+// the tickets are released.
+type Checkout struct {
+	ID            uuid.UUID          `json:"id"`
+	BookingID     uuid.UUID          `json:"booking_id"`
+	UserID        uuid.UUID          `json:"user_id"`
+	Amount        float64            `json:"amount"`
+	PaymentMethod string             `json:"payment_method,omitempty"`
+	PaymentID     *uuid.UUID         `json:"payment_id,omitempty"`
+	Status        string             `json:"status"`
+	Step          string             `json:"step"`
+	FailureReason string             `json:"failure_reason,omitempty"`
+	Attempts      int                `json:"attempts"`
+	LastError     string             `json:"last_error,omitempty"`
+	NextAttemptAt time.Time          `json:"next_attempt_at"`
+	ExpiresAt     time.Time          `json:"expires_at"`
+	LockedUntil   *time.Time         `json:"locked_until,omitempty"`
+	CreatedAt     time.Time          `json:"created_at"`
+	UpdatedAt     time.Time          `json:"updated_at"`
+	Log           []CheckoutLogEntry `json:"log,omitempty"` // only returned by GetCheckout
+}
+
+// CheckoutLogEntry records one step of a checkout being run or compensated
+type CheckoutLogEntry struct {
+	ID        uint      `json:"id"`
+	Step      string    `json:"step"`
+	Action    string    `json:"action"`  // execute or compensate
+	Outcome   string    `json:"outcome"` // success or failure
+	Error     string    `json:"error,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// CheckoutResult is the outcome of Checkout. Payment is nil while payment
+// service cannot be reached; the checkout keeps retrying in the background.
+type CheckoutResult struct {
+	Checkout *Checkout `json:"checkout"`
+	Payment  *Payment  `json:"payment,omitempty"`
+}
+
+func (s *BookingService) ListEvents(ctx context.Context) ([]Event, error) {
+	var events []Event
+	err := s.c.do(ctx, request{
+		method:  http.MethodGet,
+		baseURL: s.c.bookingURL,
+		path:    "/api/v1/events/",
+		apiKey:  true,
+	}, &envelope{Data: &events})
+	return events, err
+}
+
+func (s *BookingService) GetEvent(ctx context.Context, id uuid.UUID) (*Event, error) {
+	var event Event
+	err := s.c.do(ctx, request{
+		method:  http.MethodGet,
+		baseURL: s.c.bookingURL,
+		path:    "/api/v1/events/" + id.String(),
+		apiKey:  true,
+	}, &envelope{Data: &event})
+	if err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+func (s *BookingService) ListEventTickets(ctx context.Context, eventID uuid.UUID) ([]Ticket, error) {
+	var tickets []Ticket
+	err := s.c.do(ctx, request{
+		method:  http.MethodGet,
+		baseURL: s.c.bookingURL,
+		path:    "/api/v1/events/" + eventID.String() + "/tickets",
+		apiKey:  true,
+	}, &envelope{Data: &tickets})
+	return tickets, err
+}
+
+func (s *BookingService) GetTicket(ctx context.Context, id uuid.UUID) (*Ticket, error) {
+	var ticket Ticket
+	err := s.c.do(ctx, request{
+		method:  http.MethodGet,
+		baseURL: s.c.bookingURL,
+		path:    "/api/v1/tickets/" + id.String(),
+		apiKey:  true,
+	}, &envelope{Data: &ticket})
+	if err != nil {
+		return nil, err
+	}
+	return &ticket, nil
+}
+
+// CreateBooking holds the tickets for the logged in user, or the owner of
+// the API key, which needs the bookings:write scope
+func (s *BookingService) CreateBooking(ctx context.Context, req CreateBookingRequest) (*Booking, error) {
+	var booking Booking
+	err := s.c.do(ctx, request{
+		method:  http.MethodPost,
+		baseURL: s.c.bookingURL,
+		path:    "/api/v1/bookings/",
+		body:    req,
+		bearer:  true,
+		apiKey:  true,
+	}, &envelope{Data: &booking})
+	if err != nil {
+		return nil, err
+	}
+	return &booking, nil
+}
+
+func (s *BookingService) ListBookings(ctx context.Context) ([]Booking, error) {
+	var bookings []Booking
+	err := s.c.do(ctx, request{
+		method:  http.MethodGet,
+		baseURL: s.c.bookingURL,
+		path:    "/api/v1/bookings/",
+	}, &envelope{Data: &bookings})
+	return bookings, err
+}
+
+func (s *BookingService) GetBooking(ctx context.Context, id uuid.UUID) (*Booking, error) {
+	var booking Booking
+	err := s.c.do(ctx, request{
+		method:  http.MethodGet,
+		baseURL: s.c.bookingURL,
+		path:    "/api/v1/bookings/" + id.String(),
+	}, &envelope{Data: &booking})
+	if err != nil {
+		return nil, err
+	}
+	return &booking, nil
+}
+
+// Checkout pays for a booking created without a payment method. Repeating
+// it with the same method returns the same payment. A checkout that failed
+// is returned as an *APIError with status 409 whose data holds the
+// checkout.
+func (s *BookingService) Checkout(ctx context.Context, bookingID uuid.UUID, paymentMethod string) (*CheckoutResult, error) {
+	var result CheckoutResult
+	err := s.c.do(ctx, request{
+		method:  http.MethodPost,
+		baseURL: s.c.bookingURL,
+		path:    "/api/v1/bookings/" + bookingID.String() + "/checkout",
+		body:    map[string]string{"payment_method": paymentMethod},
+		bearer:  true,
+		apiKey:  true,
+	}, &envelope{Data: &result})
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// BookingAdminService calls the administrator endpoints of booking
+// service. The client must be logged in as an admin.
+type BookingAdminService struct {
+	c          *Client
+	deliveries deliveryAdmin
+}
+
+// CheckoutFilter narrows ListCheckouts. Zero values match everything.
+type CheckoutFilter struct {
+	Status    string
+	BookingID *uuid.UUID
+	Limit     int // 0 uses the server's default
+}
+
+func (s *BookingAdminService) ListCheckouts(ctx context.Context, filter CheckoutFilter) ([]Checkout, error) {
+	query := url.Values{}
+	setQuery(query, "status", filter.Status)
+	if filter.BookingID != nil {
+		query.Set("booking_id", filter.BookingID.String())
+	}
+	setQueryInt(query, "limit", filter.Limit)
+
+	var checkouts []Checkout
+	err := s.c.do(ctx, request{
+		method:  http.MethodGet,
+		baseURL: s.c.bookingURL,
+		path:    "/api/v1/admin/sagas",
+		query:   query,
+		bearer:  true,
+	}, &envelope{Data: &checkouts})
+	return checkouts, err
+}
+
+// GetCheckout returns a checkout with the log of every step
+func (s *BookingAdminService) GetCheckout(ctx context.Context, id uuid.UUID) (*Checkout, error) {
+	var checkout Checkout
+	err := s.c.do(ctx, request{
+		method:  http.MethodGet,
+		baseURL: s.c.bookingURL,
+		path:    "/api/v1/admin/sagas/" + id.String(),
+		bearer:  true,
+	}, &envelope{Data: &checkout})
+	if err != nil {
+		return nil, err
+	}
+	return &checkout, nil
+}
+
+func (s *BookingAdminService) ListDeliveries(ctx context.Context, filter DeliveryFilter) ([]Delivery, error) {
+	return s.deliveries.list(ctx, filter)
+}
+
+func (s *BookingAdminService) ReplayDelivery(ctx context.Context, id uuid.UUID) (*Delivery, error) {
+	return s.deliveries.replay(ctx, id)
+}
+
+func (s *BookingAdminService) ReplayDeliveries(ctx context.Context, req ReplayDeliveriesRequest) ([]Delivery, error) {
+	return s.deliveries.replayAll(ctx, req)
+}
@@ -0,0 +1,238 @@
+// Package sdk is a Go client for the public APIs of user, booking and
+// payment service.
+//
+//	client := sdk.New(sdk.Config{
+//		UserServiceURL:    "http://localhost:3001",
+//		BookingServiceURL: "http://localhost:3002",
+//		PaymentServiceURL: "http://localhost:3003",
+//	})
+//
+//	if _, err := client.Users.Login(ctx, "alice", "secret"); err != nil {
+//		return err
+//	}
+//	booking, err := client.Bookings.CreateBooking(ctx, sdk.CreateBookingRequest{
+//		EventID:  eventID,
+//		TicketID: ticketID,
+//		Quantity: 2,
+//	})
+//
+// Once logged in, every request carries the access token, which is renewed
+// through /api/v1/refresh shortly before it expires or when a service
+// rejects it. Responses outside the 2xx range are returned as *APIError.
+package sdk
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Config points the client at the services. URLs without a path, such as
+// http://localhost:3001; only the services that are used need to be set.
+type Config struct {
+	UserServiceURL    string
+	BookingServiceURL string
+	PaymentServiceURL string
+
+	// APIKey is sent as X-API-Key to booking and payment service, which
+	// then act on behalf of the key's owner within the key's scopes
+	APIKey string
+
+	// HTTPClient sends the requests. Defaults to a client with a 30 second
+	// timeout.
+	HTTPClient *http.Client
+}
+
+// Client calls user, booking and payment service. It is safe for
+// concurrent use.
+type Client struct {
+	Users        *UserService
+	UserAdmin    *UserAdminService
+	Bookings     *BookingService
+	BookingAdmin *BookingAdminService
+	Payments     *PaymentService
+	PaymentAdmin *PaymentAdminService
+
+	userURL    string
+	bookingURL string
+	paymentURL string
+	apiKey     string
+	http       *http.Client
+
+	mu     sync.Mutex
+	tokens Tokens
+}
+
+func New(cfg Config) *Client {
+	httpClient := cfg.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 30 * time.Second}
+	}
+
+	c := &Client{
+		userURL:    strings.TrimSuffix(cfg.UserServiceURL, "/"),
+		bookingURL: strings.TrimSuffix(cfg.BookingServiceURL, "/"),
+		paymentURL: strings.TrimSuffix(cfg.PaymentServiceURL, "/"),
+		apiKey:     cfg.APIKey,
+		http:       httpClient,
+	}
+	c.Users = &UserService{c: c}
+	c.UserAdmin = &UserAdminService{c: c}
+	c.Bookings = &BookingService{c: c}
+	c.BookingAdmin = &BookingAdminService{c: c, deliveries: deliveryAdmin{c: c, baseURL: c.bookingURL}}
+	c.Payments = &PaymentService{c: c}
+	c.PaymentAdmin = &PaymentAdminService{c: c, deliveries: deliveryAdmin{c: c, baseURL: c.paymentURL}}
+	return c
+}
+
+// request describes one API call
+type request struct {
+	method  string
+	baseURL string
+	path    string
+	query   url.Values
+	body    interface{} // sent as JSON
+	form    url.Values  // sent form encoded instead of body
+	header  http.Header
+
+	bearer bool // send the access token
+	apiKey bool // send the API key, if one is configured
+}
+
+// envelope is the {"message": ..., "data": ...} body most endpoints answer
+// with. Data is set to a pointer to decode it into.
+type envelope struct {
+	Message string      `json:"message"`
+	Data    interface{} `json:"data"`
+}
+
+// do sends req and decodes the response body into out, which may be nil.
+// A request rejected with 401 is sent once more after renewing the access
+// token.
+func (c *Client) do(ctx context.Context, req request, out interface{}) error {
+	resp, err := c.send(ctx, req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return newAPIError(resp, body)
+	}
+
+	if out == nil || len(body) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("failed to decode response of %s %s: %w", req.method, req.path, err)
+	}
+	return nil
+}
+
+// send sends req and returns the response without reading its body
+func (c *Client) send(ctx context.Context, req request) (*http.Response, error) {
+	if req.baseURL == "" {
+		return nil, fmt.Errorf("no URL configured for %s", req.path)
+	}
+
+	var payload []byte
+	contentType := ""
+	switch {
+	case req.form != nil:
+		payload = []byte(req.form.Encode())
+		contentType = "application/x-www-form-urlencoded"
+	case req.body != nil:
+		var err error
+		if payload, err = json.Marshal(req.body); err != nil {
+			return nil, err
+		}
+		contentType = "application/json"
+	}
+
+	target := req.baseURL + req.path
+	if len(req.query) > 0 {
+		target += "?" + req.query.Encode()
+	}
+
+	token := ""
+	if req.bearer {
+		var err error
+		if token, err = c.accessToken(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	resp, err := c.attempt(ctx, req, target, contentType, payload, token)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusUnauthorized && token != "" {
+		renewed, err := c.renewAccessToken(ctx, token)
+		if err != nil || renewed == "" {
+			// Hand back the original rejection
+			return resp, nil
+		}
+		resp.Body.Close()
+		return c.attempt(ctx, req, target, contentType, payload, renewed)
+	}
+
+	return resp, nil
+}
+
+func (c *Client) attempt(ctx context.Context, req request, target, contentType string, payload []byte, token string) (*http.Response, error) {
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, req.method, target, body)
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range req.header {
+		httpReq.Header[key] = values
+	}
+	httpReq.Header.Set("Accept", "application/json")
+	if contentType != "" {
+		httpReq.Header.Set("Content-Type", contentType)
+	}
+	if token != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+token)
+	}
+	if req.apiKey && c.apiKey != "" {
+		httpReq.Header.Set("X-API-Key", c.apiKey)
+	}
+
+	return c.http.Do(httpReq)
+}
+
+// escape prepares an ID for use as a path segment
+func escape(id string) string {
+	return url.PathEscape(id)
+}
+
+func setQuery(query url.Values, key, value string) {
+	if value != "" {
+		query.Set(key, value)
+	}
+}
+
+func setQueryInt(query url.Values, key string, value int) {
+	if value > 0 {
+		query.Set(key, strconv.Itoa(value))
+	}
+}
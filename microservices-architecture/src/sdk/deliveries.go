@@ -0,0 +1,100 @@
+package sdk
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Delivery statuses
+const (
+	DeliveryStatusPending   = "PENDING"   // Being published
+	DeliveryStatusDelivered = "DELIVERED" // Accepted by the event bus
+	DeliveryStatusFailed    = "FAILED"    // Due to be retried at NextRetryAt
+	DeliveryStatusDead      = "DEAD"      // Out of attempts, only replayed by hand
+)
+
+// Delivery is an event a service published, kept until the event bus
+// accepted it
+type Delivery struct {
+	ID          uuid.UUID       `json:"id"`
+	Subject     string          `json:"subject"`
+	Payload     json.RawMessage `json:"payload"`
+	Status      string          `json:"status"`
+	Attempts    int             `json:"attempts"`
+	LastError   string          `json:"last_error,omitempty"`
+	NextRetryAt *time.Time      `json:"next_retry_at,omitempty"`
+	DeliveredAt *time.Time      `json:"delivered_at,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+}
+
+// DeliveryFilter narrows ListDeliveries. Zero values match everything.
+type DeliveryFilter struct {
+	Status  string
+	Subject string
+	Limit   int // 0 uses the server's default
+}
+
+// ReplayDeliveriesRequest selects the deliveries to publish again, either
+// by ID or by filter
+type ReplayDeliveriesRequest struct {
+	IDs     []uuid.UUID `json:"ids,omitempty"`
+	Status  string      `json:"status,omitempty"`
+	Subject string      `json:"subject,omitempty"`
+	Limit   int         `json:"limit,omitempty"`
+}
+
+// deliveryAdmin calls the delivery endpoints, which booking and payment
+// service share
+type deliveryAdmin struct {
+	c       *Client
+	baseURL string
+}
+
+func (d deliveryAdmin) list(ctx context.Context, filter DeliveryFilter) ([]Delivery, error) {
+	query := url.Values{}
+	setQuery(query, "status", filter.Status)
+	setQuery(query, "subject", filter.Subject)
+	setQueryInt(query, "limit", filter.Limit)
+
+	var deliveries []Delivery
+	err := d.c.do(ctx, request{
+		method:  http.MethodGet,
+		baseURL: d.baseURL,
+		path:    "/api/v1/admin/deliveries",
+		query:   query,
+		bearer:  true,
+	}, &envelope{Data: &deliveries})
+	return deliveries, err
+}
+
+func (d deliveryAdmin) replay(ctx context.Context, id uuid.UUID) (*Delivery, error) {
+	var delivery Delivery
+	err := d.c.do(ctx, request{
+		method:  http.MethodPost,
+		baseURL: d.baseURL,
+		path:    "/api/v1/admin/deliveries/" + id.String() + "/replay",
+		bearer:  true,
+	}, &envelope{Data: &delivery})
+	if err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+func (d deliveryAdmin) replayAll(ctx context.Context, req ReplayDeliveriesRequest) ([]Delivery, error) {
+	var deliveries []Delivery
+	err := d.c.do(ctx, request{
+		method:  http.MethodPost,
+		baseURL: d.baseURL,
+		path:    "/api/v1/admin/deliveries/replay",
+		body:    req,
+		bearer:  true,
+	}, &envelope{Data: &deliveries})
+	return deliveries, err
+}
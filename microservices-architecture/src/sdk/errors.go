@@ -0,0 +1,117 @@
+package sdk
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Errors an *APIError matches with errors.Is, by status code
+var (
+	ErrBadRequest   = errors.New("bad request")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrForbidden    = errors.New("forbidden")
+	ErrNotFound     = errors.New("not found")
+	ErrConflict     = errors.New("conflict")
+	ErrRateLimited  = errors.New("rate limited")
+	ErrUnavailable  = errors.New("service unavailable")
+)
+
+// APIError is returned for responses outside the 2xx range
+type APIError struct {
+	StatusCode int
+	Method     string
+	URL        string
+
+	// Message is the "error" field of the response, or its body when the
+	// response is not JSON. OAuth endpoints put an error code here and
+	// explain it in Description.
+	Message     string
+	Description string
+
+	// RetryAfter is how long a throttled client should wait, from the
+	// Retry-After header
+	RetryAfter time.Duration
+
+	// Data is the "data" field some errors carry, such as the existing
+	// payment when one is created twice. Decode it with DecodeData.
+	Data json.RawMessage
+}
+
+func newAPIError(resp *http.Response, body []byte) *APIError {
+	apiErr := &APIError{
+		StatusCode: resp.StatusCode,
+		Method:     resp.Request.Method,
+		URL:        resp.Request.URL.String(),
+	}
+
+	var payload struct {
+		Error            string          `json:"error"`
+		ErrorDescription string          `json:"error_description"`
+		Data             json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(body, &payload); err == nil && payload.Error != "" {
+		apiErr.Message = payload.Error
+		apiErr.Description = payload.ErrorDescription
+		apiErr.Data = payload.Data
+	} else {
+		apiErr.Message = strings.TrimSpace(string(body))
+	}
+
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		apiErr.RetryAfter = time.Duration(seconds) * time.Second
+	}
+
+	return apiErr
+}
+
+func (e *APIError) Error() string {
+	message := e.Message
+	if e.Description != "" {
+		message += ": " + e.Description
+	}
+	if message == "" {
+		message = http.StatusText(e.StatusCode)
+	}
+	return fmt.Sprintf("%s %s returned status %d: %s", e.Method, e.URL, e.StatusCode, message)
+}
+
+// Is matches the error against ErrBadRequest, ErrUnauthorized, ErrForbidden,
+// ErrNotFound, ErrConflict, ErrRateLimited and ErrUnavailable
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrBadRequest:
+		return e.StatusCode == http.StatusBadRequest
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized
+	case ErrForbidden:
+		return e.StatusCode == http.StatusForbidden
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrConflict:
+		return e.StatusCode == http.StatusConflict
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	case ErrUnavailable:
+		return e.StatusCode >= 500
+	}
+	return false
+}
+
+// Temporary reports whether the request may succeed when sent again later
+func (e *APIError) Temporary() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
+// DecodeData unmarshals the error's data into v. It reports false if the
+// error carries no data.
+func (e *APIError) DecodeData(v interface{}) (bool, error) {
+	if len(e.Data) == 0 || string(e.Data) == "null" {
+		return false, nil
+	}
+	return true, json.Unmarshal(e.Data, v)
+}
@@ -0,0 +1,5 @@
+module sdk
+
+go 1.21
+
+require github.com/google/uuid v1.5.0
@@ -0,0 +1,124 @@
+package sdk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+)
+
+// Iterator walks a paginated listing, fetching the next page once the
+// current one is used up:
+//
+//	it := client.UserAdmin.Users(ctx, sdk.UserFilter{Role: "admin"})
+//	for it.Next() {
+//		user := it.Value()
+//		...
+//	}
+//	if err := it.Err(); err != nil {
+//		return err
+//	}
+type Iterator[T any] struct {
+	ctx   context.Context
+	fetch func(ctx context.Context, page int) (items []T, more bool, err error)
+
+	page  int
+	items []T
+	more  bool
+	value T
+	err   error
+}
+
+func newIterator[T any](ctx context.Context, fetch func(ctx context.Context, page int) ([]T, bool, error)) *Iterator[T] {
+	return &Iterator[T]{ctx: ctx, fetch: fetch, more: true}
+}
+
+// Next moves to the next item. It returns false when there are no more
+// items or a page could not be fetched; check Err to tell them apart.
+func (it *Iterator[T]) Next() bool {
+	for len(it.items) == 0 {
+		if !it.more || it.err != nil {
+			return false
+		}
+		it.page++
+		it.items, it.more, it.err = it.fetch(it.ctx, it.page)
+		if len(it.items) == 0 {
+			// An empty page ends the listing even if it claims otherwise
+			it.more = false
+		}
+	}
+
+	it.value, it.items = it.items[0], it.items[1:]
+	return true
+}
+
+// Value returns the item Next moved to
+func (it *Iterator[T]) Value() T {
+	return it.value
+}
+
+// Err returns the error that stopped the iteration, if any
+func (it *Iterator[T]) Err() error {
+	return it.err
+}
+
+// Stream reads a newline delimited JSON response one item at a time. It
+// must be closed.
+type Stream[T any] struct {
+	body    io.ReadCloser
+	decoder *json.Decoder
+	value   T
+	err     error
+}
+
+// openStream sends req and returns its body as a stream of T
+func openStream[T any](ctx context.Context, c *Client, req request) (*Stream[T], error) {
+	resp, err := c.send(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+		return nil, newAPIError(resp, body)
+	}
+
+	return &Stream[T]{body: resp.Body, decoder: json.NewDecoder(resp.Body)}, nil
+}
+
+// Next reads the next item. It returns false at the end of the stream or
+// when reading fails; check Err to tell them apart.
+func (s *Stream[T]) Next() bool {
+	if s.err != nil {
+		return false
+	}
+
+	var value T
+	if err := s.decoder.Decode(&value); err != nil {
+		if !errors.Is(err, io.EOF) {
+			s.err = err
+		}
+		return false
+	}
+	s.value = value
+	return true
+}
+
+// Value returns the item Next read
+func (s *Stream[T]) Value() T {
+	return s.value
+}
+
+// Err returns the error that stopped reading, if any
+func (s *Stream[T]) Err() error {
+	return s.err
+}
+
+func (s *Stream[T]) Close() error {
+	return s.body.Close()
+}
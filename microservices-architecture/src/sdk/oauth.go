@@ -0,0 +1,163 @@
+package sdk
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+)
+
+// OAuth grant types accepted by the token endpoint
+const (
+	GrantAuthorizationCode = "authorization_code"
+	GrantRefreshToken      = "refresh_token"
+	GrantClientCredentials = "client_credentials"
+)
+
+// TokenRequest is sent to the OAuth token endpoint. Fields that do not
+// apply to the grant type are left empty. Confidential clients set
+// ClientSecret and authenticate with HTTP Basic.
+type TokenRequest struct {
+	GrantType    string
+	Code         string
+	RedirectURI  string
+	CodeVerifier string
+	RefreshToken string
+	Scope        string
+	Audience     string // client_credentials only
+	ClientID     string
+	ClientSecret string
+}
+
+type TokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	IDToken      string `json:"id_token,omitempty"`
+	Scope        string `json:"scope"`
+	Audience     string `json:"audience,omitempty"`
+}
+
+// UserInfo holds the OIDC claims released for the access token's scopes
+type UserInfo struct {
+	Subject           string `json:"sub"`
+	PreferredUsername string `json:"preferred_username,omitempty"`
+	Email             string `json:"email,omitempty"`
+	EmailVerified     bool   `json:"email_verified,omitempty"`
+}
+
+// OpenIDConfiguration is the OIDC discovery document
+type OpenIDConfiguration struct {
+	Issuer                            string   `json:"issuer"`
+	AuthorizationEndpoint             string   `json:"authorization_endpoint"`
+	TokenEndpoint                     string   `json:"token_endpoint"`
+	UserInfoEndpoint                  string   `json:"userinfo_endpoint"`
+	JWKSURI                           string   `json:"jwks_uri"`
+	ResponseTypesSupported            []string `json:"response_types_supported"`
+	GrantTypesSupported               []string `json:"grant_types_supported"`
+	SubjectTypesSupported             []string `json:"subject_types_supported"`
+	IDTokenSigningAlgValuesSupported  []string `json:"id_token_signing_alg_values_supported"`
+	ScopesSupported                   []string `json:"scopes_supported"`
+	TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported"`
+	CodeChallengeMethodsSupported     []string `json:"code_challenge_methods_supported"`
+	ClaimsSupported                   []string `json:"claims_supported"`
+}
+
+// JWK is a public key tokens are signed with
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// Token calls the OAuth token endpoint. The returned tokens are not used
+// by the client; see SetTokens.
+func (s *UserService) Token(ctx context.Context, req TokenRequest) (*TokenResponse, error) {
+	form := url.Values{}
+	form.Set("grant_type", req.GrantType)
+	for key, value := range map[string]string{
+		"code":          req.Code,
+		"redirect_uri":  req.RedirectURI,
+		"code_verifier": req.CodeVerifier,
+		"refresh_token": req.RefreshToken,
+		"scope":         req.Scope,
+		"audience":      req.Audience,
+	} {
+		if value != "" {
+			form.Set(key, value)
+		}
+	}
+
+	header := http.Header{}
+	if req.ClientSecret != "" {
+		// Credentials are form encoded before going into the header
+		credentials := url.QueryEscape(req.ClientID) + ":" + url.QueryEscape(req.ClientSecret)
+		header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(credentials)))
+	} else {
+		form.Set("client_id", req.ClientID)
+	}
+
+	var resp TokenResponse
+	err := s.c.do(ctx, request{
+		method:  http.MethodPost,
+		baseURL: s.c.userURL,
+		path:    "/api/v1/oauth/token",
+		form:    form,
+		header:  header,
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// UserInfo returns the OIDC claims of the logged in user. The access token
+// must carry the openid scope.
+func (s *UserService) UserInfo(ctx context.Context) (*UserInfo, error) {
+	var info UserInfo
+	err := s.c.do(ctx, request{
+		method:  http.MethodGet,
+		baseURL: s.c.userURL,
+		path:    "/api/v1/oauth/userinfo",
+		bearer:  true,
+	}, &info)
+	if err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+func (s *UserService) OpenIDConfiguration(ctx context.Context) (*OpenIDConfiguration, error) {
+	var config OpenIDConfiguration
+	err := s.c.do(ctx, request{
+		method:  http.MethodGet,
+		baseURL: s.c.userURL,
+		path:    "/.well-known/openid-configuration",
+	}, &config)
+	if err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// JWKS returns the keys access tokens and id_tokens are signed with
+func (s *UserService) JWKS(ctx context.Context) (*JWKSet, error) {
+	var set JWKSet
+	err := s.c.do(ctx, request{
+		method:  http.MethodGet,
+		baseURL: s.c.userURL,
+		path:    "/.well-known/jwks.json",
+	}, &set)
+	if err != nil {
+		return nil, err
+	}
+	return &set, nil
+}
@@ -0,0 +1,116 @@
+package sdk
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PaymentService calls the payment endpoints of payment service
+type PaymentService struct {
+	c *Client
+}
+
+// Payment statuses
+const (
+	PaymentStatusPending       = "PENDING"
+	PaymentStatusPaid          = "PAID"
+	PaymentStatusFailed        = "FAILED"
+	PaymentStatusExpired       = "EXPIRED"
+	PaymentStatusCancelled     = "CANCELLED"
+	PaymentStatusRefundPending = "REFUND_PENDING"
+	PaymentStatusRefunded      = "REFUNDED"
+)
+
+type Payment struct {
+	ID            uuid.UUID  `json:"id"`
+	BookingID     uuid.UUID  `json:"booking_id"`
+	UserID        uuid.UUID  `json:"user_id"`
+	Amount        float64    `json:"amount"`
+	Currency      string     `json:"currency"`
+	PaymentMethod string     `json:"payment_method"`
+	Status        string     `json:"status"`
+	ExpiredAt     *time.Time `json:"expired_at,omitempty"`
+	PaidAt        *time.Time `json:"paid_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+type CreatePaymentRequest struct {
+	BookingID     uuid.UUID `json:"booking_id"`
+	Amount        float64   `json:"amount"`
+	PaymentMethod string    `json:"payment_method"`
+}
+
+// CreatePayment opens a payment for a booking. A booking has at most one
+// payment; creating another returns an *APIError with status 409 whose
+// data holds the existing payment.
+func (s *PaymentService) CreatePayment(ctx context.Context, req CreatePaymentRequest) (*Payment, error) {
+	var payment Payment
+	err := s.c.do(ctx, request{
+		method:  http.MethodPost,
+		baseURL: s.c.paymentURL,
+		path:    "/api/v1/payments/",
+		body:    req,
+	}, &envelope{Data: &payment})
+	if err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
+
+func (s *PaymentService) ListPayments(ctx context.Context) ([]Payment, error) {
+	var payments []Payment
+	err := s.c.do(ctx, request{
+		method:  http.MethodGet,
+		baseURL: s.c.paymentURL,
+		path:    "/api/v1/payments/",
+	}, &envelope{Data: &payments})
+	return payments, err
+}
+
+// GetPayment needs an API key with the payments:read scope, and only
+// returns payments of the key's owner
+func (s *PaymentService) GetPayment(ctx context.Context, id uuid.UUID) (*Payment, error) {
+	var payment Payment
+	err := s.c.do(ctx, request{
+		method:  http.MethodGet,
+		baseURL: s.c.paymentURL,
+		path:    "/api/v1/payments/" + id.String(),
+		apiKey:  true,
+	}, &envelope{Data: &payment})
+	if err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
+
+func (s *PaymentService) UpdatePaymentStatus(ctx context.Context, id uuid.UUID, status string) error {
+	return s.c.do(ctx, request{
+		method:  http.MethodPut,
+		baseURL: s.c.paymentURL,
+		path:    "/api/v1/payments/" + id.String() + "/status",
+		body:    map[string]string{"status": status},
+	}, nil)
+}
+
+// PaymentAdminService calls the administrator endpoints of payment
+// service. The client must be logged in as an admin.
+type PaymentAdminService struct {
+	c          *Client
+	deliveries deliveryAdmin
+}
+
+func (s *PaymentAdminService) ListDeliveries(ctx context.Context, filter DeliveryFilter) ([]Delivery, error) {
+	return s.deliveries.list(ctx, filter)
+}
+
+func (s *PaymentAdminService) ReplayDelivery(ctx context.Context, id uuid.UUID) (*Delivery, error) {
+	return s.deliveries.replay(ctx, id)
+}
+
+func (s *PaymentAdminService) ReplayDeliveries(ctx context.Context, req ReplayDeliveriesRequest) ([]Delivery, error) {
+	return s.deliveries.replayAll(ctx, req)
+}
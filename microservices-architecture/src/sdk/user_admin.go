@@ -0,0 +1,361 @@
+package sdk
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UserAdminService calls the administrator endpoints of user service. The
+// client must be logged in as an admin.
+type UserAdminService struct {
+	c *Client
+}
+
+// AdminUser is the view of an account shown to administrators
+type AdminUser struct {
+	User
+	LockedUntil    *time.Time `json:"locked_until"`
+	DisabledAt     *time.Time `json:"disabled_at"`
+	DisabledReason string     `json:"disabled_reason,omitempty"`
+	DeletedAt      *time.Time `json:"deleted_at"`
+	SessionCount   int64      `json:"session_count"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// UserFilter narrows ListUsers and Users. Zero values match everything.
+type UserFilter struct {
+	Query   string // matches username or email
+	Role    string
+	Status  string // active, disabled, locked or deleted
+	PerPage int
+}
+
+type UserPage struct {
+	Users      []AdminUser `json:"users"`
+	Page       int         `json:"page"`
+	PerPage    int         `json:"per_page"`
+	Total      int64       `json:"total"`
+	TotalPages int64       `json:"total_pages"`
+}
+
+// LockEvent records an account being locked or unlocked
+type LockEvent struct {
+	ID          uuid.UUID  `json:"id"`
+	UserID      uuid.UUID  `json:"user_id"`
+	Action      string     `json:"action"` // LOCKED or UNLOCKED
+	Reason      string     `json:"reason"`
+	IP          string     `json:"ip,omitempty"`
+	ActorID     *uuid.UUID `json:"actor_id,omitempty"`
+	LockedUntil *time.Time `json:"locked_until,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// AuthEvent is one entry of the security audit log
+type AuthEvent struct {
+	ID        uuid.UUID  `json:"id"`
+	Type      string     `json:"type"`
+	Outcome   string     `json:"outcome"` // success or failure
+	UserID    *uuid.UUID `json:"user_id,omitempty"`
+	Username  string     `json:"username,omitempty"`
+	ActorID   *uuid.UUID `json:"actor_id,omitempty"`
+	IP        string     `json:"ip,omitempty"`
+	UserAgent string     `json:"user_agent,omitempty"`
+	Reason    string     `json:"reason,omitempty"`
+	Detail    string     `json:"detail,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// AuthEventFilter narrows ListAuthEvents and ExportAuthEvents. Zero values
+// match everything.
+type AuthEventFilter struct {
+	UserID  *uuid.UUID
+	Type    string
+	Outcome string
+	IP      string
+	Since   *time.Time
+	Until   *time.Time
+	Limit   int
+}
+
+type RegisterOAuthClientRequest struct {
+	Name         string   `json:"name"`
+	RedirectURIs []string `json:"redirect_uris"`
+	Scopes       []string `json:"scopes"`
+	Public       bool     `json:"public"`
+	FirstParty   bool     `json:"first_party"`
+
+	// Service accounts use the client credentials grant to call internal
+	// APIs of the listed audiences
+	ServiceAccount bool     `json:"service_account"`
+	Audiences      []string `json:"audiences,omitempty"`
+}
+
+type OAuthClient struct {
+	ID             uuid.UUID `json:"id"`
+	ClientID       string    `json:"client_id"`
+	ClientSecret   string    `json:"client_secret,omitempty"` // only returned on registration
+	Name           string    `json:"name"`
+	RedirectURIs   []string  `json:"redirect_uris"`
+	Scopes         []string  `json:"scopes"`
+	Public         bool      `json:"public"`
+	FirstParty     bool      `json:"first_party"`
+	ServiceAccount bool      `json:"service_account"`
+	Audiences      []string  `json:"audiences,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// ListUsers returns one page of accounts, the first being page 1
+func (s *UserAdminService) ListUsers(ctx context.Context, filter UserFilter, page int) (*UserPage, error) {
+	query := url.Values{}
+	setQuery(query, "q", filter.Query)
+	setQuery(query, "role", filter.Role)
+	setQuery(query, "status", filter.Status)
+	setQueryInt(query, "page", page)
+	setQueryInt(query, "per_page", filter.PerPage)
+
+	var result UserPage
+	err := s.c.do(ctx, request{
+		method:  http.MethodGet,
+		baseURL: s.c.userURL,
+		path:    "/api/v1/admin/users",
+		query:   query,
+		bearer:  true,
+	}, &envelope{Data: &result})
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Users iterates over every account matching filter
+func (s *UserAdminService) Users(ctx context.Context, filter UserFilter) *Iterator[AdminUser] {
+	return newIterator(ctx, func(ctx context.Context, page int) ([]AdminUser, bool, error) {
+		result, err := s.ListUsers(ctx, filter, page)
+		if err != nil {
+			return nil, false, err
+		}
+		return result.Users, int64(result.Page) < result.TotalPages, nil
+	})
+}
+
+func (s *UserAdminService) GetUser(ctx context.Context, id uuid.UUID) (*AdminUser, error) {
+	return s.userAction(ctx, http.MethodGet, "/api/v1/admin/users/"+id.String(), nil)
+}
+
+// DeleteUser soft deletes the account; RestoreUser brings it back
+func (s *UserAdminService) DeleteUser(ctx context.Context, id uuid.UUID) error {
+	return s.c.do(ctx, request{
+		method:  http.MethodDelete,
+		baseURL: s.c.userURL,
+		path:    "/api/v1/admin/users/" + id.String(),
+		bearer:  true,
+	}, nil)
+}
+
+// DisableUser blocks logins and signs the user out everywhere
+func (s *UserAdminService) DisableUser(ctx context.Context, id uuid.UUID, reason string) (*AdminUser, error) {
+	return s.userAction(ctx, http.MethodPost, "/api/v1/admin/users/"+id.String()+"/disable", map[string]string{"reason": reason})
+}
+
+func (s *UserAdminService) EnableUser(ctx context.Context, id uuid.UUID) (*AdminUser, error) {
+	return s.userAction(ctx, http.MethodPost, "/api/v1/admin/users/"+id.String()+"/enable", nil)
+}
+
+func (s *UserAdminService) RestoreUser(ctx context.Context, id uuid.UUID) (*AdminUser, error) {
+	return s.userAction(ctx, http.MethodPost, "/api/v1/admin/users/"+id.String()+"/restore", nil)
+}
+
+func (s *UserAdminService) SetRole(ctx context.Context, id uuid.UUID, role string) (*AdminUser, error) {
+	return s.userAction(ctx, http.MethodPut, "/api/v1/admin/users/"+id.String()+"/role", map[string]string{"role": role})
+}
+
+func (s *UserAdminService) userAction(ctx context.Context, method, path string, body interface{}) (*AdminUser, error) {
+	var user AdminUser
+	err := s.c.do(ctx, request{
+		method:  method,
+		baseURL: s.c.userURL,
+		path:    path,
+		body:    body,
+		bearer:  true,
+	}, &envelope{Data: &user})
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// UnlockUser lifts a lock placed after too many failed logins
+func (s *UserAdminService) UnlockUser(ctx context.Context, id uuid.UUID) error {
+	return s.c.do(ctx, request{
+		method:  http.MethodPost,
+		baseURL: s.c.userURL,
+		path:    "/api/v1/admin/users/" + id.String() + "/unlock",
+		bearer:  true,
+	}, nil)
+}
+
+// ListLockEvents returns the newest lock events, of one user if userID is
+// set. A limit of 0 uses the server's default.
+func (s *UserAdminService) ListLockEvents(ctx context.Context, userID *uuid.UUID, limit int) ([]LockEvent, error) {
+	query := url.Values{}
+	if userID != nil {
+		query.Set("user_id", userID.String())
+	}
+	setQueryInt(query, "limit", limit)
+
+	var events []LockEvent
+	err := s.c.do(ctx, request{
+		method:  http.MethodGet,
+		baseURL: s.c.userURL,
+		path:    "/api/v1/admin/lock-events",
+		query:   query,
+		bearer:  true,
+	}, &envelope{Data: &events})
+	return events, err
+}
+
+// ListAuthEvents returns audit log entries, newest first
+func (s *UserAdminService) ListAuthEvents(ctx context.Context, filter AuthEventFilter) ([]AuthEvent, error) {
+	var events []AuthEvent
+	err := s.c.do(ctx, request{
+		method:  http.MethodGet,
+		baseURL: s.c.userURL,
+		path:    "/api/v1/admin/auth-events",
+		query:   authEventQuery(filter),
+		bearer:  true,
+	}, &envelope{Data: &events})
+	return events, err
+}
+
+// ExportAuthEvents streams every matching audit log entry, oldest first.
+// Without a limit the whole log is exported.
+func (s *UserAdminService) ExportAuthEvents(ctx context.Context, filter AuthEventFilter) (*Stream[AuthEvent], error) {
+	query := authEventQuery(filter)
+	query.Set("format", "ndjson")
+
+	return openStream[AuthEvent](ctx, s.c, request{
+		method:  http.MethodGet,
+		baseURL: s.c.userURL,
+		path:    "/api/v1/admin/auth-events",
+		query:   query,
+		bearer:  true,
+	})
+}
+
+func authEventQuery(filter AuthEventFilter) url.Values {
+	query := url.Values{}
+	if filter.UserID != nil {
+		query.Set("user_id", filter.UserID.String())
+	}
+	setQuery(query, "type", filter.Type)
+	setQuery(query, "outcome", filter.Outcome)
+	setQuery(query, "ip", filter.IP)
+	if filter.Since != nil {
+		query.Set("since", filter.Since.Format(time.RFC3339))
+	}
+	if filter.Until != nil {
+		query.Set("until", filter.Until.Format(time.RFC3339))
+	}
+	setQueryInt(query, "limit", filter.Limit)
+	return query
+}
+
+func (s *UserAdminService) ListUserSessions(ctx context.Context, userID uuid.UUID) ([]Session, error) {
+	var sessions []Session
+	err := s.c.do(ctx, request{
+		method:  http.MethodGet,
+		baseURL: s.c.userURL,
+		path:    "/api/v1/admin/users/" + userID.String() + "/sessions",
+		bearer:  true,
+	}, &envelope{Data: &sessions})
+	return sessions, err
+}
+
+func (s *UserAdminService) RevokeUserSession(ctx context.Context, userID, sessionID uuid.UUID) error {
+	return s.c.do(ctx, request{
+		method:  http.MethodDelete,
+		baseURL: s.c.userURL,
+		path:    "/api/v1/admin/users/" + userID.String() + "/sessions/" + sessionID.String(),
+		bearer:  true,
+	}, nil)
+}
+
+func (s *UserAdminService) RevokeAllUserSessions(ctx context.Context, userID uuid.UUID) error {
+	return s.c.do(ctx, request{
+		method:  http.MethodDelete,
+		baseURL: s.c.userURL,
+		path:    "/api/v1/admin/users/" + userID.String() + "/sessions",
+		bearer:  true,
+	}, nil)
+}
+
+// RegisterOAuthClient returns the new client with its secret, which cannot
+// be retrieved again
+func (s *UserAdminService) RegisterOAuthClient(ctx context.Context, req RegisterOAuthClientRequest) (*OAuthClient, error) {
+	var client OAuthClient
+	err := s.c.do(ctx, request{
+		method:  http.MethodPost,
+		baseURL: s.c.userURL,
+		path:    "/api/v1/admin/oauth/clients",
+		body:    req,
+		bearer:  true,
+	}, &envelope{Data: &client})
+	if err != nil {
+		return nil, err
+	}
+	return &client, nil
+}
+
+func (s *UserAdminService) ListOAuthClients(ctx context.Context) ([]OAuthClient, error) {
+	var clients []OAuthClient
+	err := s.c.do(ctx, request{
+		method:  http.MethodGet,
+		baseURL: s.c.userURL,
+		path:    "/api/v1/admin/oauth/clients",
+		bearer:  true,
+	}, &envelope{Data: &clients})
+	return clients, err
+}
+
+func (s *UserAdminService) DeleteOAuthClient(ctx context.Context, clientID string) error {
+	return s.c.do(ctx, request{
+		method:  http.MethodDelete,
+		baseURL: s.c.userURL,
+		path:    "/api/v1/admin/oauth/clients/" + escape(clientID),
+		bearer:  true,
+	}, nil)
+}
+
+// ListAPIKeys returns the API keys of every user, or of one user if userID
+// is set. A limit of 0 uses the server's default.
+func (s *UserAdminService) ListAPIKeys(ctx context.Context, userID *uuid.UUID, limit int) ([]APIKey, error) {
+	query := url.Values{}
+	if userID != nil {
+		query.Set("user_id", userID.String())
+	}
+	setQueryInt(query, "limit", limit)
+
+	var keys []APIKey
+	err := s.c.do(ctx, request{
+		method:  http.MethodGet,
+		baseURL: s.c.userURL,
+		path:    "/api/v1/admin/api-keys",
+		query:   query,
+		bearer:  true,
+	}, &envelope{Data: &keys})
+	return keys, err
+}
+
+func (s *UserAdminService) RevokeAPIKey(ctx context.Context, id uuid.UUID) error {
+	return s.c.do(ctx, request{
+		method:  http.MethodDelete,
+		baseURL: s.c.userURL,
+		path:    "/api/v1/admin/api-keys/" + id.String(),
+		bearer:  true,
+	}, nil)
+}
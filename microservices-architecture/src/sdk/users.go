@@ -0,0 +1,356 @@
+package sdk
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UserService calls the account endpoints of user service
+type UserService struct {
+	c *Client
+}
+
+type User struct {
+	ID            uuid.UUID `json:"id"`
+	Username      string    `json:"username"`
+	Email         string    `json:"email"`
+	EmailVerified bool      `json:"email_verified"`
+	Role          string    `json:"role"` // user, organizer or admin
+	MFAEnabled    bool      `json:"mfa_enabled"`
+}
+
+type RegisterRequest struct {
+	Username string `json:"username"`
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+// LoginResult is the outcome of a login. When MFARequired is set the user
+// still has to pass LoginMFA with MFAToken; otherwise the client is logged
+// in.
+type LoginResult struct {
+	AccessToken           string `json:"access_token"`
+	RefreshToken          string `json:"refresh_token"`
+	ExpiresIn             int    `json:"expires_in"`
+	MFAEnrollmentRequired bool   `json:"mfa_enrollment_required"`
+	MFARequired           bool   `json:"mfa_required"`
+	MFAToken              string `json:"mfa_token"`
+}
+
+// LoginMFARequest completes a login with either a TOTP code or a recovery
+// code
+type LoginMFARequest struct {
+	MFAToken     string `json:"mfa_token"`
+	Code         string `json:"code,omitempty"`
+	RecoveryCode string `json:"recovery_code,omitempty"`
+}
+
+// Session is a device or application the user is logged in on
+type Session struct {
+	ID         uuid.UUID  `json:"id"`
+	UserAgent  string     `json:"user_agent"`
+	IP         string     `json:"ip"`
+	MFA        bool       `json:"mfa"`
+	Current    bool       `json:"current"` // the session making the request
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+}
+
+// API key scopes
+const (
+	ScopeEventsRead    = "events:read"
+	ScopeBookingsWrite = "bookings:write"
+	ScopePaymentsRead  = "payments:read"
+)
+
+type CreateAPIKeyRequest struct {
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+type APIKey struct {
+	ID           uuid.UUID  `json:"id"`
+	UserID       uuid.UUID  `json:"user_id"`
+	Name         string     `json:"name"`
+	Prefix       string     `json:"prefix"`
+	Key          string     `json:"key,omitempty"` // only returned on creation
+	Scopes       []string   `json:"scopes"`
+	RequestCount int64      `json:"request_count"`
+	LastUsedAt   *time.Time `json:"last_used_at"`
+	ExpiresAt    *time.Time `json:"expires_at"`
+	RevokedAt    *time.Time `json:"revoked_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// APIKeyIntrospection describes the owner and scopes of an API key
+type APIKeyIntrospection struct {
+	KeyID  uuid.UUID `json:"key_id"`
+	Scopes []string  `json:"scopes"`
+	User   User      `json:"user"`
+}
+
+type TOTPEnrollment struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+	QRCode     string `json:"qr_code"` // PNG data URI
+}
+
+// Register creates an account. It does not log in.
+func (s *UserService) Register(ctx context.Context, req RegisterRequest) (*User, error) {
+	var user User
+	err := s.c.do(ctx, request{
+		method:  http.MethodPost,
+		baseURL: s.c.userURL,
+		path:    "/api/v1/users/",
+		body:    req,
+	}, &envelope{Data: &user})
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// Login logs the client in, unless the account requires a second factor
+func (s *UserService) Login(ctx context.Context, username, password string) (*LoginResult, error) {
+	return s.login(ctx, "/api/v1/login", map[string]string{"username": username, "password": password})
+}
+
+// LoginMFA completes a login that returned MFARequired
+func (s *UserService) LoginMFA(ctx context.Context, req LoginMFARequest) (*LoginResult, error) {
+	return s.login(ctx, "/api/v1/login/mfa", req)
+}
+
+func (s *UserService) login(ctx context.Context, path string, body interface{}) (*LoginResult, error) {
+	var result LoginResult
+	err := s.c.do(ctx, request{
+		method:  http.MethodPost,
+		baseURL: s.c.userURL,
+		path:    path,
+		body:    body,
+	}, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	if !result.MFARequired {
+		s.c.SetTokens(Tokens{
+			AccessToken:  result.AccessToken,
+			RefreshToken: result.RefreshToken,
+			ExpiresAt:    expiresAt(result.ExpiresIn),
+		})
+	}
+	return &result, nil
+}
+
+// Refresh renews the access token now. Requests renew it on their own when
+// needed, so this is rarely necessary.
+func (s *UserService) Refresh(ctx context.Context) error {
+	s.c.mu.Lock()
+	defer s.c.mu.Unlock()
+	return s.c.refreshLocked(ctx)
+}
+
+// Logout ends the session on the server and forgets the tokens
+func (s *UserService) Logout(ctx context.Context) error {
+	err := s.c.do(ctx, request{
+		method:  http.MethodPost,
+		baseURL: s.c.userURL,
+		path:    "/api/v1/logout",
+		body:    map[string]string{"refresh_token": s.c.Tokens().RefreshToken},
+		bearer:  true,
+	}, nil)
+	if err != nil {
+		return err
+	}
+	s.c.SetTokens(Tokens{})
+	return nil
+}
+
+// Me returns the logged in user
+func (s *UserService) Me(ctx context.Context) (*User, error) {
+	var user User
+	err := s.c.do(ctx, request{
+		method:  http.MethodGet,
+		baseURL: s.c.userURL,
+		path:    "/api/v1/users/auth",
+		bearer:  true,
+	}, &envelope{Data: &user})
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// VerifyEmail confirms an email address with the token sent to it
+func (s *UserService) VerifyEmail(ctx context.Context, token string) error {
+	return s.c.do(ctx, request{
+		method:  http.MethodPost,
+		baseURL: s.c.userURL,
+		path:    "/api/v1/users/verify-email",
+		body:    map[string]string{"token": token},
+	}, nil)
+}
+
+func (s *UserService) ResendVerification(ctx context.Context) error {
+	return s.c.do(ctx, request{
+		method:  http.MethodPost,
+		baseURL: s.c.userURL,
+		path:    "/api/v1/users/verify-email/resend",
+		bearer:  true,
+	}, nil)
+}
+
+// ForgotPassword sends a reset link to the account with the given email
+// address or username. It succeeds whether or not the account exists.
+func (s *UserService) ForgotPassword(ctx context.Context, emailOrUsername string) error {
+	return s.c.do(ctx, request{
+		method:  http.MethodPost,
+		baseURL: s.c.userURL,
+		path:    "/api/v1/password/forgot",
+		body:    map[string]string{"email": emailOrUsername},
+	}, nil)
+}
+
+// ResetPassword sets a new password with the token from the reset link
+func (s *UserService) ResetPassword(ctx context.Context, token, password string) error {
+	return s.c.do(ctx, request{
+		method:  http.MethodPost,
+		baseURL: s.c.userURL,
+		path:    "/api/v1/password/reset",
+		body:    map[string]string{"token": token, "password": password},
+	}, nil)
+}
+
+// ChangePassword signs out every other session of the user
+func (s *UserService) ChangePassword(ctx context.Context, currentPassword, newPassword string) error {
+	return s.c.do(ctx, request{
+		method:  http.MethodPost,
+		baseURL: s.c.userURL,
+		path:    "/api/v1/users/me/password",
+		body:    map[string]string{"current_password": currentPassword, "new_password": newPassword},
+		bearer:  true,
+	}, nil)
+}
+
+func (s *UserService) ListSessions(ctx context.Context) ([]Session, error) {
+	var sessions []Session
+	err := s.c.do(ctx, request{
+		method:  http.MethodGet,
+		baseURL: s.c.userURL,
+		path:    "/api/v1/users/me/sessions/",
+		bearer:  true,
+	}, &envelope{Data: &sessions})
+	return sessions, err
+}
+
+func (s *UserService) RevokeSession(ctx context.Context, id uuid.UUID) error {
+	return s.c.do(ctx, request{
+		method:  http.MethodDelete,
+		baseURL: s.c.userURL,
+		path:    "/api/v1/users/me/sessions/" + id.String(),
+		bearer:  true,
+	}, nil)
+}
+
+// RevokeAllSessions signs the user out everywhere, including this client
+func (s *UserService) RevokeAllSessions(ctx context.Context) error {
+	return s.c.do(ctx, request{
+		method:  http.MethodPost,
+		baseURL: s.c.userURL,
+		path:    "/api/v1/users/me/sessions/revoke-all",
+		bearer:  true,
+	}, nil)
+}
+
+// CreateAPIKey returns the new key with its secret in Key, which cannot be
+// retrieved again
+func (s *UserService) CreateAPIKey(ctx context.Context, req CreateAPIKeyRequest) (*APIKey, error) {
+	var key APIKey
+	err := s.c.do(ctx, request{
+		method:  http.MethodPost,
+		baseURL: s.c.userURL,
+		path:    "/api/v1/users/me/api-keys/",
+		body:    req,
+		bearer:  true,
+	}, &envelope{Data: &key})
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (s *UserService) ListAPIKeys(ctx context.Context) ([]APIKey, error) {
+	var keys []APIKey
+	err := s.c.do(ctx, request{
+		method:  http.MethodGet,
+		baseURL: s.c.userURL,
+		path:    "/api/v1/users/me/api-keys/",
+		bearer:  true,
+	}, &envelope{Data: &keys})
+	return keys, err
+}
+
+func (s *UserService) RevokeAPIKey(ctx context.Context, id uuid.UUID) error {
+	return s.c.do(ctx, request{
+		method:  http.MethodDelete,
+		baseURL: s.c.userURL,
+		path:    "/api/v1/users/me/api-keys/" + id.String(),
+		bearer:  true,
+	}, nil)
+}
+
+// BeginTOTPEnrollment returns the secret to add to an authenticator app.
+// Two-factor authentication is enabled once ConfirmTOTPEnrollment succeeds.
+func (s *UserService) BeginTOTPEnrollment(ctx context.Context) (*TOTPEnrollment, error) {
+	var enrollment TOTPEnrollment
+	err := s.c.do(ctx, request{
+		method:  http.MethodPost,
+		baseURL: s.c.userURL,
+		path:    "/api/v1/users/me/mfa/totp",
+		bearer:  true,
+	}, &envelope{Data: &enrollment})
+	if err != nil {
+		return nil, err
+	}
+	return &enrollment, nil
+}
+
+// ConfirmTOTPEnrollment enables two-factor authentication and returns the
+// recovery codes, which cannot be retrieved again
+func (s *UserService) ConfirmTOTPEnrollment(ctx context.Context, code string) ([]string, error) {
+	return s.recoveryCodes(ctx, "/api/v1/users/me/mfa/totp/confirm", code)
+}
+
+func (s *UserService) DisableTOTP(ctx context.Context, code string) error {
+	return s.c.do(ctx, request{
+		method:  http.MethodDelete,
+		baseURL: s.c.userURL,
+		path:    "/api/v1/users/me/mfa/totp",
+		body:    map[string]string{"code": code},
+		bearer:  true,
+	}, nil)
+}
+
+// RegenerateRecoveryCodes replaces every recovery code of the user
+func (s *UserService) RegenerateRecoveryCodes(ctx context.Context, code string) ([]string, error) {
+	return s.recoveryCodes(ctx, "/api/v1/users/me/mfa/recovery-codes", code)
+}
+
+func (s *UserService) recoveryCodes(ctx context.Context, path, code string) ([]string, error) {
+	var resp struct {
+		RecoveryCodes []string `json:"recovery_codes"`
+	}
+	err := s.c.do(ctx, request{
+		method:  http.MethodPost,
+		baseURL: s.c.userURL,
+		path:    path,
+		body:    map[string]string{"code": code},
+		bearer:  true,
+	}, &resp)
+	return resp.RecoveryCodes, err
+}
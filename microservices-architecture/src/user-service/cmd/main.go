@@ -1,70 +1,295 @@
 package main
 
 import (
-	"log"
+	"context"
+	"log/slog"
+	"net"
+	"os"
+	"time"
 	"user-service/config"
+	"user-service/internal/auth"
+	"user-service/internal/grpcapi"
 	"user-service/internal/handler"
+	"user-service/internal/mailer"
+	"user-service/internal/metrics"
 	"user-service/internal/middleware"
+	"user-service/internal/model"
 	"user-service/internal/repository"
 	"user-service/internal/service"
 	"user-service/migrations"
 
+	"platform/health"
+	"platform/logging"
+	"platform/tracing"
+
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
-	"github.com/gofiber/fiber/v2/middleware/logger"
 )
 
 func main() {
+	// Log JSON lines at LOG_LEVEL
+	logging.Setup("user-service")
+
+	ctx := context.Background()
+
+	// Trace requests, queries and calls to OTEL_TRACES_EXPORTER (default none)
+	shutdownTracing, err := tracing.Setup(ctx, "user-service")
+	if err != nil {
+		logging.Fatal("Failed to set up tracing", "error", err)
+	}
+	defer shutdownTracing(context.Background())
+
+	// id_tokens and OAuth access tokens are signed with the key at
+	// OIDC_SIGNING_KEY_PATH
+	if err := auth.LoadSigningKey(); err != nil {
+		logging.Fatal("Failed to load OIDC signing key", "error", err)
+	}
+
 	// Connect to database
 	config.ConnectDatabase()
 
 	// Run migrations
 	migrations.RunMigrations(config.DB)
 
+	if err := config.DB.Use(tracing.GormPlugin()); err != nil {
+		logging.Fatal("Failed to trace database", "error", err)
+	}
+
+	// Expose metrics on /metrics unless METRICS_ENABLED=false
+	metricsEnabled := metrics.Enabled()
+	if metricsEnabled {
+		if err := config.DB.Use(metrics.GormPlugin()); err != nil {
+			logging.Fatal("Failed to instrument database", "error", err)
+		}
+	}
+
 	// Initialize repositories
 	userRepo := repository.NewUserRepository(config.DB)
 	refreshTokenRepo := repository.NewRefreshTokenRepository(config.DB)
+	passwordResetRepo := repository.NewPasswordResetTokenRepository(config.DB)
+	emailVerifyRepo := repository.NewEmailVerificationTokenRepository(config.DB)
+	loginFailureRepo := repository.NewLoginFailureRepository(config.DB)
+	lockEventRepo := repository.NewAccountLockEventRepository(config.DB)
+	recoveryCodeRepo := repository.NewRecoveryCodeRepository(config.DB)
+	revokedTokenRepo := repository.NewRevokedTokenRepository(config.DB)
+	oauthClientRepo := repository.NewOAuthClientRepository(config.DB)
+	oauthCodeRepo := repository.NewOAuthCodeRepository(config.DB)
+	oauthConsentRepo := repository.NewOAuthConsentRepository(config.DB)
+	apiKeyRepo := repository.NewAPIKeyRepository(config.DB)
+	authEventRepo := repository.NewAuthEventRepository(config.DB)
+
+	// Initialize mailer
+	mail := mailer.NewMailer()
 
-	// Initialize service and handler
-	userService := service.NewUserService(userRepo, refreshTokenRepo)
+	// Initialize password hashing and policy
+	passwordHasher := auth.NewPasswordHasher(auth.LoadArgon2Params())
+	passwordPolicy := service.LoadPasswordPolicy()
+
+	// Initialize services and handlers
+	authEventService := service.NewAuthEventService(authEventRepo)
+	revocationService := service.NewRevocationService(ctx, revokedTokenRepo)
+	loginGuard := service.NewLoginGuard(service.LoadLoginGuardConfig(), userRepo, loginFailureRepo, lockEventRepo, authEventService)
+	mfaService := service.NewMFAService(userRepo, recoveryCodeRepo)
+	userService := service.NewUserService(config.DB, userRepo, refreshTokenRepo, emailVerifyRepo, loginGuard, mfaService, revocationService, passwordHasher, passwordPolicy, authEventService, mail)
+	sessionService := service.NewSessionService(userRepo, refreshTokenRepo, revocationService)
+	passwordService := service.NewPasswordService(config.DB, userRepo, sessionService, passwordResetRepo, passwordHasher, passwordPolicy, authEventService, mail)
+	oauthService := service.NewOAuthService(userRepo, refreshTokenRepo, oauthClientRepo, oauthCodeRepo, oauthConsentRepo, authEventService)
+	apiKeyService := service.NewAPIKeyService(userRepo, apiKeyRepo)
+	adminUserService := service.NewAdminUserService(userRepo, refreshTokenRepo, sessionService, authEventService)
 	userHandler := handler.NewUserHandler(userService)
+	passwordHandler := handler.NewPasswordHandler(passwordService)
+	adminHandler := handler.NewAdminHandler(loginGuard)
+	adminUserHandler := handler.NewAdminUserHandler(adminUserService)
+	mfaHandler := handler.NewMFAHandler(mfaService)
+	sessionHandler := handler.NewSessionHandler(sessionService)
+	revocationHandler := handler.NewRevocationHandler(revocationService)
+	oauthHandler := handler.NewOAuthHandler(oauthService, userService, revocationService)
+	apiKeyHandler := handler.NewAPIKeyHandler(apiKeyService)
+	authEventHandler := handler.NewAuthEventHandler(authEventService)
+	userAuthServer := grpcapi.NewUserAuthServer(userService, apiKeyService, revocationService)
+
+	// Pick up tokens revoked on other replicas
+	revocationService.StartSync(ctx, 5*time.Second)
+
+	// Periodically drop login failures that have left the counting window,
+	// authorization codes, password reset and email verification tokens that
+	// were never redeemed and audit log entries past their retention
+	go func() {
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := loginGuard.Cleanup(ctx); err != nil {
+				slog.Error("Failed to clean up login failures", "error", err)
+			}
+			if err := oauthCodeRepo.DeleteExpired(ctx); err != nil {
+				slog.Error("Failed to clean up authorization codes", "error", err)
+			}
+			if err := passwordResetRepo.DeleteExpired(ctx); err != nil {
+				slog.Error("Failed to clean up password reset tokens", "error", err)
+			}
+			if err := emailVerifyRepo.DeleteExpired(ctx); err != nil {
+				slog.Error("Failed to clean up email verification tokens", "error", err)
+			}
+			if err := authEventService.Cleanup(ctx); err != nil {
+				slog.Error("Failed to clean up auth events", "error", err)
+			}
+		}
+	}()
+
+	// Readiness checks the database
+	healthConfig := health.LoadConfig()
+	checker := health.NewChecker("user-service", healthConfig)
+	checker.Add("database", health.Database(config.DB, healthConfig.PoolSaturation))
 
 	// Initialize Fiber app
 	app := fiber.New(fiber.Config{
-		AppName: "User Service",
+		AppName:               "User Service",
+		DisableStartupMessage: true, // "Server starting" is logged instead
+		// Requests arrive through the gateway, which passes the client
+		// address on in X-Forwarded-For
+		ProxyHeader:             fiber.HeaderXForwardedFor,
+		EnableTrustedProxyCheck: true,
+		TrustedProxies:          config.TrustedProxies(),
+		EnableIPValidation:      true,
 	})
 
 	// Middleware
-	app.Use(logger.New())
+	app.Use(middleware.RequestID())
+	app.Use(tracing.HTTP())
+	app.Use(middleware.AccessLog())
+	if metricsEnabled {
+		app.Use(metrics.HTTP())
+		app.Get("/metrics", metrics.Handler())
+	}
 	app.Use(cors.New())
+	app.Use(middleware.Deadline("REQUEST_TIMEOUT", 10*time.Second))
+	authMiddleware := middleware.AuthMiddleware(revocationService)
+
+	// Logins hash passwords and may wait on the lockout bookkeeping
+	loginDeadline := middleware.Deadline("REQUEST_TIMEOUT_LOGIN", 15*time.Second)
 
 	// Routes
 	api := app.Group("/api/v1")
 
 	// Public routes (no authentication required)
-	api.Post("/login", userHandler.Login)
+	api.Post("/login", loginDeadline, userHandler.Login)
+	api.Post("/login/mfa", loginDeadline, userHandler.LoginMFA)
 	api.Post("/refresh", userHandler.RefreshToken)
 	api.Post("/logout", userHandler.Logout)
 
+	// Access token denylist feed for services that verify tokens locally
+	api.Get("/auth/revocations", middleware.RequireServiceToken("revocations:read"), revocationHandler.GetRevocations)
+
+	// Resolves X-API-Key headers presented to booking and payment service
+	api.Get("/api-keys/introspect", middleware.RequireServiceToken("api-keys:introspect"), apiKeyHandler.Introspect)
+
+	// OAuth2 / OpenID Connect provider
+	oauth := app.Group(handler.OAuthBasePath)
+	oauth.Get("/authorize", oauthHandler.Authorize)
+	oauth.Post("/login", loginDeadline, oauthHandler.Login)
+	oauth.Post("/consent", oauthHandler.Consent)
+	oauth.Post("/token", oauthHandler.Token)
+	oauth.Get("/userinfo", middleware.OAuthMiddleware(revocationService, service.ScopeOpenID), oauthHandler.UserInfo)
+	app.Get("/.well-known/openid-configuration", oauthHandler.OpenIDConfiguration)
+	app.Get("/.well-known/jwks.json", oauthHandler.JWKS)
+
+	// Password reset routes
+	password := api.Group("/password")
+	password.Post("/forgot", passwordHandler.ForgotPassword)
+	password.Post("/reset", passwordHandler.ResetPassword)
+
 	// User routes
 	users := api.Group("/users")
 	users.Post("/", userHandler.CreateUser)
+	users.Post("/verify-email", userHandler.VerifyEmail)
 
 	// Protected routes (authentication required)
-	users.Get("/auth", middleware.AuthMiddleware(), userHandler.GetAuthenticatedUser)
-
-	// Health check
-	app.Get("/health", func(c *fiber.Ctx) error {
-		return c.JSON(fiber.Map{
-			"status":  "ok",
-			"service": "user-service",
-		})
+	users.Get("/auth", authMiddleware, userHandler.GetAuthenticatedUser)
+	users.Post("/verify-email/resend", authMiddleware, userHandler.ResendVerification)
+	users.Post("/me/password", authMiddleware, passwordHandler.ChangePassword)
+
+	// Session routes
+	sessions := users.Group("/me/sessions", authMiddleware)
+	sessions.Get("/", sessionHandler.GetMySessions)
+	sessions.Post("/revoke-all", sessionHandler.RevokeAllMySessions)
+	sessions.Delete("/:id", sessionHandler.RevokeMySession)
+
+	// API key routes
+	apiKeys := users.Group("/me/api-keys", authMiddleware)
+	apiKeys.Post("/", apiKeyHandler.CreateAPIKey)
+	apiKeys.Get("/", apiKeyHandler.GetMyAPIKeys)
+	apiKeys.Delete("/:id", apiKeyHandler.RevokeMyAPIKey)
+
+	// Two-factor authentication routes
+	mfa := users.Group("/me/mfa", authMiddleware)
+	mfa.Post("/totp", mfaHandler.BeginTOTPEnrollment)
+	mfa.Post("/totp/confirm", mfaHandler.ConfirmTOTPEnrollment)
+	mfa.Delete("/totp", mfaHandler.DisableTOTP)
+	mfa.Post("/recovery-codes", mfaHandler.RegenerateRecoveryCodes)
+
+	// Admin routes
+	admin := api.Group("/admin",
+		authMiddleware,
+		middleware.RequireRole(model.RoleAdmin),
+		middleware.RequireMFAForRoles(service.MFARequiredRoles()...),
+	)
+	admin.Get("/users", adminUserHandler.GetUsers)
+	admin.Get("/users/:id", adminUserHandler.GetUser)
+	admin.Delete("/users/:id", adminUserHandler.DeleteUser)
+	admin.Post("/users/:id/disable", adminUserHandler.DisableUser)
+	admin.Post("/users/:id/enable", adminUserHandler.EnableUser)
+	admin.Post("/users/:id/restore", adminUserHandler.RestoreUser)
+	admin.Put("/users/:id/role", adminUserHandler.SetRole)
+	admin.Post("/users/:id/unlock", adminHandler.UnlockUser)
+	admin.Get("/lock-events", adminHandler.GetLockEvents)
+	admin.Get("/auth-events", middleware.Deadline("REQUEST_TIMEOUT_AUTH_EVENTS", 5*time.Minute), authEventHandler.GetAuthEvents)
+	admin.Get("/users/:id/sessions", sessionHandler.GetUserSessions)
+	admin.Delete("/users/:id/sessions", sessionHandler.RevokeAllUserSessions)
+	admin.Delete("/users/:id/sessions/:sessionId", sessionHandler.RevokeUserSession)
+	admin.Post("/oauth/clients", oauthHandler.RegisterClient)
+	admin.Get("/oauth/clients", oauthHandler.GetClients)
+	admin.Delete("/oauth/clients/:clientId", oauthHandler.DeleteClient)
+	admin.Get("/api-keys", apiKeyHandler.GetAPIKeys)
+	admin.Delete("/api-keys/:id", apiKeyHandler.RevokeAPIKey)
+
+	// Probes: /livez while the process is up, /readyz while its dependencies
+	// are usable. /health is kept for existing callers.
+	app.Get("/livez", checker.Livez)
+	app.Get("/readyz", checker.Readyz)
+	app.Get("/health", checker.Readyz)
+
+	// Start the internal gRPC API on its own port
+	grpcPort := os.Getenv("GRPC_PORT")
+	if grpcPort == "" {
+		grpcPort = "50051"
+	}
+	grpcListener, err := net.Listen("tcp", ":"+grpcPort)
+	if err != nil {
+		logging.Fatal("Failed to listen for gRPC", "error", err)
+	}
+	grpcServer := grpcapi.NewServer(userAuthServer)
+	go func() {
+		slog.Info("gRPC server starting", "port", grpcPort)
+		if err := grpcServer.Serve(grpcListener); err != nil {
+			logging.Fatal("Failed to start gRPC server", "error", err)
+		}
+	}()
+
+	// On SIGINT or SIGTERM, report not ready, give load balancers time to
+	// drain the service and finish the requests in flight
+	stopped := checker.OnShutdown(func(ctx context.Context) {
+		if err := app.ShutdownWithContext(ctx); err != nil {
+			slog.Error("Failed to shut down server", "error", err)
+		}
+		grpcServer.GracefulStop()
 	})
 
 	// Start server
 	port := ":3001"
-	log.Printf("Server starting on port %s", port)
+	slog.Info("Server starting", "port", port)
 	if err := app.Listen(port); err != nil {
-		log.Fatal("Failed to start server:", err)
+		logging.Fatal("Failed to start server", "error", err)
 	}
+	<-stopped
+	slog.Info("Server stopped")
 }
@@ -2,10 +2,12 @@ package auth
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/hex"
 	"errors"
 	"os"
 	"time"
+	"user-service/internal/model"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
@@ -17,13 +19,32 @@ var (
 )
 
 const (
-	AccessTokenExpiry  = 15 * time.Minute // 15 minutes
+	AccessTokenExpiry  = 15 * time.Minute   // 15 minutes
 	RefreshTokenExpiry = 7 * 24 * time.Hour // 7 days
+	ResetTokenExpiry   = 30 * time.Minute   // 30 minutes
+	VerifyTokenExpiry  = 24 * time.Hour     // 24 hours
+	MFATokenExpiry     = 5 * time.Minute    // 5 minutes
+	OAuthSessionExpiry = 10 * time.Minute   // 10 minutes
+	OAuthCodeExpiry    = 1 * time.Minute    // 1 minute
+	IDTokenExpiry      = 1 * time.Hour      // 1 hour
+	ServiceTokenExpiry = 10 * time.Minute   // 10 minutes
+)
+
+const (
+	// PurposeMFAChallenge marks tokens that only allow completing an MFA login
+	PurposeMFAChallenge = "mfa_challenge"
+	// PurposeOAuthSession marks the cookie that remembers a login on the authorize page
+	PurposeOAuthSession = "oauth_session"
 )
 
 type Claims struct {
-	UserID   string `json:"user_id"`
-	Username string `json:"username"`
+	UserID        string `json:"user_id"`
+	Username      string `json:"username"`
+	EmailVerified bool   `json:"email_verified"`
+	Role          string `json:"role"`
+	MFA           bool   `json:"mfa"`
+	SessionID     string `json:"sid,omitempty"`
+	Purpose       string `json:"purpose,omitempty"`
 	jwt.RegisteredClaims
 }
 
@@ -35,17 +56,69 @@ func getJWTSecret() []byte {
 	return []byte(secret)
 }
 
-// GenerateAccessToken creates a new JWT access token
-func GenerateAccessToken(userID uuid.UUID, username string) (string, error) {
+// GenerateAccessToken creates a new JWT access token for the session
+// represented by the given refresh token
+func GenerateAccessToken(user *model.User, session *model.RefreshToken) (string, error) {
 	claims := &Claims{
-		UserID:   userID.String(),
-		Username: username,
+		UserID:        user.ID.String(),
+		Username:      user.Username,
+		EmailVerified: user.IsEmailVerified(),
+		Role:          user.Role,
+		MFA:           session.MFA,
+		SessionID:     session.ID.String(),
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.New().String(),
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(AccessTokenExpiry)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			NotBefore: jwt.NewNumericDate(time.Now()),
 			Issuer:    "user-service",
-			Subject:   userID.String(),
+			Subject:   user.ID.String(),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(getJWTSecret())
+}
+
+// GenerateMFAChallengeToken creates a short-lived token proving the password
+// step succeeded. It cannot be used as an access token.
+func GenerateMFAChallengeToken(user *model.User) (string, error) {
+	claims := &Claims{
+		UserID:  user.ID.String(),
+		Purpose: PurposeMFAChallenge,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(MFATokenExpiry)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			NotBefore: jwt.NewNumericDate(time.Now()),
+			Issuer:    "user-service",
+			Subject:   user.ID.String(),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(getJWTSecret())
+}
+
+// ValidateMFAChallengeToken validates a token issued by GenerateMFAChallengeToken
+func ValidateMFAChallengeToken(tokenString string) (*Claims, error) {
+	return validatePurposeToken(tokenString, PurposeMFAChallenge)
+}
+
+// GenerateOAuthSessionToken creates the value of the authorize page login
+// cookie for the given session, which can then be revoked like any other
+func GenerateOAuthSessionToken(user *model.User, session *model.RefreshToken) (string, error) {
+	claims := &Claims{
+		UserID:    user.ID.String(),
+		MFA:       session.MFA,
+		SessionID: session.ID.String(),
+		Purpose:   PurposeOAuthSession,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.New().String(),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(OAuthSessionExpiry)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			NotBefore: jwt.NewNumericDate(time.Now()),
+			Issuer:    "user-service",
+			Subject:   user.ID.String(),
 		},
 	}
 
@@ -53,17 +126,72 @@ func GenerateAccessToken(userID uuid.UUID, username string) (string, error) {
 	return token.SignedString(getJWTSecret())
 }
 
+// ValidateOAuthSessionToken validates a token issued by GenerateOAuthSessionToken
+func ValidateOAuthSessionToken(tokenString string) (*Claims, error) {
+	return validatePurposeToken(tokenString, PurposeOAuthSession)
+}
+
+func validatePurposeToken(tokenString, purpose string) (*Claims, error) {
+	claims, err := parseToken(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Purpose != purpose {
+		return nil, ErrInvalidToken
+	}
+	return claims, nil
+}
+
 // GenerateRefreshToken creates a random refresh token
 func GenerateRefreshToken() (string, error) {
-	bytes := make([]byte, 32)
+	return generateRandomToken(32)
+}
+
+// GenerateResetToken creates a random password reset token
+func GenerateResetToken() (string, error) {
+	return generateRandomToken(32)
+}
+
+// GenerateOpaqueToken creates a random token for OAuth codes and client secrets
+func GenerateOpaqueToken() (string, error) {
+	return generateRandomToken(32)
+}
+
+// GenerateVerifyToken creates a random email verification token
+func GenerateVerifyToken() (string, error) {
+	return generateRandomToken(32)
+}
+
+// HashToken returns the hex encoded SHA-256 digest of an opaque token,
+// so that only the digest needs to be stored
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func generateRandomToken(size int) (string, error) {
+	bytes := make([]byte, size)
 	if _, err := rand.Read(bytes); err != nil {
 		return "", err
 	}
 	return hex.EncodeToString(bytes), nil
 }
 
-// ValidateAccessToken validates and parses the JWT access token
+// ValidateAccessToken validates and parses the JWT access token. OAuth
+// access tokens are RS256 and are rejected here.
 func ValidateAccessToken(tokenString string) (*Claims, error) {
+	claims, err := parseToken(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	// Purpose-bound tokens such as MFA challenges are not access tokens
+	if claims.Purpose != "" {
+		return nil, ErrInvalidToken
+	}
+	return claims, nil
+}
+
+func parseToken(tokenString string) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, ErrInvalidToken
@@ -96,3 +224,12 @@ func GetRefreshTokenExpiry() time.Time {
 	return time.Now().Add(RefreshTokenExpiry)
 }
 
+// GetVerifyTokenExpiry returns the expiry time for a new email verification token
+func GetVerifyTokenExpiry() time.Time {
+	return time.Now().Add(VerifyTokenExpiry)
+}
+
+// GetResetTokenExpiry returns the expiry time for a new password reset token
+func GetResetTokenExpiry() time.Time {
+	return time.Now().Add(ResetTokenExpiry)
+}
@@ -0,0 +1,237 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"os"
+	"time"
+	"user-service/internal/model"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// JWK is the public part of an RSA signing key in JSON Web Key format
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+type signingKey struct {
+	key *rsa.PrivateKey
+	kid string
+}
+
+// oidcKey is set by LoadSigningKey at startup
+var oidcKey *signingKey
+
+var errSigningKeyNotLoaded = errors.New("OIDC signing key is not loaded")
+
+// LoadSigningKey loads the RSA key used for id_tokens and OAuth access
+// tokens from the PEM file at OIDC_SIGNING_KEY_PATH. Call it once at
+// startup. Only with OIDC_EPHEMERAL_KEY=true, meant for local development,
+// is a key generated when none is configured: it invalidates every issued
+// token on restart and differs between replicas.
+func LoadSigningKey() error {
+	key, err := loadRSAKey(os.Getenv("OIDC_SIGNING_KEY_PATH"))
+	if err != nil {
+		if os.Getenv("OIDC_EPHEMERAL_KEY") != "true" {
+			return err
+		}
+		slog.Warn("OIDC signing key not loaded, generating an ephemeral key", "error", err)
+		key, err = rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			return fmt.Errorf("failed to generate OIDC signing key: %w", err)
+		}
+	}
+
+	sum := sha256.Sum256(key.PublicKey.N.Bytes())
+	oidcKey = &signingKey{
+		key: key,
+		kid: base64.RawURLEncoding.EncodeToString(sum[:8]),
+	}
+	return nil
+}
+
+func loadRSAKey(path string) (*rsa.PrivateKey, error) {
+	if path == "" {
+		return nil, errors.New("OIDC_SIGNING_KEY_PATH is not set")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("signing key is not an RSA key")
+	}
+	return key, nil
+}
+
+// SignIDToken signs OIDC id_token claims with RS256
+func SignIDToken(claims jwt.MapClaims) (string, error) {
+	return signRS256(claims, "JWT")
+}
+
+// SignServiceToken signs client credentials access token claims with RS256.
+// The at+jwt type (RFC 9068) keeps id_tokens from being accepted as access
+// tokens by resource servers, since both share the signing key.
+func SignServiceToken(claims jwt.MapClaims) (string, error) {
+	return signRS256(claims, "at+jwt")
+}
+
+// OAuthClaims are the claims of an access token issued to an OAuth client on
+// behalf of a user. The audience is the client and scope holds the scopes
+// the user granted it.
+type OAuthClaims struct {
+	ClientID  string `json:"client_id"`
+	Scope     string `json:"scope"`
+	SessionID string `json:"sid"`
+	jwt.RegisteredClaims
+}
+
+// GenerateOAuthAccessToken creates an RS256 access token for the OAuth
+// session. It is not signed with JWT_SECRET, so the gateway and the
+// first-party AuthMiddleware reject it; only routes that check its scopes
+// accept it.
+func GenerateOAuthAccessToken(issuer string, session *model.RefreshToken) (string, error) {
+	now := time.Now()
+	return signRS256(&OAuthClaims{
+		ClientID:  session.ClientID,
+		Scope:     session.Scope,
+		SessionID: session.ID.String(),
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.New().String(),
+			Issuer:    issuer,
+			Subject:   session.UserID.String(),
+			Audience:  jwt.ClaimStrings{session.ClientID},
+			ExpiresAt: jwt.NewNumericDate(now.Add(AccessTokenExpiry)),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	}, "at+jwt")
+}
+
+// ValidateOAuthAccessToken verifies an access token issued by
+// GenerateOAuthAccessToken. Service tokens share its type and key but have
+// no session, so they are turned away.
+func ValidateOAuthAccessToken(tokenString, issuer string) (*OAuthClaims, error) {
+	claims := &OAuthClaims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		if typ, _ := token.Header["typ"].(string); typ != "at+jwt" {
+			return nil, ErrInvalidToken
+		}
+		if oidcKey == nil {
+			return nil, errSigningKeyNotLoaded
+		}
+		return &oidcKey.key.PublicKey, nil
+	},
+		jwt.WithValidMethods([]string{"RS256"}),
+		jwt.WithIssuer(issuer),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return nil, ErrExpiredToken
+		}
+		return nil, ErrInvalidToken
+	}
+	if !token.Valid || claims.SessionID == "" || claims.ClientID == "" || !containsAudience(claims.Audience, claims.ClientID) {
+		return nil, ErrInvalidToken
+	}
+	return claims, nil
+}
+
+func containsAudience(audience jwt.ClaimStrings, value string) bool {
+	for _, a := range audience {
+		if a == value {
+			return true
+		}
+	}
+	return false
+}
+
+// ServiceClaims are the claims of a client credentials access token
+type ServiceClaims struct {
+	ClientID string `json:"client_id"`
+	Scope    string `json:"scope"`
+	jwt.RegisteredClaims
+}
+
+// ValidateServiceToken verifies a client credentials access token issued by
+// this service for audience. Other services check the same token against the
+// JWKS; here the signing key is at hand.
+func ValidateServiceToken(tokenString, issuer, audience string) (*ServiceClaims, error) {
+	claims := &ServiceClaims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		// id_tokens share the signing key, only access tokens are accepted
+		if typ, _ := token.Header["typ"].(string); typ != "at+jwt" {
+			return nil, ErrInvalidToken
+		}
+		if oidcKey == nil {
+			return nil, errSigningKeyNotLoaded
+		}
+		return &oidcKey.key.PublicKey, nil
+	},
+		jwt.WithValidMethods([]string{"RS256"}),
+		jwt.WithIssuer(issuer),
+		jwt.WithAudience(audience),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil || !token.Valid {
+		return nil, ErrInvalidToken
+	}
+	return claims, nil
+}
+
+func signRS256(claims jwt.Claims, typ string) (string, error) {
+	if oidcKey == nil {
+		return "", errSigningKeyNotLoaded
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = oidcKey.kid
+	token.Header["typ"] = typ
+	return token.SignedString(oidcKey.key)
+}
+
+// PublicJWKs returns the JSON Web Key Set for verifying id_tokens
+func PublicJWKs() []JWK {
+	key := oidcKey
+	if key == nil {
+		return nil
+	}
+	return []JWK{{
+		Kty: "RSA",
+		Use: "sig",
+		Alg: "RS256",
+		Kid: key.kid,
+		N:   base64.RawURLEncoding.EncodeToString(key.key.PublicKey.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.key.PublicKey.E)).Bytes()),
+	}}
+}
@@ -0,0 +1,156 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ErrUnknownHashFormat is returned for stored hashes no hasher recognises
+var ErrUnknownHashFormat = errors.New("unknown password hash format")
+
+// PasswordHasher hashes new passwords and verifies stored hashes
+type PasswordHasher interface {
+	Hash(password string) (string, error)
+
+	// Verify reports whether password matches encoded. needsRehash is set
+	// when the hash uses an older algorithm or weaker parameters and should
+	// be replaced with Hash(password).
+	Verify(password, encoded string) (ok bool, needsRehash bool, err error)
+}
+
+// Argon2Params tunes argon2id. Memory is in KiB.
+type Argon2Params struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// LoadArgon2Params reads ARGON2_MEMORY, ARGON2_ITERATIONS and
+// ARGON2_PARALLELISM, defaulting to the RFC 9106 second recommended option
+func LoadArgon2Params() Argon2Params {
+	return Argon2Params{
+		Memory:      uint32(envUint("ARGON2_MEMORY", 64*1024, 32)),
+		Iterations:  uint32(envUint("ARGON2_ITERATIONS", 3, 32)),
+		Parallelism: uint8(envUint("ARGON2_PARALLELISM", 2, 8)),
+		SaltLength:  16,
+		KeyLength:   32,
+	}
+}
+
+type argon2Hasher struct {
+	params Argon2Params
+}
+
+// NewPasswordHasher hashes with argon2id in PHC string format. bcrypt hashes
+// from before the switch still verify and are flagged for rehashing.
+func NewPasswordHasher(params Argon2Params) PasswordHasher {
+	return &argon2Hasher{params: params}
+}
+
+func (h *argon2Hasher) Hash(password string) (string, error) {
+	salt := make([]byte, h.params.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, h.params.Iterations, h.params.Memory, h.params.Parallelism, h.params.KeyLength)
+
+	return fmt.Sprintf(
+		"$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version,
+		h.params.Memory, h.params.Iterations, h.params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+func (h *argon2Hasher) Verify(password, encoded string) (bool, bool, error) {
+	if isBcryptHash(encoded) {
+		err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return false, false, nil
+		}
+		if err != nil {
+			return false, false, err
+		}
+		return true, true, nil
+	}
+
+	params, salt, key, err := decodeArgon2Hash(encoded)
+	if err != nil {
+		return false, false, err
+	}
+
+	candidate := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, uint32(len(key)))
+	if subtle.ConstantTimeCompare(candidate, key) != 1 {
+		return false, false, nil
+	}
+
+	needsRehash := params.Memory != h.params.Memory ||
+		params.Iterations != h.params.Iterations ||
+		params.Parallelism != h.params.Parallelism ||
+		uint32(len(key)) != h.params.KeyLength
+
+	return true, needsRehash, nil
+}
+
+func isBcryptHash(encoded string) bool {
+	return strings.HasPrefix(encoded, "$2a$") ||
+		strings.HasPrefix(encoded, "$2b$") ||
+		strings.HasPrefix(encoded, "$2y$")
+}
+
+// decodeArgon2Hash parses $argon2id$v=19$m=65536,t=3,p=2$<salt>$<hash>
+func decodeArgon2Hash(encoded string) (Argon2Params, []byte, []byte, error) {
+	var params Argon2Params
+
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return params, nil, nil, ErrUnknownHashFormat
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return params, nil, nil, ErrUnknownHashFormat
+	}
+
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return params, nil, nil, ErrUnknownHashFormat
+	}
+	if params.Iterations == 0 || params.Parallelism == 0 {
+		return params, nil, nil, ErrUnknownHashFormat
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, ErrUnknownHashFormat
+	}
+
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return params, nil, nil, ErrUnknownHashFormat
+	}
+
+	params.SaltLength = uint32(len(salt))
+	params.KeyLength = uint32(len(key))
+	return params, salt, key, nil
+}
+
+func envUint(key string, defaultValue uint64, bits int) uint64 {
+	value, err := strconv.ParseUint(os.Getenv(key), 10, bits)
+	if err != nil || value == 0 {
+		return defaultValue
+	}
+	return value
+}
@@ -0,0 +1,68 @@
+package auth
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"os"
+)
+
+// getEncryptionKey derives the AES-256 key used for secrets at rest.
+// MFA_ENCRYPTION_KEY is preferred; JWT_SECRET is used as a fallback.
+func getEncryptionKey() []byte {
+	secret := os.Getenv("MFA_ENCRYPTION_KEY")
+	if secret == "" {
+		secret = string(getJWTSecret())
+	}
+	key := sha256.Sum256([]byte(secret))
+	return key[:]
+}
+
+// EncryptSecret encrypts plaintext with AES-GCM and returns base64 output
+func EncryptSecret(plaintext string) (string, error) {
+	gcm, err := newGCM()
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// DecryptSecret reverses EncryptSecret
+func DecryptSecret(ciphertext string) (string, error) {
+	gcm, err := newGCM()
+	if err != nil {
+		return "", err
+	}
+
+	data, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return "", err
+	}
+	if len(data) < gcm.NonceSize() {
+		return "", errors.New("ciphertext too short")
+	}
+
+	plaintext, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+func newGCM() (cipher.AEAD, error) {
+	block, err := aes.NewCipher(getEncryptionKey())
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,111 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"user-service/internal/auth"
+	"user-service/internal/middleware"
+	"user-service/internal/model"
+	"user-service/internal/service"
+
+	"platform/tracing"
+	userauthv1 "proto/userauth/v1"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// userAuthScopes lists the service token scopes of the RPCs that require
+// one. AuthenticateUser, like GET /api/v1/users/auth, is authenticated by
+// the access token being resolved instead.
+var userAuthScopes = map[string][]string{
+	userauthv1.UserAuthService_IntrospectAPIKey_FullMethodName: {"api-keys:introspect"},
+}
+
+// UserAuthServer resolves user access tokens and partner API keys for
+// booking and payment service
+type UserAuthServer struct {
+	userauthv1.UnimplementedUserAuthServiceServer
+	userService   service.UserService
+	apiKeyService service.APIKeyService
+	revocations   middleware.RevocationChecker
+}
+
+func NewUserAuthServer(
+	userService service.UserService,
+	apiKeyService service.APIKeyService,
+	revocations middleware.RevocationChecker,
+) *UserAuthServer {
+	return &UserAuthServer{
+		userService:   userService,
+		apiKeyService: apiKeyService,
+		revocations:   revocations,
+	}
+}
+
+// NewServer returns a gRPC server exposing UserAuthService
+func NewServer(userAuthServer *UserAuthServer) *grpc.Server {
+	server := grpc.NewServer(grpc.ChainUnaryInterceptor(
+		tracing.UnaryServer(),
+		middleware.UnaryRequestID(),
+		middleware.UnaryServiceToken(userAuthScopes),
+	))
+	userauthv1.RegisterUserAuthServiceServer(server, userAuthServer)
+	return server
+}
+
+func (s *UserAuthServer) AuthenticateUser(ctx context.Context, req *userauthv1.AuthenticateUserRequest) (*userauthv1.User, error) {
+	tokenString := strings.TrimPrefix(req.GetAccessToken(), "Bearer ")
+	if tokenString == "" {
+		return nil, status.Error(codes.Unauthenticated, "token is required")
+	}
+
+	claims, err := auth.ValidateAccessToken(tokenString)
+	if err != nil {
+		if errors.Is(err, auth.ErrExpiredToken) {
+			return nil, status.Error(codes.Unauthenticated, "token has expired")
+		}
+		return nil, status.Error(codes.Unauthenticated, "invalid token")
+	}
+
+	if s.revocations.IsRevoked(claims.ID, claims.SessionID) {
+		return nil, status.Error(codes.Unauthenticated, "token has been revoked")
+	}
+
+	user, err := s.userService.GetAuthenticatedUser(ctx, claims.UserID)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+
+	return toUserProto(user), nil
+}
+
+func (s *UserAuthServer) IntrospectAPIKey(ctx context.Context, req *userauthv1.IntrospectAPIKeyRequest) (*userauthv1.APIKey, error) {
+	if req.GetApiKey() == "" {
+		return nil, status.Error(codes.Unauthenticated, "API key is required")
+	}
+
+	result, err := s.apiKeyService.Introspect(ctx, req.GetApiKey())
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+
+	return &userauthv1.APIKey{
+		KeyId:  result.KeyID.String(),
+		Scopes: result.Scopes,
+		User:   toUserProto(result.User),
+	}, nil
+}
+
+func toUserProto(user *model.UserResponse) *userauthv1.User {
+	return &userauthv1.User{
+		Id:            user.ID,
+		Username:      user.Username,
+		Email:         user.Email,
+		EmailVerified: user.EmailVerified,
+		Role:          user.Role,
+		MfaEnabled:    user.MFAEnabled,
+	}
+}
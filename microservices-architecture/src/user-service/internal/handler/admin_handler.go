@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"user-service/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type AdminHandler struct {
+	loginGuard service.LoginGuard
+}
+
+func NewAdminHandler(loginGuard service.LoginGuard) *AdminHandler {
+	return &AdminHandler{loginGuard: loginGuard}
+}
+
+func (h *AdminHandler) UnlockUser(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	actorID, _ := c.Locals("userID").(string)
+	if err := h.loginGuard.Unlock(c.UserContext(), id, actorID, clientInfo(c)); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "User unlocked successfully",
+	})
+}
+
+func (h *AdminHandler) GetLockEvents(c *fiber.Ctx) error {
+	var userID *uuid.UUID
+	if param := c.Query("user_id"); param != "" {
+		id, err := uuid.Parse(param)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid user ID",
+			})
+		}
+		userID = &id
+	}
+
+	events, err := h.loginGuard.ListLockEvents(c.UserContext(), userID, c.QueryInt("limit", 100))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve lock events",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Lock events retrieved successfully",
+		"data":    events,
+	})
+}
@@ -0,0 +1,152 @@
+package handler
+
+import (
+	"errors"
+	"user-service/internal/repository"
+	"user-service/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type AdminUserHandler struct {
+	service service.AdminUserService
+}
+
+type DisableUserRequest struct {
+	Reason string `json:"reason"`
+}
+
+type SetRoleRequest struct {
+	Role string `json:"role"`
+}
+
+func NewAdminUserHandler(service service.AdminUserService) *AdminUserHandler {
+	return &AdminUserHandler{service: service}
+}
+
+// GetUsers searches accounts by username or email. Supports the q, role,
+// status (active, disabled, locked, deleted), page and per_page query params.
+func (h *AdminUserHandler) GetUsers(c *fiber.Ctx) error {
+	page, err := h.service.ListUsers(c.UserContext(), repository.UserFilter{
+		Query:   c.Query("q"),
+		Role:    c.Query("role"),
+		Status:  c.Query("status"),
+		Page:    c.QueryInt("page", 1),
+		PerPage: c.QueryInt("per_page", 0),
+	})
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Users retrieved successfully",
+		"data":    page,
+	})
+}
+
+func (h *AdminUserHandler) GetUser(c *fiber.Ctx) error {
+	user, err := h.service.GetUser(c.UserContext(), c.Params("id"))
+	if err != nil {
+		return h.error(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "User retrieved successfully",
+		"data":    user,
+	})
+}
+
+func (h *AdminUserHandler) DisableUser(c *fiber.Ctx) error {
+	var req DisableUserRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid request body",
+			})
+		}
+	}
+
+	actorID, _ := c.Locals("userID").(string)
+	user, err := h.service.DisableUser(c.UserContext(), c.Params("id"), actorID, req.Reason, clientInfo(c))
+	if err != nil {
+		return h.error(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "User disabled successfully",
+		"data":    user,
+	})
+}
+
+func (h *AdminUserHandler) EnableUser(c *fiber.Ctx) error {
+	actorID, _ := c.Locals("userID").(string)
+	user, err := h.service.EnableUser(c.UserContext(), c.Params("id"), actorID, clientInfo(c))
+	if err != nil {
+		return h.error(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "User enabled successfully",
+		"data":    user,
+	})
+}
+
+func (h *AdminUserHandler) DeleteUser(c *fiber.Ctx) error {
+	actorID, _ := c.Locals("userID").(string)
+	if err := h.service.DeleteUser(c.UserContext(), c.Params("id"), actorID, clientInfo(c)); err != nil {
+		return h.error(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "User deleted successfully",
+	})
+}
+
+func (h *AdminUserHandler) RestoreUser(c *fiber.Ctx) error {
+	actorID, _ := c.Locals("userID").(string)
+	user, err := h.service.RestoreUser(c.UserContext(), c.Params("id"), actorID, clientInfo(c))
+	if err != nil {
+		return h.error(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "User restored successfully",
+		"data":    user,
+	})
+}
+
+func (h *AdminUserHandler) SetRole(c *fiber.Ctx) error {
+	var req SetRoleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	actorID, _ := c.Locals("userID").(string)
+	user, err := h.service.SetRole(c.UserContext(), c.Params("id"), actorID, req.Role, clientInfo(c))
+	if err != nil {
+		return h.error(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Role updated successfully",
+		"data":    user,
+	})
+}
+
+func (h *AdminUserHandler) error(c *fiber.Ctx, err error) error {
+	status := fiber.StatusBadRequest
+	switch {
+	case errors.Is(err, service.ErrUserNotFound):
+		status = fiber.StatusNotFound
+	case errors.Is(err, service.ErrSelfModification):
+		status = fiber.StatusForbidden
+	}
+
+	return c.Status(status).JSON(fiber.Map{
+		"error": err.Error(),
+	})
+}
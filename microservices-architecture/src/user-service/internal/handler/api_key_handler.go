@@ -0,0 +1,126 @@
+package handler
+
+import (
+	"errors"
+	"user-service/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type APIKeyHandler struct {
+	service service.APIKeyService
+}
+
+func NewAPIKeyHandler(service service.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{service: service}
+}
+
+func (h *APIKeyHandler) CreateAPIKey(c *fiber.Ctx) error {
+	var req service.CreateAPIKeyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	userID, _ := c.Locals("userID").(string)
+	key, err := h.service.CreateAPIKey(c.UserContext(), userID, req)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "API key created successfully, store it now as it will not be shown again",
+		"data":    key,
+	})
+}
+
+func (h *APIKeyHandler) GetMyAPIKeys(c *fiber.Ctx) error {
+	userID, _ := c.Locals("userID").(string)
+	keys, err := h.service.ListAPIKeys(c.UserContext(), userID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "API keys retrieved successfully",
+		"data":    keys,
+	})
+}
+
+func (h *APIKeyHandler) RevokeMyAPIKey(c *fiber.Ctx) error {
+	userID, _ := c.Locals("userID").(string)
+	return h.revokeResult(c, h.service.RevokeAPIKey(c.UserContext(), userID, c.Params("id")))
+}
+
+func (h *APIKeyHandler) GetAPIKeys(c *fiber.Ctx) error {
+	var userID *uuid.UUID
+	if param := c.Query("user_id"); param != "" {
+		id, err := uuid.Parse(param)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid user ID",
+			})
+		}
+		userID = &id
+	}
+
+	keys, err := h.service.ListAllAPIKeys(c.UserContext(), userID, c.QueryInt("limit", 100))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "API keys retrieved successfully",
+		"data":    keys,
+	})
+}
+
+func (h *APIKeyHandler) RevokeAPIKey(c *fiber.Ctx) error {
+	return h.revokeResult(c, h.service.RevokeAnyAPIKey(c.UserContext(), c.Params("id")))
+}
+
+// Introspect resolves the X-API-Key header for booking and payment service
+func (h *APIKeyHandler) Introspect(c *fiber.Ctx) error {
+	key := c.Get("X-API-Key")
+	if key == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "X-API-Key header is required",
+		})
+	}
+
+	result, err := h.service.Introspect(c.UserContext(), key)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "API key is valid",
+		"data":    result,
+	})
+}
+
+func (h *APIKeyHandler) revokeResult(c *fiber.Ctx, err error) error {
+	if err != nil {
+		status := fiber.StatusBadRequest
+		if errors.Is(err, service.ErrAPIKeyNotFound) {
+			status = fiber.StatusNotFound
+		}
+		return c.Status(status).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "API key revoked successfully",
+	})
+}
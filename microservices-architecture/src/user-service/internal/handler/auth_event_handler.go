@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+	"user-service/internal/model"
+	"user-service/internal/repository"
+	"user-service/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type AuthEventHandler struct {
+	service service.AuthEventService
+}
+
+func NewAuthEventHandler(service service.AuthEventService) *AuthEventHandler {
+	return &AuthEventHandler{service: service}
+}
+
+// GetAuthEvents lists audit log entries, newest first. Filters: user_id,
+// type, outcome, ip, since and until (RFC 3339) and limit. With
+// format=ndjson every matching entry is streamed oldest first instead.
+func (h *AuthEventHandler) GetAuthEvents(c *fiber.Ctx) error {
+	filter, err := authEventFilter(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	if c.Query("format") == "ndjson" {
+		return h.export(c, filter)
+	}
+
+	events, err := h.service.List(c.UserContext(), filter)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve auth events",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Auth events retrieved successfully",
+		"data":    events,
+	})
+}
+
+func (h *AuthEventHandler) export(c *fiber.Ctx, filter repository.AuthEventFilter) error {
+	// Export is not capped unless a limit was asked for
+	filter.Limit = c.QueryInt("limit", 0)
+
+	// The body is written after the handler and its deadline middleware have
+	// returned, so keep the request deadline but not its cancellation
+	ctx := context.WithoutCancel(c.UserContext())
+	cancel := context.CancelFunc(func() {})
+	if deadline, ok := c.UserContext().Deadline(); ok {
+		ctx, cancel = context.WithDeadline(ctx, deadline)
+	}
+
+	c.Set(fiber.HeaderContentType, "application/x-ndjson")
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="auth-events.ndjson"`)
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer cancel()
+		if err := h.service.Export(ctx, filter, w); err != nil {
+			slog.ErrorContext(ctx, "failed to export auth events", "error", err)
+		}
+		if err := w.Flush(); err != nil {
+			slog.ErrorContext(ctx, "failed to flush auth event export", "error", err)
+		}
+	})
+	return nil
+}
+
+func authEventFilter(c *fiber.Ctx) (repository.AuthEventFilter, error) {
+	filter := repository.AuthEventFilter{
+		Type:    c.Query("type"),
+		Outcome: c.Query("outcome"),
+		IP:      c.Query("ip"),
+		Limit:   c.QueryInt("limit", 0),
+	}
+
+	if param := c.Query("user_id"); param != "" {
+		id, err := uuid.Parse(param)
+		if err != nil {
+			return filter, errors.New("invalid user ID")
+		}
+		filter.UserID = &id
+	}
+
+	if param := c.Query("since"); param != "" {
+		since, err := time.Parse(time.RFC3339, param)
+		if err != nil {
+			return filter, errors.New("since must be an RFC 3339 timestamp")
+		}
+		filter.Since = &since
+	}
+
+	if param := c.Query("until"); param != "" {
+		until, err := time.Parse(time.RFC3339, param)
+		if err != nil {
+			return filter, errors.New("until must be an RFC 3339 timestamp")
+		}
+		filter.Until = &until
+	}
+
+	if filter.Outcome != "" && filter.Outcome != model.AuthOutcomeSuccess && filter.Outcome != model.AuthOutcomeFailure {
+		return filter, errors.New("outcome must be success or failure")
+	}
+	if filter.Since != nil && filter.Until != nil && !filter.Since.Before(*filter.Until) {
+		return filter, errors.New("since must be before until")
+	}
+
+	return filter, nil
+}
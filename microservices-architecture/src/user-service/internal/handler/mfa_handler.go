@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"user-service/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type MFAHandler struct {
+	service service.MFAService
+}
+
+func NewMFAHandler(service service.MFAService) *MFAHandler {
+	return &MFAHandler{service: service}
+}
+
+type MFACodeRequest struct {
+	Code string `json:"code" validate:"required"`
+}
+
+func (h *MFAHandler) BeginTOTPEnrollment(c *fiber.Ctx) error {
+	userID, _ := c.Locals("userID").(string)
+
+	enrollment, err := h.service.BeginTOTPEnrollment(c.UserContext(), userID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Scan the QR code with your authenticator app and confirm with a code",
+		"data":    enrollment,
+	})
+}
+
+func (h *MFAHandler) ConfirmTOTPEnrollment(c *fiber.Ctx) error {
+	var req MFACodeRequest
+	if err := c.BodyParser(&req); err != nil || req.Code == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Code is required",
+		})
+	}
+
+	userID, _ := c.Locals("userID").(string)
+	recoveryCodes, err := h.service.ConfirmTOTPEnrollment(c.UserContext(), userID, req.Code)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message":        "Two-factor authentication enabled. Store these recovery codes safely; they will not be shown again",
+		"recovery_codes": recoveryCodes,
+	})
+}
+
+func (h *MFAHandler) DisableTOTP(c *fiber.Ctx) error {
+	var req MFACodeRequest
+	if err := c.BodyParser(&req); err != nil || req.Code == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Code is required",
+		})
+	}
+
+	userID, _ := c.Locals("userID").(string)
+	if err := h.service.DisableTOTP(c.UserContext(), userID, req.Code); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Two-factor authentication disabled",
+	})
+}
+
+func (h *MFAHandler) RegenerateRecoveryCodes(c *fiber.Ctx) error {
+	var req MFACodeRequest
+	if err := c.BodyParser(&req); err != nil || req.Code == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Code is required",
+		})
+	}
+
+	userID, _ := c.Locals("userID").(string)
+	recoveryCodes, err := h.service.RegenerateRecoveryCodes(c.UserContext(), userID, req.Code)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message":        "Recovery codes regenerated",
+		"recovery_codes": recoveryCodes,
+	})
+}
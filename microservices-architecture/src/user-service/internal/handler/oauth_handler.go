@@ -0,0 +1,485 @@
+package handler
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"html/template"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"user-service/internal/auth"
+	"user-service/internal/middleware"
+	"user-service/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// OAuthBasePath is where the OAuth endpoints are mounted
+const OAuthBasePath = "/api/v1/oauth"
+
+const oauthSessionCookie = "oauth_session"
+
+var scopeDescriptions = map[string]string{
+	service.ScopeOpenID:        "Your account ID",
+	service.ScopeProfile:       "Your username",
+	service.ScopeEmail:         "Your email address",
+	service.ScopeOfflineAccess: "Access while you are not signed in",
+}
+
+type OAuthHandler struct {
+	service     service.OAuthService
+	userService service.UserService
+	revocations middleware.RevocationChecker
+}
+
+func NewOAuthHandler(service service.OAuthService, userService service.UserService, revocations middleware.RevocationChecker) *OAuthHandler {
+	return &OAuthHandler{service: service, userService: userService, revocations: revocations}
+}
+
+// Authorize starts the authorization code flow. Users without a login
+// cookie get the login page, and third-party clients get a consent page
+// unless the requested scopes were already granted.
+func (h *OAuthHandler) Authorize(c *fiber.Ctx) error {
+	rawQuery := string(c.Request().URI().QueryString())
+	query, _ := url.ParseQuery(rawQuery)
+	return h.authorize(c, query)
+}
+
+func (h *OAuthHandler) authorize(c *fiber.Ctx, query url.Values) error {
+	req := authorizeRequestFromQuery(query)
+
+	client, err := h.service.ValidateAuthorizeRequest(c.UserContext(), req)
+	if client == nil {
+		return h.renderError(c, err.Error())
+	}
+	if err != nil {
+		return h.redirectError(c, req, err)
+	}
+
+	claims := h.sessionClaims(c)
+	if claims == nil || query.Get("prompt") == "login" {
+		query.Del("prompt")
+		return h.render(c, fiber.StatusOK, oauthLoginTemplate, oauthPage{
+			Title:      "Sign in",
+			ClientName: client.Name,
+			Action:     OAuthBasePath + "/login",
+			Request:    query.Encode(),
+		})
+	}
+
+	user, err := h.service.FindUser(c.UserContext(), claims.UserID)
+	if err != nil {
+		h.clearSessionCookie(c)
+		return h.renderError(c, "Your session is no longer valid, please sign in again")
+	}
+
+	scopes := req.Scopes()
+	needsConsent, err := h.service.NeedsConsent(c.UserContext(), claims.UserID, client, scopes)
+	if err != nil {
+		return h.renderError(c, err.Error())
+	}
+
+	if needsConsent {
+		descriptions := make([]string, 0, len(scopes))
+		for _, scope := range scopes {
+			descriptions = append(descriptions, scopeDescriptions[scope])
+		}
+		return h.render(c, fiber.StatusOK, oauthConsentTemplate, oauthPage{
+			Title:      "Authorize " + client.Name,
+			ClientName: client.Name,
+			Action:     OAuthBasePath + "/consent",
+			Request:    query.Encode(),
+			Username:   user.Username,
+			Scopes:     descriptions,
+			CSRFToken:  csrfToken(c.Cookies(oauthSessionCookie)),
+		})
+	}
+
+	return h.issueCode(c, req, claims)
+}
+
+// Login handles the login form rendered by Authorize
+func (h *OAuthHandler) Login(c *fiber.Ctx) error {
+	query, err := url.ParseQuery(c.FormValue("request"))
+	if err != nil {
+		return h.renderError(c, "Invalid authorization request")
+	}
+
+	req := authorizeRequestFromQuery(query)
+	client, err := h.service.ValidateAuthorizeRequest(c.UserContext(), req)
+	if client == nil {
+		return h.renderError(c, err.Error())
+	}
+
+	username := c.FormValue("username")
+	user, err := h.userService.Authenticate(c.UserContext(),
+		username,
+		c.FormValue("password"),
+		c.FormValue("code"),
+		c.FormValue("recovery_code"),
+		clientInfo(c),
+	)
+	if err != nil {
+		page := oauthPage{
+			Title:       "Sign in",
+			ClientName:  client.Name,
+			Action:      OAuthBasePath + "/login",
+			Request:     query.Encode(),
+			Username:    username,
+			Error:       err.Error(),
+			MFARequired: c.FormValue("code") != "" || c.FormValue("recovery_code") != "",
+		}
+
+		status := fiber.StatusUnauthorized
+		var throttleErr *service.ThrottleError
+		switch {
+		case errors.Is(err, service.ErrMFACodeRequired):
+			page.MFARequired = true
+			page.Error = ""
+			status = fiber.StatusOK
+		case errors.As(err, &throttleErr):
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(throttleErr.RetryAfterSeconds()))
+			status = fiber.StatusTooManyRequests
+		}
+		return h.render(c, status, oauthLoginTemplate, page)
+	}
+
+	token, err := h.service.StartSession(c.UserContext(), user, user.IsMFAEnabled(), clientInfo(c))
+	if err != nil {
+		return h.renderError(c, "Failed to sign in")
+	}
+
+	c.Cookie(&fiber.Cookie{
+		Name:     oauthSessionCookie,
+		Value:    token,
+		Path:     OAuthBasePath,
+		Expires:  time.Now().Add(auth.OAuthSessionExpiry),
+		HTTPOnly: true,
+		Secure:   strings.HasPrefix(h.service.Issuer(), "https://"),
+		SameSite: fiber.CookieSameSiteLaxMode,
+	})
+
+	return c.Redirect(OAuthBasePath+"/authorize?"+query.Encode(), fiber.StatusSeeOther)
+}
+
+// Consent handles the allow and deny buttons of the consent page
+func (h *OAuthHandler) Consent(c *fiber.Ctx) error {
+	query, err := url.ParseQuery(c.FormValue("request"))
+	if err != nil {
+		return h.renderError(c, "Invalid authorization request")
+	}
+
+	claims := h.sessionClaims(c)
+	if claims == nil {
+		return h.authorize(c, query)
+	}
+
+	expected := csrfToken(c.Cookies(oauthSessionCookie))
+	if subtle.ConstantTimeCompare([]byte(expected), []byte(c.FormValue("csrf_token"))) != 1 {
+		return h.renderError(c, "Invalid consent request, please try again")
+	}
+
+	req := authorizeRequestFromQuery(query)
+	client, err := h.service.ValidateAuthorizeRequest(c.UserContext(), req)
+	if client == nil {
+		return h.renderError(c, err.Error())
+	}
+	if err != nil {
+		return h.redirectError(c, req, err)
+	}
+
+	if c.FormValue("decision") != "allow" {
+		return h.redirectError(c, req, &service.OAuthError{
+			Code:        "access_denied",
+			Description: "the user denied the request",
+		})
+	}
+
+	if err := h.service.GrantConsent(c.UserContext(), claims.UserID, client, req.Scopes()); err != nil {
+		return h.renderError(c, "Failed to save consent")
+	}
+
+	return h.issueCode(c, req, claims)
+}
+
+// Token is the OAuth token endpoint. Confidential clients authenticate with
+// HTTP Basic or with client_id and client_secret in the form body.
+func (h *OAuthHandler) Token(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderCacheControl, "no-store")
+
+	clientID, clientSecret, ok := basicAuth(c.Get(fiber.HeaderAuthorization))
+	if !ok {
+		clientID = c.FormValue("client_id")
+		clientSecret = c.FormValue("client_secret")
+	}
+
+	response, err := h.service.Token(c.UserContext(), service.TokenRequest{
+		GrantType:    c.FormValue("grant_type"),
+		Code:         c.FormValue("code"),
+		RedirectURI:  c.FormValue("redirect_uri"),
+		CodeVerifier: c.FormValue("code_verifier"),
+		RefreshToken: c.FormValue("refresh_token"),
+		Scope:        c.FormValue("scope"),
+		Audience:     c.FormValue("audience"),
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+	}, clientInfo(c))
+	if err != nil {
+		var oauthErr *service.OAuthError
+		if !errors.As(err, &oauthErr) {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error":             "server_error",
+				"error_description": err.Error(),
+			})
+		}
+
+		status := fiber.StatusBadRequest
+		if oauthErr.Code == "invalid_client" {
+			status = fiber.StatusUnauthorized
+			c.Set(fiber.HeaderWWWAuthenticate, `Basic realm="oauth"`)
+		}
+		return c.Status(status).JSON(fiber.Map{
+			"error":             oauthErr.Code,
+			"error_description": oauthErr.Description,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response)
+}
+
+// UserInfo returns the OIDC claims of the access token's user
+func (h *OAuthHandler) UserInfo(c *fiber.Ctx) error {
+	userID, _ := c.Locals("userID").(string)
+	sessionID, _ := c.Locals("sessionID").(string)
+
+	claims, err := h.service.UserInfo(c.UserContext(), userID, sessionID)
+	if err != nil {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(claims)
+}
+
+// OpenIDConfiguration serves the OIDC discovery document
+func (h *OAuthHandler) OpenIDConfiguration(c *fiber.Ctx) error {
+	issuer := h.service.Issuer()
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"issuer":                                issuer,
+		"authorization_endpoint":                issuer + OAuthBasePath + "/authorize",
+		"token_endpoint":                        issuer + OAuthBasePath + "/token",
+		"userinfo_endpoint":                     issuer + OAuthBasePath + "/userinfo",
+		"jwks_uri":                              issuer + "/.well-known/jwks.json",
+		"response_types_supported":              []string{"code"},
+		"grant_types_supported":                 []string{"authorization_code", "refresh_token", "client_credentials"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"scopes_supported":                      service.SupportedScopes(),
+		"token_endpoint_auth_methods_supported": []string{"client_secret_basic", "client_secret_post", "none"},
+		"code_challenge_methods_supported":      []string{"S256"},
+		"claims_supported":                      []string{"sub", "iss", "aud", "exp", "iat", "auth_time", "nonce", "preferred_username", "email", "email_verified"},
+	})
+}
+
+// JWKS serves the public keys used to sign id_tokens
+func (h *OAuthHandler) JWKS(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"keys": auth.PublicJWKs(),
+	})
+}
+
+func (h *OAuthHandler) RegisterClient(c *fiber.Ctx) error {
+	var req service.RegisterClientRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	client, err := h.service.RegisterClient(c.UserContext(), req)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "OAuth client registered successfully",
+		"data":    client,
+	})
+}
+
+func (h *OAuthHandler) GetClients(c *fiber.Ctx) error {
+	clients, err := h.service.ListClients(c.UserContext())
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve OAuth clients",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "OAuth clients retrieved successfully",
+		"data":    clients,
+	})
+}
+
+func (h *OAuthHandler) DeleteClient(c *fiber.Ctx) error {
+	if err := h.service.DeleteClient(c.UserContext(), c.Params("clientId")); err != nil {
+		status := fiber.StatusInternalServerError
+		if errors.Is(err, service.ErrOAuthClientNotFound) {
+			status = fiber.StatusNotFound
+		}
+		return c.Status(status).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "OAuth client deleted successfully",
+	})
+}
+
+func (h *OAuthHandler) issueCode(c *fiber.Ctx, req *service.AuthorizeRequest, claims *auth.Claims) error {
+	authTime := time.Now()
+	if claims.IssuedAt != nil {
+		authTime = claims.IssuedAt.Time
+	}
+
+	code, err := h.service.IssueCode(c.UserContext(), claims.UserID, claims.MFA, authTime, req)
+	if err != nil {
+		return h.redirectError(c, req, &service.OAuthError{
+			Code:        "server_error",
+			Description: err.Error(),
+		})
+	}
+
+	params := url.Values{}
+	params.Set("code", code)
+	if req.State != "" {
+		params.Set("state", req.State)
+	}
+	return c.Redirect(appendQuery(req.RedirectURI, params), fiber.StatusFound)
+}
+
+// redirectError sends an OAuth error back to the client's redirect URI
+func (h *OAuthHandler) redirectError(c *fiber.Ctx, req *service.AuthorizeRequest, err error) error {
+	params := url.Values{}
+	var oauthErr *service.OAuthError
+	if errors.As(err, &oauthErr) {
+		params.Set("error", oauthErr.Code)
+		params.Set("error_description", oauthErr.Description)
+	} else {
+		params.Set("error", "server_error")
+	}
+	if req.State != "" {
+		params.Set("state", req.State)
+	}
+	return c.Redirect(appendQuery(req.RedirectURI, params), fiber.StatusFound)
+}
+
+// sessionClaims returns the claims of a valid login cookie, or nil. A
+// revoked session is denylisted for longer than the cookie lives, so the
+// denylist is all that needs checking.
+func (h *OAuthHandler) sessionClaims(c *fiber.Ctx) *auth.Claims {
+	cookie := c.Cookies(oauthSessionCookie)
+	if cookie == "" {
+		return nil
+	}
+	claims, err := auth.ValidateOAuthSessionToken(cookie)
+	if err != nil || claims.SessionID == "" {
+		return nil
+	}
+	if h.revocations.IsRevoked(claims.ID, claims.SessionID) {
+		return nil
+	}
+	return claims
+}
+
+func (h *OAuthHandler) clearSessionCookie(c *fiber.Ctx) {
+	c.Cookie(&fiber.Cookie{
+		Name:     oauthSessionCookie,
+		Value:    "",
+		Path:     OAuthBasePath,
+		Expires:  time.Unix(0, 0),
+		HTTPOnly: true,
+		SameSite: fiber.CookieSameSiteLaxMode,
+	})
+}
+
+func (h *OAuthHandler) renderError(c *fiber.Ctx, message string) error {
+	return h.render(c, fiber.StatusBadRequest, oauthErrorTemplate, oauthPage{
+		Title: "Authorization failed",
+		Error: message,
+	})
+}
+
+func (h *OAuthHandler) render(c *fiber.Ctx, status int, tmpl *template.Template, page oauthPage) error {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, page); err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to render page")
+	}
+
+	// The pages must not be framed, to prevent clickjacking the consent screen
+	c.Set("X-Frame-Options", "DENY")
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	c.Type("html", "utf-8")
+	return c.Status(status).Send(buf.Bytes())
+}
+
+func authorizeRequestFromQuery(query url.Values) *service.AuthorizeRequest {
+	return &service.AuthorizeRequest{
+		ResponseType:        query.Get("response_type"),
+		ClientID:            query.Get("client_id"),
+		RedirectURI:         query.Get("redirect_uri"),
+		Scope:               query.Get("scope"),
+		State:               query.Get("state"),
+		Nonce:               query.Get("nonce"),
+		CodeChallenge:       query.Get("code_challenge"),
+		CodeChallengeMethod: query.Get("code_challenge_method"),
+	}
+}
+
+// csrfToken derives the consent form token from the login cookie, so that
+// only a page rendered for this browser can submit a decision
+func csrfToken(sessionCookie string) string {
+	return auth.HashToken("consent:" + sessionCookie)
+}
+
+func appendQuery(uri string, params url.Values) string {
+	if strings.Contains(uri, "?") {
+		return uri + "&" + params.Encode()
+	}
+	return uri + "?" + params.Encode()
+}
+
+func basicAuth(header string) (username, password string, ok bool) {
+	const prefix = "Basic "
+	if !strings.HasPrefix(header, prefix) {
+		return "", "", false
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(header[len(prefix):])
+	if err != nil {
+		return "", "", false
+	}
+
+	username, password, ok = strings.Cut(string(decoded), ":")
+	if !ok {
+		return "", "", false
+	}
+
+	// Credentials are form encoded before being placed in the header
+	username, err = url.QueryUnescape(username)
+	if err != nil {
+		return "", "", false
+	}
+	password, err = url.QueryUnescape(password)
+	if err != nil {
+		return "", "", false
+	}
+	return username, password, true
+}
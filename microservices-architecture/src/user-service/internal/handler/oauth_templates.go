@@ -0,0 +1,67 @@
+package handler
+
+import "html/template"
+
+const oauthLayout = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; background: #f4f4f5; display: flex; justify-content: center; padding-top: 10vh; }
+main { background: #fff; padding: 2rem; border-radius: 8px; width: 100%; max-width: 360px; box-shadow: 0 1px 3px rgba(0,0,0,.1); }
+label { display: block; margin-top: 1rem; font-size: .9rem; }
+input { width: 100%; padding: .5rem; margin-top: .25rem; box-sizing: border-box; }
+button { margin-top: 1.5rem; padding: .6rem 1rem; width: 100%; }
+.error { color: #b91c1c; }
+ul { padding-left: 1.25rem; }
+</style>
+</head>
+<body><main>{{template "content" .}}</main></body>
+</html>`
+
+var oauthLoginTemplate = template.Must(template.Must(template.New("login").Parse(oauthLayout)).Parse(`{{define "content"}}
+<h1>Sign in</h1>
+<p>to continue to <strong>{{.ClientName}}</strong></p>
+{{if .Error}}<p class="error">{{.Error}}</p>{{end}}
+<form method="post" action="{{.Action}}">
+<input type="hidden" name="request" value="{{.Request}}">
+<label>Username<input name="username" value="{{.Username}}" autocomplete="username" required></label>
+<label>Password<input type="password" name="password" autocomplete="current-password" required></label>
+{{if .MFARequired}}
+<label>Authenticator code<input name="code" inputmode="numeric" autocomplete="one-time-code"></label>
+<label>Or a recovery code<input name="recovery_code"></label>
+{{end}}
+<button type="submit">Sign in</button>
+</form>
+{{end}}`))
+
+var oauthConsentTemplate = template.Must(template.Must(template.New("consent").Parse(oauthLayout)).Parse(`{{define "content"}}
+<h1>Authorize {{.ClientName}}</h1>
+<p>Signed in as <strong>{{.Username}}</strong>. {{.ClientName}} is requesting access to:</p>
+<ul>{{range .Scopes}}<li>{{.}}</li>{{end}}</ul>
+<form method="post" action="{{.Action}}">
+<input type="hidden" name="request" value="{{.Request}}">
+<input type="hidden" name="csrf_token" value="{{.CSRFToken}}">
+<button type="submit" name="decision" value="allow">Allow</button>
+<button type="submit" name="decision" value="deny">Deny</button>
+</form>
+{{end}}`))
+
+var oauthErrorTemplate = template.Must(template.Must(template.New("error").Parse(oauthLayout)).Parse(`{{define "content"}}
+<h1>Authorization failed</h1>
+<p class="error">{{.Error}}</p>
+{{end}}`))
+
+type oauthPage struct {
+	Title       string
+	ClientName  string
+	Action      string
+	Request     string
+	Username    string
+	Error       string
+	MFARequired bool
+	Scopes      []string
+	CSRFToken   string
+}
@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"errors"
+	"user-service/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type PasswordHandler struct {
+	service service.PasswordService
+}
+
+func NewPasswordHandler(service service.PasswordService) *PasswordHandler {
+	return &PasswordHandler{service: service}
+}
+
+type ForgotPasswordRequest struct {
+	Email    string `json:"email"`
+	Username string `json:"username"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required"`
+}
+
+func (h *PasswordHandler) ForgotPassword(c *fiber.Ctx) error {
+	var req ForgotPasswordRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	identifier := req.Email
+	if identifier == "" {
+		identifier = req.Username
+	}
+
+	if identifier == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Email or username is required",
+		})
+	}
+
+	// The response is the same whether or not the account exists
+	_ = h.service.ForgotPassword(c.UserContext(), identifier)
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "If the account exists, a password reset link has been sent",
+	})
+}
+
+func (h *PasswordHandler) ResetPassword(c *fiber.Ctx) error {
+	var req ResetPasswordRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if req.Token == "" || req.Password == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Token and password are required",
+		})
+	}
+
+	if err := h.service.ResetPassword(c.UserContext(), req.Token, req.Password, clientInfo(c)); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Password reset successfully",
+	})
+}
+
+func (h *PasswordHandler) ChangePassword(c *fiber.Ctx) error {
+	var req ChangePasswordRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	userID, _ := c.Locals("userID").(string)
+	sessionID, _ := c.Locals("sessionID").(string)
+	if err := h.service.ChangePassword(c.UserContext(), userID, sessionID, req.CurrentPassword, req.NewPassword, clientInfo(c)); err != nil {
+		status := fiber.StatusBadRequest
+		if errors.Is(err, service.ErrIncorrectPassword) {
+			status = fiber.StatusForbidden
+		}
+		return c.Status(status).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Password changed successfully, other sessions have been signed out",
+	})
+}
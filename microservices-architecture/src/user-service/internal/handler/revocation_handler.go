@@ -0,0 +1,35 @@
+package handler
+
+import (
+	"time"
+	"user-service/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type RevocationHandler struct {
+	service service.RevocationService
+}
+
+func NewRevocationHandler(service service.RevocationService) *RevocationHandler {
+	return &RevocationHandler{service: service}
+}
+
+// GetRevocations lists denylist entries created since the given unix time,
+// for services that verify access tokens locally and need to catch up
+func (h *RevocationHandler) GetRevocations(c *fiber.Ctx) error {
+	since := time.Unix(int64(c.QueryInt("since", 0)), 0)
+
+	entries, err := h.service.ListSince(c.UserContext(), since)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve revocations",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message":     "Revocations retrieved successfully",
+		"data":        entries,
+		"server_time": time.Now().Unix(),
+	})
+}
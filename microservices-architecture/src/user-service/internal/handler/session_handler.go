@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"errors"
+	"user-service/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type SessionHandler struct {
+	service service.SessionService
+}
+
+func NewSessionHandler(service service.SessionService) *SessionHandler {
+	return &SessionHandler{service: service}
+}
+
+func (h *SessionHandler) GetMySessions(c *fiber.Ctx) error {
+	userID, _ := c.Locals("userID").(string)
+	sessionID, _ := c.Locals("sessionID").(string)
+	return h.listSessions(c, userID, sessionID)
+}
+
+func (h *SessionHandler) RevokeMySession(c *fiber.Ctx) error {
+	userID, _ := c.Locals("userID").(string)
+	return h.revokeSession(c, userID, c.Params("id"))
+}
+
+func (h *SessionHandler) RevokeAllMySessions(c *fiber.Ctx) error {
+	userID, _ := c.Locals("userID").(string)
+	return h.revokeAllSessions(c, userID)
+}
+
+func (h *SessionHandler) GetUserSessions(c *fiber.Ctx) error {
+	return h.listSessions(c, c.Params("id"), "")
+}
+
+func (h *SessionHandler) RevokeUserSession(c *fiber.Ctx) error {
+	return h.revokeSession(c, c.Params("id"), c.Params("sessionId"))
+}
+
+func (h *SessionHandler) RevokeAllUserSessions(c *fiber.Ctx) error {
+	return h.revokeAllSessions(c, c.Params("id"))
+}
+
+func (h *SessionHandler) listSessions(c *fiber.Ctx, userID, currentSessionID string) error {
+	sessions, err := h.service.ListSessions(c.UserContext(), userID, currentSessionID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Sessions retrieved successfully",
+		"data":    sessions,
+	})
+}
+
+func (h *SessionHandler) revokeSession(c *fiber.Ctx, userID, sessionID string) error {
+	if err := h.service.RevokeSession(c.UserContext(), userID, sessionID); err != nil {
+		status := fiber.StatusBadRequest
+		if errors.Is(err, service.ErrSessionNotFound) {
+			status = fiber.StatusNotFound
+		}
+		return c.Status(status).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Session revoked successfully",
+	})
+}
+
+func (h *SessionHandler) revokeAllSessions(c *fiber.Ctx, userID string) error {
+	if err := h.service.RevokeAllSessions(c.UserContext(), userID); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "All sessions revoked successfully",
+	})
+}
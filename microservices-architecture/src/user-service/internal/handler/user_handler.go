@@ -1,6 +1,9 @@
 package handler
 
 import (
+	"errors"
+	"strconv"
+	"strings"
 	"user-service/internal/service"
 
 	"github.com/gofiber/fiber/v2"
@@ -16,6 +19,7 @@ func NewUserHandler(service service.UserService) *UserHandler {
 
 type CreateUserRequest struct {
 	Username string `json:"username" validate:"required"`
+	Email    string `json:"email" validate:"required"`
 	Password string `json:"password" validate:"required"`
 }
 
@@ -32,6 +36,16 @@ type LogoutRequest struct {
 	RefreshToken string `json:"refresh_token" validate:"required"`
 }
 
+type MFALoginRequest struct {
+	MFAToken     string `json:"mfa_token" validate:"required"`
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recovery_code"`
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
 func (h *UserHandler) CreateUser(c *fiber.Ctx) error {
 	var req CreateUserRequest
 	if err := c.BodyParser(&req); err != nil {
@@ -40,13 +54,13 @@ func (h *UserHandler) CreateUser(c *fiber.Ctx) error {
 		})
 	}
 
-	if req.Username == "" || req.Password == "" {
+	if req.Username == "" || req.Email == "" || req.Password == "" {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Username and password are required",
+			"error": "Username, email and password are required",
 		})
 	}
 
-	user, err := h.service.CreateUser(req.Username, req.Password)
+	user, err := h.service.CreateUser(c.UserContext(), req.Username, req.Email, req.Password)
 	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": err.Error(),
@@ -73,13 +87,47 @@ func (h *UserHandler) Login(c *fiber.Ctx) error {
 		})
 	}
 
-	loginResponse, err := h.service.Login(req.Username, req.Password)
+	loginResponse, err := h.service.Login(c.UserContext(), req.Username, req.Password, clientInfo(c))
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": err.Error(),
+		return h.loginError(c, err)
+	}
+
+	if loginResponse.MFARequired {
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{
+			"message":      "Two-factor authentication required",
+			"mfa_required": true,
+			"mfa_token":    loginResponse.MFAToken,
 		})
 	}
 
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message":                 "Login successful",
+		"access_token":            loginResponse.AccessToken,
+		"refresh_token":           loginResponse.RefreshToken,
+		"expires_in":              loginResponse.ExpiresIn,
+		"mfa_enrollment_required": loginResponse.MFAEnrollmentRequired,
+	})
+}
+
+func (h *UserHandler) LoginMFA(c *fiber.Ctx) error {
+	var req MFALoginRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if req.MFAToken == "" || (req.Code == "" && req.RecoveryCode == "") {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "mfa_token and either code or recovery_code are required",
+		})
+	}
+
+	loginResponse, err := h.service.VerifyMFALogin(c.UserContext(), req.MFAToken, req.Code, req.RecoveryCode, clientInfo(c))
+	if err != nil {
+		return h.loginError(c, err)
+	}
+
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
 		"message":       "Login successful",
 		"access_token":  loginResponse.AccessToken,
@@ -88,6 +136,22 @@ func (h *UserHandler) Login(c *fiber.Ctx) error {
 	})
 }
 
+// loginError maps login failures to 429 with Retry-After when throttled,
+// and 401 otherwise
+func (h *UserHandler) loginError(c *fiber.Ctx, err error) error {
+	var throttleErr *service.ThrottleError
+	if errors.As(err, &throttleErr) {
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(throttleErr.RetryAfterSeconds()))
+		return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+			"error":       err.Error(),
+			"retry_after": throttleErr.RetryAfterSeconds(),
+		})
+	}
+	return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+		"error": err.Error(),
+	})
+}
+
 func (h *UserHandler) GetAuthenticatedUser(c *fiber.Ctx) error {
 	// Get user ID from context (set by auth middleware)
 	userID, ok := c.Locals("userID").(string)
@@ -97,7 +161,7 @@ func (h *UserHandler) GetAuthenticatedUser(c *fiber.Ctx) error {
 		})
 	}
 
-	user, err := h.service.GetAuthenticatedUser(userID)
+	user, err := h.service.GetAuthenticatedUser(c.UserContext(), userID)
 	if err != nil {
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 			"error": err.Error(),
@@ -124,7 +188,7 @@ func (h *UserHandler) RefreshToken(c *fiber.Ctx) error {
 		})
 	}
 
-	refreshResponse, err := h.service.RefreshToken(req.RefreshToken)
+	refreshResponse, err := h.service.RefreshToken(c.UserContext(), req.RefreshToken, clientInfo(c))
 	if err != nil {
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 			"error": err.Error(),
@@ -152,7 +216,8 @@ func (h *UserHandler) Logout(c *fiber.Ctx) error {
 		})
 	}
 
-	err := h.service.Logout(req.RefreshToken)
+	accessToken := strings.TrimPrefix(c.Get("Authorization"), "Bearer ")
+	err := h.service.Logout(c.UserContext(), req.RefreshToken, accessToken, clientInfo(c))
 	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": err.Error(),
@@ -163,3 +228,54 @@ func (h *UserHandler) Logout(c *fiber.Ctx) error {
 		"message": "Logout successful",
 	})
 }
+
+func (h *UserHandler) VerifyEmail(c *fiber.Ctx) error {
+	var req VerifyEmailRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if req.Token == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Verification token is required",
+		})
+	}
+
+	if err := h.service.VerifyEmail(c.UserContext(), req.Token); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Email verified successfully",
+	})
+}
+
+func (h *UserHandler) ResendVerification(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(string)
+	if !ok || userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	if err := h.service.ResendVerification(c.UserContext(), userID); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Verification email sent",
+	})
+}
+
+func clientInfo(c *fiber.Ctx) service.ClientInfo {
+	return service.ClientInfo{
+		IP:        c.IP(),
+		UserAgent: c.Get(fiber.HeaderUserAgent),
+	}
+}
@@ -0,0 +1,44 @@
+package mailer
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+type logMailer struct {
+	path string
+	from string
+	mu   sync.Mutex
+}
+
+// NewLogMailer creates a mailer that appends messages to the file at path,
+// or writes them to the standard logger when path is empty.
+func NewLogMailer(path, from string) Mailer {
+	return &logMailer{path: path, from: from}
+}
+
+func (m *logMailer) Send(to, subject, body string) error {
+	if m.path == "" {
+		slog.Info("mail", "to", to, "subject", subject, "body", body)
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	f, err := os.OpenFile(m.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open mail log: %v", err)
+	}
+	defer f.Close()
+
+	entry := fmt.Sprintf("Date: %s\r\n%s\r\n\r\n", time.Now().Format(time.RFC1123Z), buildMessage(m.from, to, subject, body))
+	if _, err := f.WriteString(entry); err != nil {
+		return fmt.Errorf("failed to write mail log: %v", err)
+	}
+
+	return nil
+}
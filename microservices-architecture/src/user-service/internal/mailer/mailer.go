@@ -0,0 +1,35 @@
+package mailer
+
+import (
+	"os"
+	"strings"
+)
+
+// Mailer sends plain text emails
+type Mailer interface {
+	Send(to, subject, body string) error
+}
+
+// NewMailer creates a mailer based on the MAIL_DRIVER environment variable.
+// Supported drivers are "smtp" and "log" (default).
+func NewMailer() Mailer {
+	from := os.Getenv("MAIL_FROM")
+	if from == "" {
+		from = "no-reply@localhost"
+	}
+
+	switch strings.ToLower(os.Getenv("MAIL_DRIVER")) {
+	case "smtp":
+		host := os.Getenv("SMTP_HOST")
+		if host == "" {
+			host = "localhost"
+		}
+		port := os.Getenv("SMTP_PORT")
+		if port == "" {
+			port = "1025"
+		}
+		return NewSMTPMailer(host, port, os.Getenv("SMTP_USERNAME"), os.Getenv("SMTP_PASSWORD"), from)
+	default:
+		return NewLogMailer(os.Getenv("MAIL_LOG_PATH"), from)
+	}
+}
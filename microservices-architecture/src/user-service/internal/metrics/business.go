@@ -0,0 +1,17 @@
+package metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var loginFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "login_failures_total",
+	Help: "Failed logins, by reason such as unknown_user, invalid_password or invalid_second_factor.",
+}, []string{"reason"})
+
+func init() {
+	Registry.MustRegister(loginFailures)
+}
+
+// LoginFailure counts a failed login
+func LoginFailure(reason string) {
+	loginFailures.WithLabelValues(reason).Inc()
+}
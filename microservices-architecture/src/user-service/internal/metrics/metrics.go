@@ -0,0 +1,37 @@
+// Package metrics keeps the Prometheus metrics served on /metrics: HTTP
+// requests per route, database queries and the service's business counters.
+package metrics
+
+import (
+	"os"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Registry holds every metric of the service, along with the Go runtime
+// and process collectors
+var Registry = prometheus.NewRegistry()
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+// Enabled reports whether metrics are collected and served. They are unless
+// METRICS_ENABLED is set to false.
+func Enabled() bool {
+	enabled, err := strconv.ParseBool(os.Getenv("METRICS_ENABLED"))
+	return err != nil || enabled
+}
+
+// Handler serves the registry in the Prometheus text format
+func Handler() fiber.Handler {
+	return adaptor.HTTPHandler(promhttp.HandlerFor(Registry, promhttp.HandlerOpts{}))
+}
@@ -7,8 +7,14 @@ import (
 	"github.com/gofiber/fiber/v2"
 )
 
-// AuthMiddleware validates JWT access tokens
-func AuthMiddleware() fiber.Handler {
+// RevocationChecker reports whether an access token has been revoked,
+// either individually by jti or through its session
+type RevocationChecker interface {
+	IsRevoked(jti, sessionID string) bool
+}
+
+// AuthMiddleware validates JWT access tokens and rejects revoked ones
+func AuthMiddleware(revocations RevocationChecker) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		authHeader := c.Get("Authorization")
 		if authHeader == "" {
@@ -44,11 +50,103 @@ func AuthMiddleware() fiber.Handler {
 			})
 		}
 
+		if revocations.IsRevoked(claims.ID, claims.SessionID) {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Token has been revoked",
+			})
+		}
+
 		// Store user info in context
 		c.Locals("userID", claims.UserID)
 		c.Locals("username", claims.Username)
+		c.Locals("emailVerified", claims.EmailVerified)
+		c.Locals("role", claims.Role)
+		c.Locals("mfa", claims.MFA)
+		c.Locals("sessionID", claims.SessionID)
+		c.Locals("jti", claims.ID)
+		if claims.ExpiresAt != nil {
+			c.Locals("tokenExpiresAt", claims.ExpiresAt.Time)
+		}
 
 		return c.Next()
 	}
 }
 
+// OAuthMiddleware authenticates routes that OAuth clients may call. It
+// accepts access tokens issued to a client that carry every one of scopes,
+// and first-party access tokens as AuthMiddleware does.
+func OAuthMiddleware(revocations RevocationChecker, scopes ...string) fiber.Handler {
+	issuer := oidcIssuer()
+	firstParty := AuthMiddleware(revocations)
+
+	return func(c *fiber.Ctx) error {
+		claims, err := auth.ValidateOAuthAccessToken(strings.TrimPrefix(c.Get("Authorization"), "Bearer "), issuer)
+		if err == auth.ErrExpiredToken {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Token has expired",
+			})
+		}
+		if err != nil {
+			// Not an OAuth token; AuthMiddleware answers for anything invalid
+			return firstParty(c)
+		}
+
+		granted := strings.Fields(claims.Scope)
+		for _, scope := range scopes {
+			if !containsScope(granted, scope) {
+				return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+					"error": "Token is missing scope " + scope,
+				})
+			}
+		}
+
+		if revocations.IsRevoked(claims.ID, claims.SessionID) {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Token has been revoked",
+			})
+		}
+
+		c.Locals("userID", claims.Subject)
+		c.Locals("sessionID", claims.SessionID)
+		c.Locals("jti", claims.ID)
+		c.Locals("oauthClientID", claims.ClientID)
+		return c.Next()
+	}
+}
+
+// RequireRole allows the request only if the authenticated user has one of
+// the given roles. It must run after AuthMiddleware.
+func RequireRole(roles ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		role, _ := c.Locals("role").(string)
+		for _, allowed := range roles {
+			if role == allowed {
+				return c.Next()
+			}
+		}
+
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Insufficient permissions",
+		})
+	}
+}
+
+// RequireMFAForRoles rejects sessions that were not established with a
+// second factor when the user's role is one of roles. It must run after
+// AuthMiddleware.
+func RequireMFAForRoles(roles ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		role, _ := c.Locals("role").(string)
+		mfa, _ := c.Locals("mfa").(bool)
+
+		for _, required := range roles {
+			if role == required && !mfa {
+				return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+					"error": "Two-factor authentication is required for this account",
+				})
+			}
+		}
+
+		return c.Next()
+	}
+}
@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"platform/logging"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryRequestID is the gRPC counterpart of RequestID and AccessLog. It
+// takes the request ID from the x-request-id metadata, or assigns a new
+// one, and logs a line for every call. It goes before the other
+// interceptors.
+func UnaryRequestID() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		var id string
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if values := md.Get(logging.MetadataRequestID); len(values) > 0 {
+				id = values[0]
+			}
+		}
+		ctx = logging.WithRequestID(ctx, logging.ResolveRequestID(id))
+
+		start := time.Now()
+		resp, err := handler(ctx, req)
+
+		code := status.Code(err)
+		level := slog.LevelInfo
+		switch code {
+		case codes.Internal, codes.Unknown, codes.DataLoss, codes.Unavailable:
+			level = slog.LevelError
+		}
+		slog.LogAttrs(ctx, level, "rpc",
+			slog.String("method", info.FullMethod),
+			slog.String("code", code.String()),
+			slog.Float64("duration_ms", float64(time.Since(start).Microseconds())/1000),
+		)
+		return resp, err
+	}
+}
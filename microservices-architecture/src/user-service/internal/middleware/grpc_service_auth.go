@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"context"
+	"strings"
+	"user-service/internal/auth"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryServiceToken is the gRPC counterpart of RequireServiceToken. Calls to
+// a method listed in scopes must present a service token for this service
+// that carries the scopes listed for it. Methods missing from scopes are
+// left to authenticate their callers themselves.
+func UnaryServiceToken(scopes map[string][]string) grpc.UnaryServerInterceptor {
+	issuer := oidcIssuer()
+	audience := serviceAudience()
+
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		required, ok := scopes[info.FullMethod]
+		if !ok {
+			return handler(ctx, req)
+		}
+
+		var authHeader string
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if values := md.Get("authorization"); len(values) > 0 {
+				authHeader = values[0]
+			}
+		}
+		if !strings.HasPrefix(authHeader, "Bearer ") {
+			return nil, status.Error(codes.Unauthenticated, "service token is required")
+		}
+
+		claims, err := auth.ValidateServiceToken(strings.TrimPrefix(authHeader, "Bearer "), issuer, audience)
+		if err != nil {
+			return nil, status.Error(codes.Unauthenticated, "invalid or expired service token")
+		}
+
+		granted := strings.Fields(claims.Scope)
+		for _, scope := range required {
+			if !containsScope(granted, scope) {
+				return nil, status.Error(codes.PermissionDenied, "service token is missing scope "+scope)
+			}
+		}
+
+		return handler(ctx, req)
+	}
+}
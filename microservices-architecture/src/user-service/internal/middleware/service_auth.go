@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"os"
+	"strings"
+	"user-service/internal/auth"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RequireServiceToken only lets through callers presenting a client
+// credentials token for this service that carries every one of scopes. The
+// token must be issued by OIDC_ISSUER for the SERVICE_AUDIENCE audience
+// (default "user-service").
+func RequireServiceToken(scopes ...string) fiber.Handler {
+	issuer := oidcIssuer()
+	audience := serviceAudience()
+
+	return func(c *fiber.Ctx) error {
+		authHeader := c.Get("Authorization")
+		if !strings.HasPrefix(authHeader, "Bearer ") {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Service token is required",
+			})
+		}
+
+		claims, err := auth.ValidateServiceToken(strings.TrimPrefix(authHeader, "Bearer "), issuer, audience)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Invalid or expired service token",
+			})
+		}
+
+		granted := strings.Fields(claims.Scope)
+		for _, scope := range scopes {
+			if !containsScope(granted, scope) {
+				return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+					"error": "Service token is missing scope " + scope,
+				})
+			}
+		}
+
+		c.Locals("serviceClientID", claims.ClientID)
+		return c.Next()
+	}
+}
+
+// serviceAudience returns the audience of service tokens for user-service
+func serviceAudience() string {
+	if audience := os.Getenv("SERVICE_AUDIENCE"); audience != "" {
+		return audience
+	}
+	return "user-service"
+}
+
+// oidcIssuer returns the issuer user-service signs RS256 tokens as
+func oidcIssuer() string {
+	if issuer := os.Getenv("OIDC_ISSUER"); issuer != "" {
+		return issuer
+	}
+	return "http://localhost:3001"
+}
+
+func containsScope(scopes []string, scope string) bool {
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
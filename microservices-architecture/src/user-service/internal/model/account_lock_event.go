@@ -0,0 +1,35 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	LockActionLocked   = "LOCKED"
+	LockActionUnlocked = "UNLOCKED"
+
+	LockReasonTooManyFailures = "too_many_failures"
+	LockReasonExpired         = "expired"
+	LockReasonAdmin           = "admin"
+)
+
+type AccountLockEvent struct {
+	ID          uuid.UUID  `gorm:"type:uuid;primaryKey;" json:"id"`
+	UserID      uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	Action      string     `gorm:"type:varchar(20);not null" json:"action"` // LOCKED, UNLOCKED
+	Reason      string     `gorm:"type:varchar(50);not null" json:"reason"`
+	IP          string     `gorm:"type:varchar(64)" json:"ip,omitempty"`
+	ActorID     *uuid.UUID `gorm:"type:uuid" json:"actor_id,omitempty"`
+	LockedUntil *time.Time `json:"locked_until,omitempty"`
+	CreatedAt   time.Time  `gorm:"index" json:"created_at"`
+}
+
+func (m *AccountLockEvent) BeforeCreate(tx *gorm.DB) error {
+	if m.ID == uuid.Nil {
+		m.ID = uuid.New()
+	}
+	return nil
+}
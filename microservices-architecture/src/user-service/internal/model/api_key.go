@@ -0,0 +1,89 @@
+package model
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// API key scopes granted to partners and scripts
+const (
+	ScopeEventsRead    = "events:read"
+	ScopeBookingsWrite = "bookings:write"
+	ScopePaymentsRead  = "payments:read"
+)
+
+// APIKeyScopes lists every scope an API key may carry
+var APIKeyScopes = []string{ScopeEventsRead, ScopeBookingsWrite, ScopePaymentsRead}
+
+// APIKey lets a user's scripts call the API without logging in. Only the
+// hash of the key is stored; the prefix is kept so users can tell keys apart.
+type APIKey struct {
+	ID           uuid.UUID  `gorm:"type:uuid;primaryKey;" json:"id"`
+	UserID       uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	Name         string     `gorm:"type:varchar(100);not null" json:"name"`
+	Prefix       string     `gorm:"type:varchar(16);not null" json:"prefix"`
+	KeyHash      string     `gorm:"type:varchar(64);unique;not null" json:"-"`
+	Scopes       string     `gorm:"type:text;not null" json:"-"` // space separated
+	RequestCount int64      `gorm:"not null;default:0" json:"request_count"`
+	LastUsedAt   *time.Time `json:"last_used_at"`
+	ExpiresAt    *time.Time `json:"expires_at"`
+	RevokedAt    *time.Time `json:"revoked_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+
+	// Relation
+	User User `gorm:"foreignKey:UserID" json:"-"`
+}
+
+type APIKeyResponse struct {
+	ID           uuid.UUID  `json:"id"`
+	UserID       uuid.UUID  `json:"user_id"`
+	Name         string     `json:"name"`
+	Prefix       string     `json:"prefix"`
+	Key          string     `json:"key,omitempty"` // only returned on creation
+	Scopes       []string   `json:"scopes"`
+	RequestCount int64      `json:"request_count"`
+	LastUsedAt   *time.Time `json:"last_used_at"`
+	ExpiresAt    *time.Time `json:"expires_at"`
+	RevokedAt    *time.Time `json:"revoked_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+func (m *APIKey) BeforeCreate(tx *gorm.DB) error {
+	if m.ID == uuid.Nil {
+		m.ID = uuid.New()
+	}
+	return nil
+}
+
+// IsActive reports whether the key is neither revoked nor expired
+func (m *APIKey) IsActive() bool {
+	if m.RevokedAt != nil {
+		return false
+	}
+	return m.ExpiresAt == nil || time.Now().Before(*m.ExpiresAt)
+}
+
+// ScopeList returns the key's scopes
+func (m *APIKey) ScopeList() []string {
+	return strings.Fields(m.Scopes)
+}
+
+// ToResponse converts the key into its public representation
+func (m *APIKey) ToResponse() *APIKeyResponse {
+	return &APIKeyResponse{
+		ID:           m.ID,
+		UserID:       m.UserID,
+		Name:         m.Name,
+		Prefix:       m.Prefix,
+		Scopes:       m.ScopeList(),
+		RequestCount: m.RequestCount,
+		LastUsedAt:   m.LastUsedAt,
+		ExpiresAt:    m.ExpiresAt,
+		RevokedAt:    m.RevokedAt,
+		CreatedAt:    m.CreatedAt,
+	}
+}
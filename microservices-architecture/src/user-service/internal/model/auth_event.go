@@ -0,0 +1,52 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Authentication event types
+const (
+	AuthEventLogin           = "login"
+	AuthEventRefresh         = "token_refresh"
+	AuthEventLogout          = "logout"
+	AuthEventPasswordChange  = "password_change"
+	AuthEventPasswordReset   = "password_reset"
+	AuthEventRoleChange      = "role_change"
+	AuthEventAccountLocked   = "account_locked"
+	AuthEventAccountUnlocked = "account_unlocked"
+	AuthEventAccountDisabled = "account_disabled"
+	AuthEventAccountEnabled  = "account_enabled"
+	AuthEventAccountDeleted  = "account_deleted"
+	AuthEventAccountRestored = "account_restored"
+)
+
+const (
+	AuthOutcomeSuccess = "success"
+	AuthOutcomeFailure = "failure"
+)
+
+// AuthEvent is one entry of the security audit log. Rows are only ever
+// inserted, and removed once they fall out of the retention period.
+type AuthEvent struct {
+	ID        uuid.UUID  `gorm:"type:uuid;primaryKey;" json:"id"`
+	Type      string     `gorm:"type:varchar(32);not null;index" json:"type"`
+	Outcome   string     `gorm:"type:varchar(16);not null" json:"outcome"`
+	UserID    *uuid.UUID `gorm:"type:uuid;index" json:"user_id,omitempty"`
+	Username  string     `gorm:"type:varchar(255)" json:"username,omitempty"`
+	ActorID   *uuid.UUID `gorm:"type:uuid" json:"actor_id,omitempty"` // administrator acting on the account
+	IP        string     `gorm:"type:varchar(64);index" json:"ip,omitempty"`
+	UserAgent string     `gorm:"type:varchar(512)" json:"user_agent,omitempty"`
+	Reason    string     `gorm:"type:varchar(64)" json:"reason,omitempty"` // why a failure happened
+	Detail    string     `gorm:"type:varchar(255)" json:"detail,omitempty"`
+	CreatedAt time.Time  `gorm:"index" json:"created_at"`
+}
+
+func (m *AuthEvent) BeforeCreate(tx *gorm.DB) error {
+	if m.ID == uuid.Nil {
+		m.ID = uuid.New()
+	}
+	return nil
+}
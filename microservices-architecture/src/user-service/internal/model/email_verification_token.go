@@ -0,0 +1,38 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type EmailVerificationToken struct {
+	ID        uuid.UUID  `gorm:"type:uuid;primaryKey;" json:"id"`
+	UserID    uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	Email     string     `gorm:"type:varchar(255);not null" json:"email"`
+	TokenHash string     `gorm:"type:varchar(64);unique;not null" json:"-"`
+	ExpiresAt time.Time  `gorm:"not null" json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+
+	// Relation
+	User User `gorm:"foreignKey:UserID" json:"-"`
+}
+
+func (m *EmailVerificationToken) BeforeCreate(tx *gorm.DB) error {
+	if m.ID == uuid.Nil {
+		m.ID = uuid.New()
+	}
+	return nil
+}
+
+// IsExpired checks if the verification token has expired
+func (m *EmailVerificationToken) IsExpired() bool {
+	return time.Now().After(m.ExpiresAt)
+}
+
+// IsUsed checks if the verification token has already been consumed
+func (m *EmailVerificationToken) IsUsed() bool {
+	return m.UsedAt != nil
+}
@@ -0,0 +1,32 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// LoginFailure records a single failed login attempt. Rows are counted in a
+// sliding window per username and per IP address.
+type LoginFailure struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey;" json:"id"`
+	Username  string    `gorm:"type:varchar(255);not null;index:idx_login_failures_username_created" json:"username"`
+	IP        string    `gorm:"type:varchar(64);not null;index:idx_login_failures_ip_created" json:"ip"`
+	Cleared   bool      `gorm:"not null;default:false" json:"cleared"` // set after a successful login for the username
+	CreatedAt time.Time `gorm:"index:idx_login_failures_username_created;index:idx_login_failures_ip_created" json:"created_at"`
+}
+
+func (m *LoginFailure) BeforeCreate(tx *gorm.DB) error {
+	if m.ID == uuid.Nil {
+		m.ID = uuid.New()
+	}
+	return nil
+}
+
+// LoginFailureStats summarizes failures inside a window
+type LoginFailureStats struct {
+	Count   int64
+	FirstAt *time.Time
+	LastAt  *time.Time
+}
@@ -0,0 +1,37 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type OAuthAuthorizationCode struct {
+	ID                  uuid.UUID  `gorm:"type:uuid;primaryKey;" json:"id"`
+	CodeHash            string     `gorm:"type:varchar(64);unique;not null" json:"-"`
+	ClientID            string     `gorm:"type:varchar(64);not null" json:"client_id"`
+	UserID              uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	RedirectURI         string     `gorm:"type:text;not null" json:"redirect_uri"`
+	Scope               string     `gorm:"type:text;not null" json:"scope"`
+	Nonce               string     `gorm:"type:varchar(255)" json:"-"`
+	CodeChallenge       string     `gorm:"type:varchar(128)" json:"-"`
+	CodeChallengeMethod string     `gorm:"type:varchar(10)" json:"-"`
+	MFA                 bool       `gorm:"not null;default:false" json:"mfa"`
+	AuthTime            time.Time  `gorm:"not null" json:"auth_time"`
+	ExpiresAt           time.Time  `gorm:"not null" json:"expires_at"`
+	UsedAt              *time.Time `json:"used_at"`
+	CreatedAt           time.Time  `json:"created_at"`
+}
+
+func (m *OAuthAuthorizationCode) BeforeCreate(tx *gorm.DB) error {
+	if m.ID == uuid.Nil {
+		m.ID = uuid.New()
+	}
+	return nil
+}
+
+// IsExpired checks if the authorization code has expired
+func (m *OAuthAuthorizationCode) IsExpired() bool {
+	return time.Now().After(m.ExpiresAt)
+}
@@ -0,0 +1,100 @@
+package model
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// OAuthClient is an application allowed to use the authorization code flow
+type OAuthClient struct {
+	ID               uuid.UUID `gorm:"type:uuid;primaryKey;" json:"id"`
+	ClientID         string    `gorm:"type:varchar(64);unique;not null" json:"client_id"`
+	ClientSecretHash string    `gorm:"type:varchar(64)" json:"-"` // empty for public clients
+	Name             string    `gorm:"type:varchar(100);not null" json:"name"`
+	RedirectURIs     string    `gorm:"type:text;not null" json:"-"` // space separated
+	Scopes           string    `gorm:"type:text;not null" json:"-"` // space separated
+	Public           bool      `gorm:"not null;default:false" json:"public"`
+	FirstParty       bool      `gorm:"not null;default:false" json:"first_party"`     // skips the consent screen
+	ServiceAccount   bool      `gorm:"not null;default:false" json:"service_account"` // uses the client credentials grant
+	Audiences        string    `gorm:"type:text" json:"-"`                            // space separated, service accounts only
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+type OAuthClientResponse struct {
+	ID             uuid.UUID `json:"id"`
+	ClientID       string    `json:"client_id"`
+	ClientSecret   string    `json:"client_secret,omitempty"` // only returned on creation
+	Name           string    `json:"name"`
+	RedirectURIs   []string  `json:"redirect_uris"`
+	Scopes         []string  `json:"scopes"`
+	Public         bool      `json:"public"`
+	FirstParty     bool      `json:"first_party"`
+	ServiceAccount bool      `json:"service_account"`
+	Audiences      []string  `json:"audiences,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+func (m *OAuthClient) BeforeCreate(tx *gorm.DB) error {
+	if m.ID == uuid.Nil {
+		m.ID = uuid.New()
+	}
+	return nil
+}
+
+// HasRedirectURI reports whether uri exactly matches a registered redirect URI
+func (m *OAuthClient) HasRedirectURI(uri string) bool {
+	for _, registered := range strings.Fields(m.RedirectURIs) {
+		if registered == uri {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsScopes reports whether every requested scope is registered for the client
+func (m *OAuthClient) AllowsScopes(scopes []string) bool {
+	allowed := strings.Fields(m.Scopes)
+	for _, scope := range scopes {
+		found := false
+		for _, a := range allowed {
+			if a == scope {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// HasAudience reports whether the client may request tokens for audience
+func (m *OAuthClient) HasAudience(audience string) bool {
+	for _, registered := range strings.Fields(m.Audiences) {
+		if registered == audience {
+			return true
+		}
+	}
+	return false
+}
+
+// ToResponse converts the client into its public representation
+func (m *OAuthClient) ToResponse() *OAuthClientResponse {
+	return &OAuthClientResponse{
+		ID:             m.ID,
+		ClientID:       m.ClientID,
+		Name:           m.Name,
+		RedirectURIs:   strings.Fields(m.RedirectURIs),
+		Scopes:         strings.Fields(m.Scopes),
+		Public:         m.Public,
+		FirstParty:     m.FirstParty,
+		ServiceAccount: m.ServiceAccount,
+		Audiences:      strings.Fields(m.Audiences),
+		CreatedAt:      m.CreatedAt,
+	}
+}
@@ -0,0 +1,25 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// OAuthConsent records the scopes a user has granted to a client
+type OAuthConsent struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey;" json:"id"`
+	UserID    uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_oauth_consents_user_client" json:"user_id"`
+	ClientID  string    `gorm:"type:varchar(64);not null;uniqueIndex:idx_oauth_consents_user_client" json:"client_id"`
+	Scopes    string    `gorm:"type:text;not null" json:"scopes"` // space separated
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (m *OAuthConsent) BeforeCreate(tx *gorm.DB) error {
+	if m.ID == uuid.Nil {
+		m.ID = uuid.New()
+	}
+	return nil
+}
@@ -8,13 +8,19 @@ import (
 )
 
 type RefreshToken struct {
-	ID        uuid.UUID `gorm:"type:uuid;primaryKey;" json:"id"`
-	UserID    uuid.UUID `gorm:"type:uuid;not null;index" json:"user_id"`
-	Token     string    `gorm:"type:varchar(255);unique;not null;index" json:"token"`
-	ExpiresAt time.Time `gorm:"not null" json:"expires_at"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	
+	ID         uuid.UUID  `gorm:"type:uuid;primaryKey;" json:"id"`
+	UserID     uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	Token      string     `gorm:"type:varchar(255);unique;not null;index" json:"token"`
+	ExpiresAt  time.Time  `gorm:"not null" json:"expires_at"`
+	MFA        bool       `gorm:"not null;default:false" json:"mfa"` // session was established with a second factor
+	ClientID   string     `gorm:"type:varchar(64)" json:"client_id"` // OAuth client the session was issued to, empty for direct logins
+	Scope      string     `gorm:"type:text" json:"scope"`
+	UserAgent  string     `gorm:"type:varchar(512)" json:"user_agent"`
+	IP         string     `gorm:"type:varchar(64)" json:"ip"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+
 	// Relation
 	User User `gorm:"foreignKey:UserID" json:"-"`
 }
@@ -31,3 +37,28 @@ func (m *RefreshToken) IsExpired() bool {
 	return time.Now().After(m.ExpiresAt)
 }
 
+// SessionResponse is the public view of a refresh token
+type SessionResponse struct {
+	ID         uuid.UUID  `json:"id"`
+	UserAgent  string     `json:"user_agent"`
+	IP         string     `json:"ip"`
+	MFA        bool       `json:"mfa"`
+	Current    bool       `json:"current"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+}
+
+// ToSessionResponse converts the token into its session representation
+func (m *RefreshToken) ToSessionResponse(currentID string) SessionResponse {
+	return SessionResponse{
+		ID:         m.ID,
+		UserAgent:  m.UserAgent,
+		IP:         m.IP,
+		MFA:        m.MFA,
+		Current:    m.ID.String() == currentID,
+		CreatedAt:  m.CreatedAt,
+		LastUsedAt: m.LastUsedAt,
+		ExpiresAt:  m.ExpiresAt,
+	}
+}
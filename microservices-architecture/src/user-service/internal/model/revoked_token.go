@@ -0,0 +1,30 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	RevokedKindToken   = "jti" // a single access token
+	RevokedKindSession = "sid" // every access token issued for a session
+)
+
+// RevokedToken is a denylist entry. It only needs to live until the
+// access tokens it covers would have expired anyway.
+type RevokedToken struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey;" json:"id"`
+	Kind      string    `gorm:"type:varchar(10);not null;uniqueIndex:idx_revoked_tokens_kind_value" json:"kind"`
+	Value     string    `gorm:"type:varchar(64);not null;uniqueIndex:idx_revoked_tokens_kind_value" json:"value"`
+	ExpiresAt time.Time `gorm:"not null;index" json:"expires_at"`
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+}
+
+func (m *RevokedToken) BeforeCreate(tx *gorm.DB) error {
+	if m.ID == uuid.Nil {
+		m.ID = uuid.New()
+	}
+	return nil
+}
@@ -1,25 +1,124 @@
 package model
 
 import (
+	"time"
+
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
+const (
+	RoleUser      = "user"
+	RoleOrganizer = "organizer"
+	RoleAdmin     = "admin"
+)
+
 type User struct {
-	ID       uuid.UUID `gorm:"type:uuid;primaryKey;" json:"id"`
-	Username string    `gorm:"unique;not null" json:"username"`
-	Password string    `gorm:"not null" json:"password,omitempty"`
+	ID              uuid.UUID  `gorm:"type:uuid;primaryKey;" json:"id"`
+	Username        string     `gorm:"unique;not null" json:"username"`
+	Email           *string    `gorm:"type:varchar(255);uniqueIndex" json:"email"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+	Password        string     `gorm:"not null" json:"password,omitempty"`
+	Role            string     `gorm:"type:varchar(20);not null;default:user" json:"role"`
+	LockedUntil     *time.Time `json:"locked_until"`
+	TOTPSecret      string     `gorm:"type:text" json:"-"` // encrypted at rest
+	TOTPEnabledAt   *time.Time `json:"totp_enabled_at"`
+	TOTPLastStep    int64      `gorm:"not null;default:0" json:"-"` // last accepted time step, prevents code replay
+	DisabledAt      *time.Time `json:"disabled_at"`
+	DisabledReason  string     `gorm:"type:varchar(255)" json:"disabled_reason"`
 	gorm.Model
 }
 
 type UserResponse struct {
-	ID       string `json:"id"`
-	Username string `json:"username"`
+	ID            string `json:"id"`
+	Username      string `json:"username"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+	Role          string `json:"role"`
+	MFAEnabled    bool   `json:"mfa_enabled"`
+}
+
+// AdminUserResponse is the view of an account shown to administrators
+type AdminUserResponse struct {
+	UserResponse
+	LockedUntil    *time.Time `json:"locked_until"`
+	DisabledAt     *time.Time `json:"disabled_at"`
+	DisabledReason string     `json:"disabled_reason,omitempty"`
+	DeletedAt      *time.Time `json:"deleted_at"`
+	SessionCount   int64      `json:"session_count"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// IsValidRole reports whether role is one of the known roles
+func IsValidRole(role string) bool {
+	return role == RoleUser || role == RoleOrganizer || role == RoleAdmin
 }
 
 func (m *User) BeforeCreate(tx *gorm.DB) error {
 	if m.ID == uuid.Nil {
 		m.ID = uuid.New()
 	}
+	if m.Role == "" {
+		m.Role = RoleUser
+	}
 	return nil
 }
+
+// IsMFAEnabled reports whether TOTP two-factor authentication is active
+func (m *User) IsMFAEnabled() bool {
+	return m.TOTPEnabledAt != nil
+}
+
+// IsLocked reports whether the account is temporarily locked
+func (m *User) IsLocked() bool {
+	return m.LockedUntil != nil && time.Now().Before(*m.LockedUntil)
+}
+
+// IsDisabled reports whether an administrator has disabled the account
+func (m *User) IsDisabled() bool {
+	return m.DisabledAt != nil
+}
+
+// IsEmailVerified reports whether the user's current email address has been verified
+func (m *User) IsEmailVerified() bool {
+	return m.Email != nil && m.EmailVerifiedAt != nil
+}
+
+// GetEmail returns the user's email address, or an empty string if none is set
+func (m *User) GetEmail() string {
+	if m.Email == nil {
+		return ""
+	}
+	return *m.Email
+}
+
+// ToResponse converts the user into its public representation
+func (m *User) ToResponse() *UserResponse {
+	return &UserResponse{
+		ID:            m.ID.String(),
+		Username:      m.Username,
+		Email:         m.GetEmail(),
+		EmailVerified: m.IsEmailVerified(),
+		Role:          m.Role,
+		MFAEnabled:    m.IsMFAEnabled(),
+	}
+}
+
+// ToAdminResponse converts the user into the administrator view
+func (m *User) ToAdminResponse(sessionCount int64) *AdminUserResponse {
+	response := &AdminUserResponse{
+		UserResponse:   *m.ToResponse(),
+		LockedUntil:    m.LockedUntil,
+		DisabledAt:     m.DisabledAt,
+		DisabledReason: m.DisabledReason,
+		SessionCount:   sessionCount,
+		CreatedAt:      m.CreatedAt,
+		UpdatedAt:      m.UpdatedAt,
+	}
+	if m.DeletedAt.Valid {
+		deletedAt := m.DeletedAt.Time
+		response.DeletedAt = &deletedAt
+	}
+	return response
+}
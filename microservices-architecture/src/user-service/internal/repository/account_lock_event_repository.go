@@ -0,0 +1,36 @@
+package repository
+
+import (
+	"context"
+	"user-service/internal/model"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type AccountLockEventRepository interface {
+	Create(ctx context.Context, event *model.AccountLockEvent) error
+	FindAll(ctx context.Context, userID *uuid.UUID, limit int) ([]model.AccountLockEvent, error)
+}
+
+type accountLockEventRepository struct {
+	db *gorm.DB
+}
+
+func NewAccountLockEventRepository(db *gorm.DB) AccountLockEventRepository {
+	return &accountLockEventRepository{db: db}
+}
+
+func (r *accountLockEventRepository) Create(ctx context.Context, event *model.AccountLockEvent) error {
+	return r.db.WithContext(ctx).Create(event).Error
+}
+
+func (r *accountLockEventRepository) FindAll(ctx context.Context, userID *uuid.UUID, limit int) ([]model.AccountLockEvent, error) {
+	var events []model.AccountLockEvent
+	query := r.db.WithContext(ctx).Order("created_at DESC").Limit(limit)
+	if userID != nil {
+		query = query.Where("user_id = ?", *userID)
+	}
+	err := query.Find(&events).Error
+	return events, err
+}
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"time"
+	"user-service/internal/model"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type APIKeyRepository interface {
+	Create(ctx context.Context, key *model.APIKey) error
+	FindByKeyHash(ctx context.Context, keyHash string) (*model.APIKey, error)
+	FindByUserID(ctx context.Context, userID uuid.UUID) ([]model.APIKey, error)
+	FindAll(ctx context.Context, userID *uuid.UUID, limit int) ([]model.APIKey, error)
+	CountActiveByUserID(ctx context.Context, userID uuid.UUID) (int64, error)
+	Revoke(ctx context.Context, id uuid.UUID, userID *uuid.UUID) (bool, error)
+	RecordUsage(ctx context.Context, id uuid.UUID) error
+}
+
+type apiKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewAPIKeyRepository(db *gorm.DB) APIKeyRepository {
+	return &apiKeyRepository{db: db}
+}
+
+func (r *apiKeyRepository) Create(ctx context.Context, key *model.APIKey) error {
+	return r.db.WithContext(ctx).Create(key).Error
+}
+
+func (r *apiKeyRepository) FindByKeyHash(ctx context.Context, keyHash string) (*model.APIKey, error) {
+	var key model.APIKey
+	err := r.db.WithContext(ctx).Where("key_hash = ?", keyHash).First(&key).Error
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (r *apiKeyRepository) FindByUserID(ctx context.Context, userID uuid.UUID) ([]model.APIKey, error) {
+	var keys []model.APIKey
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("created_at DESC").Find(&keys).Error
+	return keys, err
+}
+
+func (r *apiKeyRepository) FindAll(ctx context.Context, userID *uuid.UUID, limit int) ([]model.APIKey, error) {
+	var keys []model.APIKey
+	query := r.db.WithContext(ctx).Order("created_at DESC").Limit(limit)
+	if userID != nil {
+		query = query.Where("user_id = ?", *userID)
+	}
+	err := query.Find(&keys).Error
+	return keys, err
+}
+
+func (r *apiKeyRepository) CountActiveByUserID(ctx context.Context, userID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&model.APIKey{}).
+		Where("user_id = ? AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > NOW())", userID).
+		Count(&count).Error
+	return count, err
+}
+
+// Revoke marks a key as revoked. When userID is set the key must belong to
+// that user. It reports whether an active key was found.
+func (r *apiKeyRepository) Revoke(ctx context.Context, id uuid.UUID, userID *uuid.UUID) (bool, error) {
+	query := r.db.WithContext(ctx).Model(&model.APIKey{}).Where("id = ? AND revoked_at IS NULL", id)
+	if userID != nil {
+		query = query.Where("user_id = ?", *userID)
+	}
+	result := query.Update("revoked_at", time.Now())
+	return result.RowsAffected > 0, result.Error
+}
+
+// RecordUsage counts one request made with the key
+func (r *apiKeyRepository) RecordUsage(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Model(&model.APIKey{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"request_count": gorm.Expr("request_count + 1"),
+		"last_used_at":  time.Now(),
+	}).Error
+}
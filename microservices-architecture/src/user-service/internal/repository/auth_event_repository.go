@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+	"time"
+	"user-service/internal/model"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// AuthEventFilter narrows audit log queries. Zero values match everything.
+type AuthEventFilter struct {
+	UserID  *uuid.UUID
+	Type    string
+	Outcome string
+	IP      string
+	Since   *time.Time
+	Until   *time.Time
+	Limit   int
+}
+
+// AuthEventRepository has no update method; the audit log is append-only
+type AuthEventRepository interface {
+	Create(ctx context.Context, event *model.AuthEvent) error
+	FindAll(ctx context.Context, filter AuthEventFilter) ([]model.AuthEvent, error)
+	Each(ctx context.Context, filter AuthEventFilter, fn func(event *model.AuthEvent) error) error
+	DeleteBefore(ctx context.Context, cutoff time.Time) (int64, error)
+}
+
+type authEventRepository struct {
+	db *gorm.DB
+}
+
+func NewAuthEventRepository(db *gorm.DB) AuthEventRepository {
+	return &authEventRepository{db: db}
+}
+
+func (r *authEventRepository) Create(ctx context.Context, event *model.AuthEvent) error {
+	return r.db.WithContext(ctx).Create(event).Error
+}
+
+// FindAll returns the newest matching events first
+func (r *authEventRepository) FindAll(ctx context.Context, filter AuthEventFilter) ([]model.AuthEvent, error) {
+	var events []model.AuthEvent
+	query := r.filtered(ctx, filter).Order("created_at DESC")
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+	err := query.Find(&events).Error
+	return events, err
+}
+
+// Each streams matching events oldest first without loading them all
+func (r *authEventRepository) Each(ctx context.Context, filter AuthEventFilter, fn func(event *model.AuthEvent) error) error {
+	query := r.filtered(ctx, filter).Order("created_at ASC")
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+
+	rows, err := query.Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var event model.AuthEvent
+		if err := r.db.WithContext(ctx).ScanRows(rows, &event); err != nil {
+			return err
+		}
+		if err := fn(&event); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (r *authEventRepository) DeleteBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Where("created_at < ?", cutoff).Delete(&model.AuthEvent{})
+	return result.RowsAffected, result.Error
+}
+
+func (r *authEventRepository) filtered(ctx context.Context, filter AuthEventFilter) *gorm.DB {
+	query := r.db.WithContext(ctx).Model(&model.AuthEvent{})
+	if filter.UserID != nil {
+		query = query.Where("user_id = ?", *filter.UserID)
+	}
+	if filter.Type != "" {
+		query = query.Where("type = ?", filter.Type)
+	}
+	if filter.Outcome != "" {
+		query = query.Where("outcome = ?", filter.Outcome)
+	}
+	if filter.IP != "" {
+		query = query.Where("ip = ?", filter.IP)
+	}
+	if filter.Since != nil {
+		query = query.Where("created_at >= ?", *filter.Since)
+	}
+	if filter.Until != nil {
+		query = query.Where("created_at < ?", *filter.Until)
+	}
+	return query
+}
@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+	"time"
+	"user-service/internal/model"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type EmailVerificationTokenRepository interface {
+	Create(ctx context.Context, token *model.EmailVerificationToken) error
+	FindByTokenHash(ctx context.Context, tokenHash string) (*model.EmailVerificationToken, error)
+	MarkUsed(ctx context.Context, id uuid.UUID) error
+	DeleteByUserID(ctx context.Context, userID uuid.UUID) error
+	DeleteExpired(ctx context.Context) error
+	WithTx(tx *gorm.DB) EmailVerificationTokenRepository
+}
+
+type emailVerificationTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewEmailVerificationTokenRepository(db *gorm.DB) EmailVerificationTokenRepository {
+	return &emailVerificationTokenRepository{db: db}
+}
+
+func (r *emailVerificationTokenRepository) Create(ctx context.Context, token *model.EmailVerificationToken) error {
+	return r.db.WithContext(ctx).Create(token).Error
+}
+
+func (r *emailVerificationTokenRepository) FindByTokenHash(ctx context.Context, tokenHash string) (*model.EmailVerificationToken, error) {
+	var token model.EmailVerificationToken
+	err := r.db.WithContext(ctx).Where("token_hash = ?", tokenHash).First(&token).Error
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *emailVerificationTokenRepository) MarkUsed(ctx context.Context, id uuid.UUID) error {
+	result := r.db.WithContext(ctx).Model(&model.EmailVerificationToken{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrTokenAlreadyUsed
+	}
+	return nil
+}
+
+func (r *emailVerificationTokenRepository) DeleteByUserID(ctx context.Context, userID uuid.UUID) error {
+	return r.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&model.EmailVerificationToken{}).Error
+}
+
+func (r *emailVerificationTokenRepository) DeleteExpired(ctx context.Context) error {
+	return r.db.WithContext(ctx).Where("expires_at < NOW()").Delete(&model.EmailVerificationToken{}).Error
+}
+
+func (r *emailVerificationTokenRepository) WithTx(tx *gorm.DB) EmailVerificationTokenRepository {
+	return &emailVerificationTokenRepository{db: tx}
+}
@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+	"time"
+	"user-service/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type LoginFailureRepository interface {
+	Create(ctx context.Context, failure *model.LoginFailure) error
+	StatsByUsername(ctx context.Context, username string, since time.Time) (*model.LoginFailureStats, error)
+	StatsByIP(ctx context.Context, ip string, since time.Time) (*model.LoginFailureStats, error)
+	ClearByUsername(ctx context.Context, username string) error
+	DeleteBefore(ctx context.Context, before time.Time) error
+}
+
+type loginFailureRepository struct {
+	db *gorm.DB
+}
+
+func NewLoginFailureRepository(db *gorm.DB) LoginFailureRepository {
+	return &loginFailureRepository{db: db}
+}
+
+func (r *loginFailureRepository) Create(ctx context.Context, failure *model.LoginFailure) error {
+	return r.db.WithContext(ctx).Create(failure).Error
+}
+
+// StatsByUsername ignores failures cleared by a later successful login
+func (r *loginFailureRepository) StatsByUsername(ctx context.Context, username string, since time.Time) (*model.LoginFailureStats, error) {
+	return r.stats(r.db.WithContext(ctx).Where("username = ? AND cleared = ? AND created_at > ?", username, false, since))
+}
+
+// StatsByIP counts every failure from the address, cleared or not, so that
+// logging into one account does not reset the budget for guessing others
+func (r *loginFailureRepository) StatsByIP(ctx context.Context, ip string, since time.Time) (*model.LoginFailureStats, error) {
+	return r.stats(r.db.WithContext(ctx).Where("ip = ? AND created_at > ?", ip, since))
+}
+
+func (r *loginFailureRepository) stats(query *gorm.DB) (*model.LoginFailureStats, error) {
+	var stats model.LoginFailureStats
+	err := query.Model(&model.LoginFailure{}).
+		Select("COUNT(*) AS count, MIN(created_at) AS first_at, MAX(created_at) AS last_at").
+		Scan(&stats).Error
+	if err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+func (r *loginFailureRepository) ClearByUsername(ctx context.Context, username string) error {
+	return r.db.WithContext(ctx).Model(&model.LoginFailure{}).
+		Where("username = ? AND cleared = ?", username, false).
+		Update("cleared", true).Error
+}
+
+func (r *loginFailureRepository) DeleteBefore(ctx context.Context, before time.Time) error {
+	return r.db.WithContext(ctx).Where("created_at < ?", before).Delete(&model.LoginFailure{}).Error
+}
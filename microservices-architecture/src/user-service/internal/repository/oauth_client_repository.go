@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"context"
+	"user-service/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type OAuthClientRepository interface {
+	Create(ctx context.Context, client *model.OAuthClient) error
+	FindByClientID(ctx context.Context, clientID string) (*model.OAuthClient, error)
+	FindAll(ctx context.Context) ([]model.OAuthClient, error)
+	DeleteByClientID(ctx context.Context, clientID string) error
+}
+
+type oauthClientRepository struct {
+	db *gorm.DB
+}
+
+func NewOAuthClientRepository(db *gorm.DB) OAuthClientRepository {
+	return &oauthClientRepository{db: db}
+}
+
+func (r *oauthClientRepository) Create(ctx context.Context, client *model.OAuthClient) error {
+	return r.db.WithContext(ctx).Create(client).Error
+}
+
+func (r *oauthClientRepository) FindByClientID(ctx context.Context, clientID string) (*model.OAuthClient, error) {
+	var client model.OAuthClient
+	err := r.db.WithContext(ctx).Where("client_id = ?", clientID).First(&client).Error
+	if err != nil {
+		return nil, err
+	}
+	return &client, nil
+}
+
+func (r *oauthClientRepository) FindAll(ctx context.Context) ([]model.OAuthClient, error) {
+	var clients []model.OAuthClient
+	err := r.db.WithContext(ctx).Order("created_at ASC").Find(&clients).Error
+	return clients, err
+}
+
+func (r *oauthClientRepository) DeleteByClientID(ctx context.Context, clientID string) error {
+	return r.db.WithContext(ctx).Where("client_id = ?", clientID).Delete(&model.OAuthClient{}).Error
+}
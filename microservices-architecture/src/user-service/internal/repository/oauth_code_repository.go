@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"context"
+	"time"
+	"user-service/internal/model"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type OAuthCodeRepository interface {
+	Create(ctx context.Context, code *model.OAuthAuthorizationCode) error
+	FindByCodeHash(ctx context.Context, codeHash string) (*model.OAuthAuthorizationCode, error)
+	MarkUsed(ctx context.Context, id uuid.UUID) error
+	DeleteExpired(ctx context.Context) error
+}
+
+type oauthCodeRepository struct {
+	db *gorm.DB
+}
+
+func NewOAuthCodeRepository(db *gorm.DB) OAuthCodeRepository {
+	return &oauthCodeRepository{db: db}
+}
+
+func (r *oauthCodeRepository) Create(ctx context.Context, code *model.OAuthAuthorizationCode) error {
+	return r.db.WithContext(ctx).Create(code).Error
+}
+
+func (r *oauthCodeRepository) FindByCodeHash(ctx context.Context, codeHash string) (*model.OAuthAuthorizationCode, error) {
+	var code model.OAuthAuthorizationCode
+	err := r.db.WithContext(ctx).Where("code_hash = ?", codeHash).First(&code).Error
+	if err != nil {
+		return nil, err
+	}
+	return &code, nil
+}
+
+func (r *oauthCodeRepository) MarkUsed(ctx context.Context, id uuid.UUID) error {
+	result := r.db.WithContext(ctx).Model(&model.OAuthAuthorizationCode{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrTokenAlreadyUsed
+	}
+	return nil
+}
+
+func (r *oauthCodeRepository) DeleteExpired(ctx context.Context) error {
+	return r.db.WithContext(ctx).Where("expires_at < NOW()").Delete(&model.OAuthAuthorizationCode{}).Error
+}
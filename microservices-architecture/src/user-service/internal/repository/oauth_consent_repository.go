@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"context"
+	"user-service/internal/model"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type OAuthConsentRepository interface {
+	Find(ctx context.Context, userID uuid.UUID, clientID string) (*model.OAuthConsent, error)
+	Upsert(ctx context.Context, consent *model.OAuthConsent) error
+}
+
+type oauthConsentRepository struct {
+	db *gorm.DB
+}
+
+func NewOAuthConsentRepository(db *gorm.DB) OAuthConsentRepository {
+	return &oauthConsentRepository{db: db}
+}
+
+func (r *oauthConsentRepository) Find(ctx context.Context, userID uuid.UUID, clientID string) (*model.OAuthConsent, error) {
+	var consent model.OAuthConsent
+	err := r.db.WithContext(ctx).Where("user_id = ? AND client_id = ?", userID, clientID).First(&consent).Error
+	if err != nil {
+		return nil, err
+	}
+	return &consent, nil
+}
+
+func (r *oauthConsentRepository) Upsert(ctx context.Context, consent *model.OAuthConsent) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "client_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"scopes", "updated_at"}),
+	}).Create(consent).Error
+}
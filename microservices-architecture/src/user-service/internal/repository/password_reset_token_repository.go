@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+	"user-service/internal/model"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var ErrTokenAlreadyUsed = errors.New("token already used")
+
+type PasswordResetTokenRepository interface {
+	Create(ctx context.Context, token *model.PasswordResetToken) error
+	FindByTokenHash(ctx context.Context, tokenHash string) (*model.PasswordResetToken, error)
+	MarkUsed(ctx context.Context, id uuid.UUID) error
+	DeleteByUserID(ctx context.Context, userID uuid.UUID) error
+	DeleteExpired(ctx context.Context) error
+	WithTx(tx *gorm.DB) PasswordResetTokenRepository
+}
+
+type passwordResetTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewPasswordResetTokenRepository(db *gorm.DB) PasswordResetTokenRepository {
+	return &passwordResetTokenRepository{db: db}
+}
+
+func (r *passwordResetTokenRepository) Create(ctx context.Context, token *model.PasswordResetToken) error {
+	return r.db.WithContext(ctx).Create(token).Error
+}
+
+func (r *passwordResetTokenRepository) FindByTokenHash(ctx context.Context, tokenHash string) (*model.PasswordResetToken, error) {
+	var token model.PasswordResetToken
+	err := r.db.WithContext(ctx).Where("token_hash = ?", tokenHash).First(&token).Error
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// MarkUsed consumes the token. It only succeeds once, so two concurrent
+// resets with the same token cannot both go through.
+func (r *passwordResetTokenRepository) MarkUsed(ctx context.Context, id uuid.UUID) error {
+	result := r.db.WithContext(ctx).Model(&model.PasswordResetToken{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrTokenAlreadyUsed
+	}
+	return nil
+}
+
+func (r *passwordResetTokenRepository) DeleteByUserID(ctx context.Context, userID uuid.UUID) error {
+	return r.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&model.PasswordResetToken{}).Error
+}
+
+func (r *passwordResetTokenRepository) DeleteExpired(ctx context.Context) error {
+	return r.db.WithContext(ctx).Where("expires_at < NOW()").Delete(&model.PasswordResetToken{}).Error
+}
+
+func (r *passwordResetTokenRepository) WithTx(tx *gorm.DB) PasswordResetTokenRepository {
+	return &passwordResetTokenRepository{db: tx}
+}
@@ -1,6 +1,8 @@
 package repository
 
 import (
+	"context"
+	"time"
 	"user-service/internal/model"
 
 	"github.com/google/uuid"
@@ -8,11 +10,16 @@ import (
 )
 
 type RefreshTokenRepository interface {
-	Create(token *model.RefreshToken) error
-	FindByToken(token string) (*model.RefreshToken, error)
-	DeleteByToken(token string) error
-	DeleteByUserID(userID uuid.UUID) error
-	DeleteExpired() error
+	Create(ctx context.Context, token *model.RefreshToken) error
+	FindByToken(ctx context.Context, token string) (*model.RefreshToken, error)
+	FindByID(ctx context.Context, id uuid.UUID) (*model.RefreshToken, error)
+	FindActiveByUserID(ctx context.Context, userID uuid.UUID) ([]model.RefreshToken, error)
+	CountActiveByUserID(ctx context.Context, userID uuid.UUID) (int64, error)
+	Touch(ctx context.Context, id uuid.UUID, ip, userAgent string) error
+	DeleteByToken(ctx context.Context, token string) error
+	DeleteByIDAndUserID(ctx context.Context, id, userID uuid.UUID) (bool, error)
+	DeleteByUserID(ctx context.Context, userID uuid.UUID) error
+	DeleteExpired(ctx context.Context) error
 }
 
 type refreshTokenRepository struct {
@@ -23,28 +30,68 @@ func NewRefreshTokenRepository(db *gorm.DB) RefreshTokenRepository {
 	return &refreshTokenRepository{db: db}
 }
 
-func (r *refreshTokenRepository) Create(token *model.RefreshToken) error {
-	return r.db.Create(token).Error
+func (r *refreshTokenRepository) Create(ctx context.Context, token *model.RefreshToken) error {
+	return r.db.WithContext(ctx).Create(token).Error
 }
 
-func (r *refreshTokenRepository) FindByToken(token string) (*model.RefreshToken, error) {
+func (r *refreshTokenRepository) FindByToken(ctx context.Context, token string) (*model.RefreshToken, error) {
 	var refreshToken model.RefreshToken
-	err := r.db.Where("token = ?", token).First(&refreshToken).Error
+	err := r.db.WithContext(ctx).Where("token = ?", token).First(&refreshToken).Error
 	if err != nil {
 		return nil, err
 	}
 	return &refreshToken, nil
 }
 
-func (r *refreshTokenRepository) DeleteByToken(token string) error {
-	return r.db.Where("token = ?", token).Delete(&model.RefreshToken{}).Error
+func (r *refreshTokenRepository) FindByID(ctx context.Context, id uuid.UUID) (*model.RefreshToken, error) {
+	var refreshToken model.RefreshToken
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&refreshToken).Error
+	if err != nil {
+		return nil, err
+	}
+	return &refreshToken, nil
+}
+
+func (r *refreshTokenRepository) FindActiveByUserID(ctx context.Context, userID uuid.UUID) ([]model.RefreshToken, error) {
+	var tokens []model.RefreshToken
+	err := r.db.WithContext(ctx).Where("user_id = ? AND expires_at > NOW()", userID).
+		Order("COALESCE(last_used_at, created_at) DESC").
+		Find(&tokens).Error
+	return tokens, err
 }
 
-func (r *refreshTokenRepository) DeleteByUserID(userID uuid.UUID) error {
-	return r.db.Where("user_id = ?", userID).Delete(&model.RefreshToken{}).Error
+func (r *refreshTokenRepository) CountActiveByUserID(ctx context.Context, userID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&model.RefreshToken{}).
+		Where("user_id = ? AND expires_at > NOW()", userID).
+		Count(&count).Error
+	return count, err
 }
 
-func (r *refreshTokenRepository) DeleteExpired() error {
-	return r.db.Where("expires_at < NOW()").Delete(&model.RefreshToken{}).Error
+// Touch records that the session was just used from ip and userAgent
+func (r *refreshTokenRepository) Touch(ctx context.Context, id uuid.UUID, ip, userAgent string) error {
+	return r.db.WithContext(ctx).Model(&model.RefreshToken{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"last_used_at": time.Now(),
+		"ip":           ip,
+		"user_agent":   userAgent,
+	}).Error
 }
 
+// DeleteByIDAndUserID removes a single session owned by userID and reports
+// whether it existed
+func (r *refreshTokenRepository) DeleteByIDAndUserID(ctx context.Context, id, userID uuid.UUID) (bool, error) {
+	result := r.db.WithContext(ctx).Where("id = ? AND user_id = ?", id, userID).Delete(&model.RefreshToken{})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *refreshTokenRepository) DeleteByToken(ctx context.Context, token string) error {
+	return r.db.WithContext(ctx).Where("token = ?", token).Delete(&model.RefreshToken{}).Error
+}
+
+func (r *refreshTokenRepository) DeleteByUserID(ctx context.Context, userID uuid.UUID) error {
+	return r.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&model.RefreshToken{}).Error
+}
+
+func (r *refreshTokenRepository) DeleteExpired(ctx context.Context) error {
+	return r.db.WithContext(ctx).Where("expires_at < NOW()").Delete(&model.RefreshToken{}).Error
+}
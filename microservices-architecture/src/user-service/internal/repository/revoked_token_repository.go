@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"context"
+	"time"
+	"user-service/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type RevokedTokenRepository interface {
+	Create(ctx context.Context, token *model.RevokedToken) error
+	FindActive(ctx context.Context) ([]model.RevokedToken, error)
+	FindCreatedSince(ctx context.Context, since time.Time) ([]model.RevokedToken, error)
+	DeleteExpired(ctx context.Context) error
+}
+
+type revokedTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewRevokedTokenRepository(db *gorm.DB) RevokedTokenRepository {
+	return &revokedTokenRepository{db: db}
+}
+
+// Create is idempotent; revoking the same token twice is not an error
+func (r *revokedTokenRepository) Create(ctx context.Context, token *model.RevokedToken) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(token).Error
+}
+
+func (r *revokedTokenRepository) FindActive(ctx context.Context) ([]model.RevokedToken, error) {
+	var tokens []model.RevokedToken
+	err := r.db.WithContext(ctx).Where("expires_at > NOW()").Find(&tokens).Error
+	return tokens, err
+}
+
+func (r *revokedTokenRepository) FindCreatedSince(ctx context.Context, since time.Time) ([]model.RevokedToken, error) {
+	var tokens []model.RevokedToken
+	err := r.db.WithContext(ctx).Where("created_at >= ? AND expires_at > NOW()", since).
+		Order("created_at ASC").
+		Find(&tokens).Error
+	return tokens, err
+}
+
+func (r *revokedTokenRepository) DeleteExpired(ctx context.Context) error {
+	return r.db.WithContext(ctx).Where("expires_at < NOW()").Delete(&model.RevokedToken{}).Error
+}
@@ -1,19 +1,52 @@
 package repository
 
 import (
+	"context"
+	"strings"
+	"time"
 	"user-service/internal/model"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
+// User statuses accepted by UserFilter
+const (
+	UserStatusActive   = "active"
+	UserStatusDisabled = "disabled"
+	UserStatusLocked   = "locked"
+	UserStatusDeleted  = "deleted"
+)
+
+// UserFilter narrows and paginates FindAll. Query matches the username or
+// email; an empty Status returns every account that is not deleted.
+type UserFilter struct {
+	Query   string
+	Role    string
+	Status  string
+	Page    int
+	PerPage int
+}
+
 type UserRepository interface {
-	Create(user *model.User) error
-	Update(user *model.User) error
-	FindAll() ([]model.User, error)
-	FindByID(id uuid.UUID) (*model.User, error)
-	FindByUsername(username string) (*model.User, error)
-	FindLatest() (*model.User, error)
+	Create(ctx context.Context, user *model.User) error
+	Update(ctx context.Context, user *model.User) error
+	UpdatePassword(ctx context.Context, id uuid.UUID, hashedPassword string) error
+	MarkEmailVerified(ctx context.Context, id uuid.UUID, email string) error
+	SetLockedUntil(ctx context.Context, id uuid.UUID, lockedUntil *time.Time) error
+	UpdateTOTP(ctx context.Context, id uuid.UUID, secret string, enabledAt *time.Time) error
+	AdvanceTOTPStep(ctx context.Context, id uuid.UUID, step int64) error
+	UpdateRole(ctx context.Context, id uuid.UUID, role string) error
+	SetDisabled(ctx context.Context, id uuid.UUID, disabledAt *time.Time, reason string) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	Restore(ctx context.Context, id uuid.UUID) (bool, error)
+	FindAll(ctx context.Context, filter UserFilter) ([]model.User, int64, error)
+	FindByID(ctx context.Context, id uuid.UUID) (*model.User, error)
+	FindByIDWithDeleted(ctx context.Context, id uuid.UUID) (*model.User, error)
+	FindByUsername(ctx context.Context, username string) (*model.User, error)
+	FindByEmail(ctx context.Context, email string) (*model.User, error)
+	FindLatest(ctx context.Context) (*model.User, error)
+	WithTx(tx *gorm.DB) UserRepository
 }
 
 type userRepository struct {
@@ -24,43 +57,169 @@ func NewUserRepository(db *gorm.DB) UserRepository {
 	return &userRepository{db: db}
 }
 
-func (r *userRepository) Create(user *model.User) error {
-	return r.db.Create(user).Error
+func (r *userRepository) Create(ctx context.Context, user *model.User) error {
+	return r.db.WithContext(ctx).Create(user).Error
+}
+
+func (r *userRepository) Update(ctx context.Context, user *model.User) error {
+	return r.db.WithContext(ctx).Save(user).Error
+}
+
+func (r *userRepository) UpdatePassword(ctx context.Context, id uuid.UUID, hashedPassword string) error {
+	return r.db.WithContext(ctx).Model(&model.User{}).Where("id = ?", id).Update("password", hashedPassword).Error
+}
+
+// MarkEmailVerified only verifies the address the token was issued for, so a
+// token sent before an email change cannot verify the new address
+func (r *userRepository) MarkEmailVerified(ctx context.Context, id uuid.UUID, email string) error {
+	result := r.db.WithContext(ctx).Model(&model.User{}).
+		Where("id = ? AND email = ?", id, email).
+		Update("email_verified_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *userRepository) SetLockedUntil(ctx context.Context, id uuid.UUID, lockedUntil *time.Time) error {
+	return r.db.WithContext(ctx).Model(&model.User{}).Where("id = ?", id).Update("locked_until", lockedUntil).Error
+}
+
+// UpdateTOTP stores the TOTP secret and when it was enabled. The last
+// accepted time step is kept: steps only move forward with the clock, so
+// the code that confirmed enrollment cannot be replayed afterwards.
+func (r *userRepository) UpdateTOTP(ctx context.Context, id uuid.UUID, secret string, enabledAt *time.Time) error {
+	return r.db.WithContext(ctx).Model(&model.User{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"totp_secret":     secret,
+		"totp_enabled_at": enabledAt,
+	}).Error
+}
+
+// AdvanceTOTPStep stores the last accepted time step. It fails if the step
+// is not newer than the stored one, so each code can only be used once.
+func (r *userRepository) AdvanceTOTPStep(ctx context.Context, id uuid.UUID, step int64) error {
+	result := r.db.WithContext(ctx).Model(&model.User{}).
+		Where("id = ? AND totp_last_step < ?", id, step).
+		Update("totp_last_step", step)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrTokenAlreadyUsed
+	}
+	return nil
+}
+
+func (r *userRepository) UpdateRole(ctx context.Context, id uuid.UUID, role string) error {
+	return r.db.WithContext(ctx).Model(&model.User{}).Where("id = ?", id).Update("role", role).Error
+}
+
+func (r *userRepository) SetDisabled(ctx context.Context, id uuid.UUID, disabledAt *time.Time, reason string) error {
+	return r.db.WithContext(ctx).Model(&model.User{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"disabled_at":     disabledAt,
+		"disabled_reason": reason,
+	}).Error
+}
+
+// Delete soft deletes the user; Restore undoes it
+func (r *userRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Where("id = ?", id).Delete(&model.User{}).Error
 }
 
-func (r *userRepository) Update(user *model.User) error {
-	return r.db.Save(user).Error
+// Restore reports whether a soft deleted user was found and restored
+func (r *userRepository) Restore(ctx context.Context, id uuid.UUID) (bool, error) {
+	result := r.db.WithContext(ctx).Unscoped().Model(&model.User{}).
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		Update("deleted_at", nil)
+	return result.RowsAffected > 0, result.Error
 }
 
-func (r *userRepository) FindAll() ([]model.User, error) {
+func (r *userRepository) FindAll(ctx context.Context, filter UserFilter) ([]model.User, int64, error) {
+	query := r.db.WithContext(ctx).Model(&model.User{})
+
+	switch filter.Status {
+	case UserStatusActive:
+		query = query.Where("disabled_at IS NULL AND (locked_until IS NULL OR locked_until <= NOW())")
+	case UserStatusDisabled:
+		query = query.Where("disabled_at IS NOT NULL")
+	case UserStatusLocked:
+		query = query.Where("locked_until > NOW()")
+	case UserStatusDeleted:
+		query = query.Unscoped().Where("deleted_at IS NOT NULL")
+	}
+
+	if filter.Query != "" {
+		pattern := "%" + strings.ToLower(filter.Query) + "%"
+		query = query.Where("LOWER(username) LIKE ? OR LOWER(email) LIKE ?", pattern, pattern)
+	}
+	if filter.Role != "" {
+		query = query.Where("role = ?", filter.Role)
+	}
+
+	// Count and Find each run on their own copy of the filtered query
+	query = query.Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
 	var users []model.User
-	err := r.db.Find(&users).Error
-	return users, err
+	err := query.Order("created_at DESC").
+		Offset((filter.Page - 1) * filter.PerPage).
+		Limit(filter.PerPage).
+		Find(&users).Error
+	return users, total, err
 }
 
-func (r *userRepository) FindByUsername(username string) (*model.User, error) {
+func (r *userRepository) FindByUsername(ctx context.Context, username string) (*model.User, error) {
 	var user model.User
-	err := r.db.Where("username = ?", username).First(&user).Error
+	err := r.db.WithContext(ctx).Where("username = ?", username).First(&user).Error
 	if err != nil {
 		return nil, err
 	}
 	return &user, nil
 }
 
-func (r *userRepository) FindLatest() (*model.User, error) {
+func (r *userRepository) FindByEmail(ctx context.Context, email string) (*model.User, error) {
 	var user model.User
-	err := r.db.Order("created_at DESC").First(&user).Error
+	err := r.db.WithContext(ctx).Where("email = ?", email).First(&user).Error
 	if err != nil {
 		return nil, err
 	}
 	return &user, nil
 }
 
-func (r *userRepository) FindByID(id uuid.UUID) (*model.User, error) {
+func (r *userRepository) FindLatest(ctx context.Context) (*model.User, error) {
 	var user model.User
-	err := r.db.Where("id = ?", id).First(&user).Error
+	err := r.db.WithContext(ctx).Order("created_at DESC").First(&user).Error
 	if err != nil {
 		return nil, err
 	}
 	return &user, nil
 }
+
+func (r *userRepository) FindByID(ctx context.Context, id uuid.UUID) (*model.User, error) {
+	var user model.User
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&user).Error
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *userRepository) FindByIDWithDeleted(ctx context.Context, id uuid.UUID) (*model.User, error) {
+	var user model.User
+	err := r.db.WithContext(ctx).Unscoped().Where("id = ?", id).First(&user).Error
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *userRepository) WithTx(tx *gorm.DB) UserRepository {
+	return &userRepository{db: tx}
+}
@@ -0,0 +1,278 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+	"user-service/internal/model"
+	"user-service/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultUsersPerPage = 20
+	maxUsersPerPage     = 100
+)
+
+var (
+	ErrUserNotFound     = errors.New("user not found")
+	ErrSelfModification = errors.New("administrators cannot change their own account this way")
+)
+
+// UserPage is one page of FindAll results
+type UserPage struct {
+	Users      []model.AdminUserResponse `json:"users"`
+	Page       int                       `json:"page"`
+	PerPage    int                       `json:"per_page"`
+	Total      int64                     `json:"total"`
+	TotalPages int64                     `json:"total_pages"`
+}
+
+// AdminUserService lets administrators look up and act on accounts
+type AdminUserService interface {
+	ListUsers(ctx context.Context, filter repository.UserFilter) (*UserPage, error)
+	GetUser(ctx context.Context, userID string) (*model.AdminUserResponse, error)
+	DisableUser(ctx context.Context, userID, actorID, reason string, client ClientInfo) (*model.AdminUserResponse, error)
+	EnableUser(ctx context.Context, userID, actorID string, client ClientInfo) (*model.AdminUserResponse, error)
+	DeleteUser(ctx context.Context, userID, actorID string, client ClientInfo) error
+	RestoreUser(ctx context.Context, userID, actorID string, client ClientInfo) (*model.AdminUserResponse, error)
+	SetRole(ctx context.Context, userID, actorID, role string, client ClientInfo) (*model.AdminUserResponse, error)
+}
+
+type adminUserService struct {
+	repo        repository.UserRepository
+	refreshRepo repository.RefreshTokenRepository
+	sessions    SessionService
+	events      AuthEventService
+}
+
+func NewAdminUserService(
+	repo repository.UserRepository,
+	refreshRepo repository.RefreshTokenRepository,
+	sessions SessionService,
+	events AuthEventService,
+) AdminUserService {
+	return &adminUserService{
+		repo:        repo,
+		refreshRepo: refreshRepo,
+		sessions:    sessions,
+		events:      events,
+	}
+}
+
+func (s *adminUserService) ListUsers(ctx context.Context, filter repository.UserFilter) (*UserPage, error) {
+	switch filter.Status {
+	case "", repository.UserStatusActive, repository.UserStatusDisabled,
+		repository.UserStatusLocked, repository.UserStatusDeleted:
+	default:
+		return nil, errors.New("status must be one of active, disabled, locked or deleted")
+	}
+
+	if filter.Role != "" && !model.IsValidRole(filter.Role) {
+		return nil, errors.New("unknown role")
+	}
+
+	filter.Query = strings.TrimSpace(filter.Query)
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.PerPage < 1 {
+		filter.PerPage = defaultUsersPerPage
+	}
+	if filter.PerPage > maxUsersPerPage {
+		filter.PerPage = maxUsersPerPage
+	}
+
+	users, total, err := s.repo.FindAll(ctx, filter)
+	if err != nil {
+		return nil, errors.New("failed to retrieve users")
+	}
+
+	page := &UserPage{
+		Users:      make([]model.AdminUserResponse, 0, len(users)),
+		Page:       filter.Page,
+		PerPage:    filter.PerPage,
+		Total:      total,
+		TotalPages: (total + int64(filter.PerPage) - 1) / int64(filter.PerPage),
+	}
+	for i := range users {
+		page.Users = append(page.Users, *s.toResponse(ctx, &users[i]))
+	}
+
+	return page, nil
+}
+
+func (s *adminUserService) GetUser(ctx context.Context, userID string) (*model.AdminUserResponse, error) {
+	user, err := s.findUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.toResponse(ctx, user), nil
+}
+
+// DisableUser blocks login, refresh and API key use for the account and
+// ends its sessions, which also revokes access tokens already issued
+func (s *adminUserService) DisableUser(ctx context.Context, userID, actorID, reason string, client ClientInfo) (*model.AdminUserResponse, error) {
+	user, err := s.findUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.DeletedAt.Valid {
+		return nil, ErrUserNotFound
+	}
+	if user.ID.String() == actorID {
+		return nil, ErrSelfModification
+	}
+
+	if !user.IsDisabled() {
+		now := time.Now()
+		reason = truncate(strings.TrimSpace(reason), 255)
+		if err := s.repo.SetDisabled(ctx, user.ID, &now, reason); err != nil {
+			return nil, errors.New("failed to disable user")
+		}
+		user.DisabledAt = &now
+		user.DisabledReason = reason
+		s.record(ctx, model.AuthEventAccountDisabled, user, actorID, client, reason)
+	}
+
+	if err := s.sessions.RevokeAllSessions(ctx, user.ID.String()); err != nil {
+		return nil, err
+	}
+
+	return s.toResponse(ctx, user), nil
+}
+
+func (s *adminUserService) EnableUser(ctx context.Context, userID, actorID string, client ClientInfo) (*model.AdminUserResponse, error) {
+	user, err := s.findUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.DeletedAt.Valid {
+		return nil, ErrUserNotFound
+	}
+
+	if user.IsDisabled() {
+		if err := s.repo.SetDisabled(ctx, user.ID, nil, ""); err != nil {
+			return nil, errors.New("failed to enable user")
+		}
+		user.DisabledAt = nil
+		user.DisabledReason = ""
+		s.record(ctx, model.AuthEventAccountEnabled, user, actorID, client, "")
+	}
+
+	return s.toResponse(ctx, user), nil
+}
+
+// DeleteUser soft deletes the account after ending its sessions. The row is
+// kept so that RestoreUser can bring it back.
+func (s *adminUserService) DeleteUser(ctx context.Context, userID, actorID string, client ClientInfo) error {
+	user, err := s.findUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user.DeletedAt.Valid {
+		return ErrUserNotFound
+	}
+	if user.ID.String() == actorID {
+		return ErrSelfModification
+	}
+
+	if err := s.sessions.RevokeAllSessions(ctx, user.ID.String()); err != nil {
+		return err
+	}
+
+	if err := s.repo.Delete(ctx, user.ID); err != nil {
+		return errors.New("failed to delete user")
+	}
+	s.record(ctx, model.AuthEventAccountDeleted, user, actorID, client, "")
+
+	return nil
+}
+
+func (s *adminUserService) RestoreUser(ctx context.Context, userID, actorID string, client ClientInfo) (*model.AdminUserResponse, error) {
+	parsedID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, errors.New("invalid user ID")
+	}
+
+	restored, err := s.repo.Restore(ctx, parsedID)
+	if err != nil {
+		return nil, errors.New("failed to restore user")
+	}
+	if !restored {
+		return nil, errors.New("user is not deleted")
+	}
+
+	user, err := s.findUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	s.record(ctx, model.AuthEventAccountRestored, user, actorID, client, "")
+
+	return s.toResponse(ctx, user), nil
+}
+
+// SetRole changes the account's role. Sessions are ended because issued
+// access tokens carry the old role.
+func (s *adminUserService) SetRole(ctx context.Context, userID, actorID, role string, client ClientInfo) (*model.AdminUserResponse, error) {
+	if !model.IsValidRole(role) {
+		return nil, errors.New("unknown role")
+	}
+
+	user, err := s.findUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.DeletedAt.Valid {
+		return nil, ErrUserNotFound
+	}
+	if user.ID.String() == actorID {
+		return nil, ErrSelfModification
+	}
+
+	if user.Role != role {
+		if err := s.repo.UpdateRole(ctx, user.ID, role); err != nil {
+			return nil, errors.New("failed to update role")
+		}
+		s.record(ctx, model.AuthEventRoleChange, user, actorID, client, user.Role+" -> "+role)
+		user.Role = role
+
+		if err := s.sessions.RevokeAllSessions(ctx, user.ID.String()); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.toResponse(ctx, user), nil
+}
+
+// record adds an administrative action on user to the audit log
+func (s *adminUserService) record(ctx context.Context, eventType string, user *model.User, actorID string, client ClientInfo, detail string) {
+	event := withActor(newAuthEvent(eventType, user, client, ""), actorID)
+	event.Detail = detail
+	s.events.Record(ctx, event)
+}
+
+// findUser includes soft deleted accounts
+func (s *adminUserService) findUser(ctx context.Context, userID string) (*model.User, error) {
+	parsedID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, errors.New("invalid user ID")
+	}
+
+	user, err := s.repo.FindByIDWithDeleted(ctx, parsedID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+
+	return user, nil
+}
+
+func (s *adminUserService) toResponse(ctx context.Context, user *model.User) *model.AdminUserResponse {
+	var sessionCount int64
+	if !user.DeletedAt.Valid {
+		sessionCount, _ = s.refreshRepo.CountActiveByUserID(ctx, user.ID)
+	}
+	return user.ToAdminResponse(sessionCount)
+}
@@ -0,0 +1,206 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+	"user-service/internal/auth"
+	"user-service/internal/model"
+	"user-service/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+const (
+	apiKeyPrefix       = "ina_"
+	apiKeyDisplayChars = 12 // apiKeyPrefix plus the first 8 characters of the secret
+	maxAPIKeysPerUser  = 25
+)
+
+var (
+	ErrAPIKeyNotFound = errors.New("API key not found")
+	ErrInvalidAPIKey  = errors.New("invalid or revoked API key")
+)
+
+type CreateAPIKeyRequest struct {
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// APIKeyIntrospection describes the owner and scopes of a presented key
+type APIKeyIntrospection struct {
+	KeyID  uuid.UUID           `json:"key_id"`
+	Scopes []string            `json:"scopes"`
+	User   *model.UserResponse `json:"user"`
+}
+
+type APIKeyService interface {
+	CreateAPIKey(ctx context.Context, userID string, req CreateAPIKeyRequest) (*model.APIKeyResponse, error)
+	ListAPIKeys(ctx context.Context, userID string) ([]model.APIKeyResponse, error)
+	RevokeAPIKey(ctx context.Context, userID, keyID string) error
+	ListAllAPIKeys(ctx context.Context, userID *uuid.UUID, limit int) ([]model.APIKeyResponse, error)
+	RevokeAnyAPIKey(ctx context.Context, keyID string) error
+	Introspect(ctx context.Context, key string) (*APIKeyIntrospection, error)
+}
+
+type apiKeyService struct {
+	repo    repository.UserRepository
+	keyRepo repository.APIKeyRepository
+}
+
+func NewAPIKeyService(repo repository.UserRepository, keyRepo repository.APIKeyRepository) APIKeyService {
+	return &apiKeyService{
+		repo:    repo,
+		keyRepo: keyRepo,
+	}
+}
+
+func (s *apiKeyService) CreateAPIKey(ctx context.Context, userID string, req CreateAPIKeyRequest) (*model.APIKeyResponse, error) {
+	parsedID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, errors.New("invalid user ID")
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, errors.New("name is required")
+	}
+
+	if len(req.Scopes) == 0 {
+		return nil, errors.New("at least one scope is required")
+	}
+	for _, scope := range req.Scopes {
+		if !containsScope(model.APIKeyScopes, scope) {
+			return nil, errors.New("unsupported scope: " + scope)
+		}
+	}
+
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, errors.New("expires_at must be in the future")
+	}
+
+	count, err := s.keyRepo.CountActiveByUserID(ctx, parsedID)
+	if err != nil {
+		return nil, errors.New("failed to create API key")
+	}
+	if count >= maxAPIKeysPerUser {
+		return nil, errors.New("too many active API keys, revoke one first")
+	}
+
+	secret, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		return nil, errors.New("failed to generate API key")
+	}
+	key := apiKeyPrefix + secret
+
+	apiKey := &model.APIKey{
+		UserID:    parsedID,
+		Name:      name,
+		Prefix:    key[:apiKeyDisplayChars],
+		KeyHash:   auth.HashToken(key),
+		Scopes:    strings.Join(req.Scopes, " "),
+		ExpiresAt: req.ExpiresAt,
+	}
+
+	if err := s.keyRepo.Create(ctx, apiKey); err != nil {
+		return nil, errors.New("failed to create API key")
+	}
+
+	response := apiKey.ToResponse()
+	response.Key = key
+	return response, nil
+}
+
+func (s *apiKeyService) ListAPIKeys(ctx context.Context, userID string) ([]model.APIKeyResponse, error) {
+	parsedID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, errors.New("invalid user ID")
+	}
+
+	keys, err := s.keyRepo.FindByUserID(ctx, parsedID)
+	if err != nil {
+		return nil, errors.New("failed to retrieve API keys")
+	}
+
+	return toAPIKeyResponses(keys), nil
+}
+
+func (s *apiKeyService) RevokeAPIKey(ctx context.Context, userID, keyID string) error {
+	parsedID, err := uuid.Parse(userID)
+	if err != nil {
+		return errors.New("invalid user ID")
+	}
+	return s.revoke(ctx, keyID, &parsedID)
+}
+
+func (s *apiKeyService) ListAllAPIKeys(ctx context.Context, userID *uuid.UUID, limit int) ([]model.APIKeyResponse, error) {
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+
+	keys, err := s.keyRepo.FindAll(ctx, userID, limit)
+	if err != nil {
+		return nil, errors.New("failed to retrieve API keys")
+	}
+
+	return toAPIKeyResponses(keys), nil
+}
+
+func (s *apiKeyService) RevokeAnyAPIKey(ctx context.Context, keyID string) error {
+	return s.revoke(ctx, keyID, nil)
+}
+
+func (s *apiKeyService) revoke(ctx context.Context, keyID string, userID *uuid.UUID) error {
+	parsedKeyID, err := uuid.Parse(keyID)
+	if err != nil {
+		return errors.New("invalid API key ID")
+	}
+
+	found, err := s.keyRepo.Revoke(ctx, parsedKeyID, userID)
+	if err != nil {
+		return errors.New("failed to revoke API key")
+	}
+	if !found {
+		return ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+// Introspect resolves a key presented to another service and counts the
+// request against it
+func (s *apiKeyService) Introspect(ctx context.Context, key string) (*APIKeyIntrospection, error) {
+	if !strings.HasPrefix(key, apiKeyPrefix) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	apiKey, err := s.keyRepo.FindByKeyHash(ctx, auth.HashToken(key))
+	if err != nil || !apiKey.IsActive() {
+		return nil, ErrInvalidAPIKey
+	}
+
+	user, err := s.repo.FindByID(ctx, apiKey.UserID)
+	if err != nil || user.IsDisabled() {
+		return nil, ErrInvalidAPIKey
+	}
+
+	if err := s.keyRepo.RecordUsage(ctx, apiKey.ID); err != nil {
+		slog.ErrorContext(ctx, "failed to record API key usage", "key_id", apiKey.ID, "error", err)
+	}
+
+	return &APIKeyIntrospection{
+		KeyID:  apiKey.ID,
+		Scopes: apiKey.ScopeList(),
+		User:   user.ToResponse(),
+	}, nil
+}
+
+func toAPIKeyResponses(keys []model.APIKey) []model.APIKeyResponse {
+	responses := make([]model.APIKeyResponse, 0, len(keys))
+	for i := range keys {
+		responses = append(responses, *keys[i].ToResponse())
+	}
+	return responses
+}
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"time"
+	"user-service/internal/model"
+	"user-service/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultAuthEventLimit = 100
+	maxAuthEventLimit     = 1000
+)
+
+// AuthEventService records and queries the security audit log
+type AuthEventService interface {
+	// Record stores event. Failures are logged rather than returned so that
+	// auditing never breaks the flow being audited.
+	Record(ctx context.Context, event *model.AuthEvent)
+	List(ctx context.Context, filter repository.AuthEventFilter) ([]model.AuthEvent, error)
+	// Export writes every matching event to w as newline delimited JSON
+	Export(ctx context.Context, filter repository.AuthEventFilter, w io.Writer) error
+	// Cleanup deletes events older than the retention period
+	Cleanup(ctx context.Context) error
+}
+
+type authEventService struct {
+	repo      repository.AuthEventRepository
+	retention time.Duration
+}
+
+// NewAuthEventService keeps events for AUTH_EVENT_RETENTION_DAYS days
+// (default 365). Zero or less keeps them forever.
+func NewAuthEventService(repo repository.AuthEventRepository) AuthEventService {
+	return &authEventService{
+		repo:      repo,
+		retention: time.Duration(getEnvInt("AUTH_EVENT_RETENTION_DAYS", 365)) * 24 * time.Hour,
+	}
+}
+
+// newAuthEvent describes an event concerning user, which may be nil when the
+// account is unknown. A non-empty reason marks the event as a failure.
+func newAuthEvent(eventType string, user *model.User, client ClientInfo, reason string) *model.AuthEvent {
+	event := &model.AuthEvent{
+		Type:      eventType,
+		Outcome:   model.AuthOutcomeSuccess,
+		IP:        client.IP,
+		UserAgent: client.UserAgent,
+		Reason:    reason,
+	}
+	if reason != "" {
+		event.Outcome = model.AuthOutcomeFailure
+	}
+	if user != nil {
+		userID := user.ID
+		event.UserID = &userID
+		event.Username = user.Username
+	}
+	return event
+}
+
+// withActor sets the administrator who performed the event
+func withActor(event *model.AuthEvent, actorID string) *model.AuthEvent {
+	if parsedID, err := uuid.Parse(actorID); err == nil {
+		event.ActorID = &parsedID
+	}
+	return event
+}
+
+func (s *authEventService) Record(ctx context.Context, event *model.AuthEvent) {
+	event.Username = truncate(event.Username, 255)
+	event.UserAgent = truncate(event.UserAgent, 512)
+	event.Reason = truncate(event.Reason, 64)
+	event.Detail = truncate(event.Detail, 255)
+
+	if err := s.repo.Create(ctx, event); err != nil {
+		slog.ErrorContext(ctx, "failed to record auth event", "type", event.Type, "outcome", event.Outcome, "error", err)
+	}
+}
+
+func (s *authEventService) List(ctx context.Context, filter repository.AuthEventFilter) ([]model.AuthEvent, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = defaultAuthEventLimit
+	}
+	if filter.Limit > maxAuthEventLimit {
+		filter.Limit = maxAuthEventLimit
+	}
+
+	events, err := s.repo.FindAll(ctx, filter)
+	if err != nil {
+		return nil, errors.New("failed to retrieve auth events")
+	}
+	return events, nil
+}
+
+func (s *authEventService) Export(ctx context.Context, filter repository.AuthEventFilter, w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	return s.repo.Each(ctx, filter, func(event *model.AuthEvent) error {
+		return encoder.Encode(event)
+	})
+}
+
+func (s *authEventService) Cleanup(ctx context.Context) error {
+	if s.retention <= 0 {
+		return nil
+	}
+
+	deleted, err := s.repo.DeleteBefore(ctx, time.Now().Add(-s.retention))
+	if err != nil {
+		return err
+	}
+	if deleted > 0 {
+		slog.InfoContext(ctx, "Deleted auth events past retention", "count", deleted)
+	}
+	return nil
+}
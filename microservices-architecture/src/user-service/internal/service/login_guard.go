@@ -0,0 +1,252 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+	"user-service/internal/model"
+	"user-service/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// ThrottleError is returned when a login attempt is rejected before the
+// password is checked
+type ThrottleError struct {
+	RetryAfter time.Duration
+	Locked     bool
+}
+
+func (e *ThrottleError) Error() string {
+	if e.Locked {
+		return "account is temporarily locked"
+	}
+	return "too many login attempts, please try again later"
+}
+
+// RetryAfterSeconds returns the wait time rounded up to whole seconds
+func (e *ThrottleError) RetryAfterSeconds() int {
+	seconds := int((e.RetryAfter + time.Second - 1) / time.Second)
+	if seconds < 1 {
+		return 1
+	}
+	return seconds
+}
+
+type LoginGuardConfig struct {
+	Window          time.Duration // sliding window failures are counted in
+	MaxFailures     int           // failures per username before the account is locked
+	LockoutDuration time.Duration
+	IPMaxFailures   int // failures per IP before the address is throttled
+	DelayAfter      int // failures per username before progressive delays start
+	DelayBase       time.Duration
+	DelayMax        time.Duration
+}
+
+// LoadLoginGuardConfig reads the brute-force protection settings from the environment
+func LoadLoginGuardConfig() LoginGuardConfig {
+	return LoginGuardConfig{
+		Window:          getEnvDuration("LOGIN_WINDOW", 15*time.Minute),
+		MaxFailures:     getEnvInt("LOGIN_MAX_FAILURES", 10),
+		LockoutDuration: getEnvDuration("LOGIN_LOCKOUT_DURATION", 15*time.Minute),
+		IPMaxFailures:   getEnvInt("LOGIN_IP_MAX_FAILURES", 50),
+		DelayAfter:      getEnvInt("LOGIN_DELAY_AFTER", 3),
+		DelayBase:       getEnvDuration("LOGIN_DELAY_BASE", time.Second),
+		DelayMax:        getEnvDuration("LOGIN_DELAY_MAX", 30*time.Second),
+	}
+}
+
+type LoginGuard interface {
+	Check(ctx context.Context, user *model.User, username, ip string) error
+	RecordFailure(ctx context.Context, user *model.User, username, ip string)
+	RecordSuccess(ctx context.Context, username string)
+	Unlock(ctx context.Context, userID uuid.UUID, actorID string, client ClientInfo) error
+	ListLockEvents(ctx context.Context, userID *uuid.UUID, limit int) ([]model.AccountLockEvent, error)
+	Cleanup(ctx context.Context) error
+}
+
+type loginGuard struct {
+	cfg         LoginGuardConfig
+	userRepo    repository.UserRepository
+	failureRepo repository.LoginFailureRepository
+	lockRepo    repository.AccountLockEventRepository
+	events      AuthEventService
+}
+
+func NewLoginGuard(
+	cfg LoginGuardConfig,
+	userRepo repository.UserRepository,
+	failureRepo repository.LoginFailureRepository,
+	lockRepo repository.AccountLockEventRepository,
+	events AuthEventService,
+) LoginGuard {
+	return &loginGuard{
+		cfg:         cfg,
+		userRepo:    userRepo,
+		failureRepo: failureRepo,
+		lockRepo:    lockRepo,
+		events:      events,
+	}
+}
+
+// Check rejects the attempt if the IP is over its budget, the account is
+// locked, or the progressive delay since the last failure has not elapsed.
+// user may be nil when the username does not exist.
+func (g *loginGuard) Check(ctx context.Context, user *model.User, username, ip string) error {
+	now := time.Now()
+	since := now.Add(-g.cfg.Window)
+
+	ipStats, err := g.failureRepo.StatsByIP(ctx, ip, since)
+	if err != nil {
+		return err
+	}
+	if g.cfg.IPMaxFailures > 0 && ipStats.Count >= int64(g.cfg.IPMaxFailures) && ipStats.FirstAt != nil {
+		return &ThrottleError{RetryAfter: ipStats.FirstAt.Add(g.cfg.Window).Sub(now)}
+	}
+
+	if user != nil && user.LockedUntil != nil {
+		if user.IsLocked() {
+			return &ThrottleError{RetryAfter: user.LockedUntil.Sub(now), Locked: true}
+		}
+
+		// The lock has run out; clear it and record the time-based unlock
+		if err := g.userRepo.SetLockedUntil(ctx, user.ID, nil); err != nil {
+			return err
+		}
+		g.recordEvent(ctx, user.ID, model.LockActionUnlocked, model.LockReasonExpired, ip, nil, nil)
+		event := newAuthEvent(model.AuthEventAccountUnlocked, user, ClientInfo{IP: ip}, "")
+		event.Detail = model.LockReasonExpired
+		g.events.Record(ctx, event)
+		user.LockedUntil = nil
+	}
+
+	userStats, err := g.failureRepo.StatsByUsername(ctx, normalizeUsername(username), since)
+	if err != nil {
+		return err
+	}
+	if delay := g.delayFor(userStats.Count); delay > 0 && userStats.LastAt != nil {
+		if wait := userStats.LastAt.Add(delay).Sub(now); wait > 0 {
+			return &ThrottleError{RetryAfter: wait}
+		}
+	}
+
+	return nil
+}
+
+func (g *loginGuard) RecordFailure(ctx context.Context, user *model.User, username, ip string) {
+	failure := &model.LoginFailure{
+		Username: normalizeUsername(username),
+		IP:       ip,
+	}
+	if err := g.failureRepo.Create(ctx, failure); err != nil {
+		slog.ErrorContext(ctx, "failed to record login failure", "error", err)
+		return
+	}
+
+	if user == nil || g.cfg.MaxFailures <= 0 {
+		return
+	}
+
+	stats, err := g.failureRepo.StatsByUsername(ctx, failure.Username, time.Now().Add(-g.cfg.Window))
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to count login failures", "error", err)
+		return
+	}
+
+	if stats.Count < int64(g.cfg.MaxFailures) {
+		return
+	}
+
+	lockedUntil := time.Now().Add(g.cfg.LockoutDuration)
+	if err := g.userRepo.SetLockedUntil(ctx, user.ID, &lockedUntil); err != nil {
+		slog.ErrorContext(ctx, "failed to lock account", "user_id", user.ID, "error", err)
+		return
+	}
+	if err := g.failureRepo.ClearByUsername(ctx, failure.Username); err != nil {
+		slog.ErrorContext(ctx, "failed to clear login failures", "error", err)
+	}
+	g.recordEvent(ctx, user.ID, model.LockActionLocked, model.LockReasonTooManyFailures, ip, nil, &lockedUntil)
+	event := newAuthEvent(model.AuthEventAccountLocked, user, ClientInfo{IP: ip}, "")
+	event.Detail = model.LockReasonTooManyFailures
+	g.events.Record(ctx, event)
+}
+
+func (g *loginGuard) RecordSuccess(ctx context.Context, username string) {
+	if err := g.failureRepo.ClearByUsername(ctx, normalizeUsername(username)); err != nil {
+		slog.ErrorContext(ctx, "failed to clear login failures", "error", err)
+	}
+}
+
+func (g *loginGuard) Unlock(ctx context.Context, userID uuid.UUID, actorID string, client ClientInfo) error {
+	user, err := g.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return errors.New("user not found")
+	}
+
+	actor, err := uuid.Parse(actorID)
+	if err != nil {
+		return errors.New("invalid actor ID")
+	}
+
+	if err := g.userRepo.SetLockedUntil(ctx, user.ID, nil); err != nil {
+		return errors.New("failed to unlock account")
+	}
+	if err := g.failureRepo.ClearByUsername(ctx, normalizeUsername(user.Username)); err != nil {
+		return errors.New("failed to unlock account")
+	}
+
+	g.recordEvent(ctx, user.ID, model.LockActionUnlocked, model.LockReasonAdmin, "", &actor, nil)
+	event := withActor(newAuthEvent(model.AuthEventAccountUnlocked, user, client, ""), actorID)
+	event.Detail = model.LockReasonAdmin
+	g.events.Record(ctx, event)
+	return nil
+}
+
+func (g *loginGuard) ListLockEvents(ctx context.Context, userID *uuid.UUID, limit int) ([]model.AccountLockEvent, error) {
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+	return g.lockRepo.FindAll(ctx, userID, limit)
+}
+
+// Cleanup drops failures that have left the counting window
+func (g *loginGuard) Cleanup(ctx context.Context) error {
+	return g.failureRepo.DeleteBefore(ctx, time.Now().Add(-g.cfg.Window))
+}
+
+// delayFor returns the minimum wait after the last failure, doubling for
+// every failure past DelayAfter and capped at DelayMax
+func (g *loginGuard) delayFor(failures int64) time.Duration {
+	if g.cfg.DelayAfter <= 0 || failures < int64(g.cfg.DelayAfter) {
+		return 0
+	}
+
+	delay := g.cfg.DelayBase
+	for i := int64(g.cfg.DelayAfter); i < failures && delay < g.cfg.DelayMax; i++ {
+		delay *= 2
+	}
+	if delay > g.cfg.DelayMax {
+		delay = g.cfg.DelayMax
+	}
+	return delay
+}
+
+func (g *loginGuard) recordEvent(ctx context.Context, userID uuid.UUID, action, reason, ip string, actorID *uuid.UUID, lockedUntil *time.Time) {
+	event := &model.AccountLockEvent{
+		UserID:      userID,
+		Action:      action,
+		Reason:      reason,
+		IP:          ip,
+		ActorID:     actorID,
+		LockedUntil: lockedUntil,
+	}
+	if err := g.lockRepo.Create(ctx, event); err != nil {
+		slog.ErrorContext(ctx, "failed to record account event", "action", strings.ToLower(action), "user_id", userID, "error", err)
+	}
+}
+
+func normalizeUsername(username string) string {
+	return strings.ToLower(strings.TrimSpace(username))
+}
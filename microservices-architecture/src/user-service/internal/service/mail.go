@@ -0,0 +1,39 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/mail"
+	"strings"
+	"user-service/internal/mailer"
+)
+
+// normalizeEmail trims and lowercases an email address and checks that it is
+// a bare address without a display name
+func normalizeEmail(email string) (string, error) {
+	normalized := strings.ToLower(strings.TrimSpace(email))
+	if normalized == "" {
+		return "", errors.New("email is required")
+	}
+
+	addr, err := mail.ParseAddress(normalized)
+	if err != nil || addr.Address != normalized {
+		return "", errors.New("invalid email address")
+	}
+
+	return normalized, nil
+}
+
+// sendMail delivers a message in the background and logs failures, so
+// callers never block on (or leak timing information from) the mail server
+func sendMail(ctx context.Context, m mailer.Mailer, to, subject, body string) {
+	if to == "" {
+		return
+	}
+	go func() {
+		if err := m.Send(to, subject, body); err != nil {
+			slog.ErrorContext(ctx, "failed to send mail", "subject", subject, "error", err)
+		}
+	}()
+}
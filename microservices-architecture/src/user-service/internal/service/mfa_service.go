@@ -0,0 +1,285 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"os"
+	"strings"
+	"time"
+	"user-service/internal/auth"
+	"user-service/internal/model"
+	"user-service/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/skip2/go-qrcode"
+)
+
+const recoveryCodeCount = 10
+
+// recoveryCodeAlphabet avoids characters that are easy to confuse
+const recoveryCodeAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+
+type TOTPEnrollmentResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+	QRCode     string `json:"qr_code"` // PNG data URI
+}
+
+type MFAService interface {
+	BeginTOTPEnrollment(ctx context.Context, userID string) (*TOTPEnrollmentResponse, error)
+	ConfirmTOTPEnrollment(ctx context.Context, userID, code string) ([]string, error)
+	DisableTOTP(ctx context.Context, userID, code string) error
+	RegenerateRecoveryCodes(ctx context.Context, userID, code string) ([]string, error)
+	VerifySecondFactor(ctx context.Context, user *model.User, code, recoveryCode string) error
+	IsRequiredFor(user *model.User) bool
+}
+
+type mfaService struct {
+	repo          repository.UserRepository
+	recoveryRepo  repository.RecoveryCodeRepository
+	issuer        string
+	requiredRoles []string
+}
+
+func NewMFAService(repo repository.UserRepository, recoveryRepo repository.RecoveryCodeRepository) MFAService {
+	issuer := os.Getenv("MFA_ISSUER")
+	if issuer == "" {
+		issuer = "INA 17"
+	}
+	return &mfaService{
+		repo:          repo,
+		recoveryRepo:  recoveryRepo,
+		issuer:        issuer,
+		requiredRoles: MFARequiredRoles(),
+	}
+}
+
+// MFARequiredRoles returns the roles that must use two-factor authentication,
+// configured as a comma separated MFA_REQUIRED_ROLES list (default "admin")
+func MFARequiredRoles() []string {
+	value, ok := os.LookupEnv("MFA_REQUIRED_ROLES")
+	if !ok {
+		return []string{model.RoleAdmin}
+	}
+
+	var roles []string
+	for _, role := range strings.Split(value, ",") {
+		if role = strings.TrimSpace(role); role != "" {
+			roles = append(roles, role)
+		}
+	}
+	return roles
+}
+
+func (s *mfaService) IsRequiredFor(user *model.User) bool {
+	for _, role := range s.requiredRoles {
+		if user.Role == role {
+			return true
+		}
+	}
+	return false
+}
+
+// BeginTOTPEnrollment stores a new pending secret. It is not used for login
+// until ConfirmTOTPEnrollment succeeds with a code from the authenticator.
+func (s *mfaService) BeginTOTPEnrollment(ctx context.Context, userID string) (*TOTPEnrollmentResponse, error) {
+	user, err := s.findUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.IsMFAEnabled() {
+		return nil, errors.New("two-factor authentication is already enabled")
+	}
+
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		return nil, errors.New("failed to generate secret")
+	}
+
+	encrypted, err := auth.EncryptSecret(secret)
+	if err != nil {
+		return nil, errors.New("failed to store secret")
+	}
+
+	if err := s.repo.UpdateTOTP(ctx, user.ID, encrypted, nil); err != nil {
+		return nil, errors.New("failed to store secret")
+	}
+
+	uri := auth.TOTPURI(s.issuer, user.Username, secret)
+	png, err := qrcode.Encode(uri, qrcode.Medium, 256)
+	if err != nil {
+		return nil, errors.New("failed to generate QR code")
+	}
+
+	return &TOTPEnrollmentResponse{
+		Secret:     secret,
+		OTPAuthURI: uri,
+		QRCode:     "data:image/png;base64," + base64.StdEncoding.EncodeToString(png),
+	}, nil
+}
+
+func (s *mfaService) ConfirmTOTPEnrollment(ctx context.Context, userID, code string) ([]string, error) {
+	user, err := s.findUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.IsMFAEnabled() {
+		return nil, errors.New("two-factor authentication is already enabled")
+	}
+	if user.TOTPSecret == "" {
+		return nil, errors.New("two-factor enrollment has not been started")
+	}
+
+	if err := s.verifyTOTP(ctx, user, code); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if err := s.repo.UpdateTOTP(ctx, user.ID, user.TOTPSecret, &now); err != nil {
+		return nil, errors.New("failed to enable two-factor authentication")
+	}
+
+	return s.issueRecoveryCodes(ctx, user.ID)
+}
+
+func (s *mfaService) DisableTOTP(ctx context.Context, userID, code string) error {
+	user, err := s.findUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if !user.IsMFAEnabled() {
+		return errors.New("two-factor authentication is not enabled")
+	}
+	if s.IsRequiredFor(user) {
+		return errors.New("two-factor authentication is required for this account")
+	}
+
+	if err := s.VerifySecondFactor(ctx, user, code, ""); err != nil {
+		return err
+	}
+
+	if err := s.repo.UpdateTOTP(ctx, user.ID, "", nil); err != nil {
+		return errors.New("failed to disable two-factor authentication")
+	}
+	if err := s.recoveryRepo.DeleteByUserID(ctx, user.ID); err != nil {
+		return errors.New("failed to disable two-factor authentication")
+	}
+
+	return nil
+}
+
+func (s *mfaService) RegenerateRecoveryCodes(ctx context.Context, userID, code string) ([]string, error) {
+	user, err := s.findUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.IsMFAEnabled() {
+		return nil, errors.New("two-factor authentication is not enabled")
+	}
+
+	if err := s.VerifySecondFactor(ctx, user, code, ""); err != nil {
+		return nil, err
+	}
+
+	return s.issueRecoveryCodes(ctx, user.ID)
+}
+
+// VerifySecondFactor accepts either a current TOTP code or an unused recovery code
+func (s *mfaService) VerifySecondFactor(ctx context.Context, user *model.User, code, recoveryCode string) error {
+	if !user.IsMFAEnabled() {
+		return errors.New("two-factor authentication is not enabled")
+	}
+
+	if recoveryCode != "" {
+		normalized := normalizeRecoveryCode(recoveryCode)
+		if err := s.recoveryRepo.Consume(ctx, user.ID, auth.HashToken(normalized)); err != nil {
+			return errors.New("invalid recovery code")
+		}
+		return nil
+	}
+
+	return s.verifyTOTP(ctx, user, code)
+}
+
+func (s *mfaService) verifyTOTP(ctx context.Context, user *model.User, code string) error {
+	secret, err := auth.DecryptSecret(user.TOTPSecret)
+	if err != nil {
+		return errors.New("failed to read two-factor secret")
+	}
+
+	step, ok := auth.ValidateTOTP(secret, strings.TrimSpace(code), time.Now())
+	if !ok {
+		return errors.New("invalid authentication code")
+	}
+
+	if err := s.repo.AdvanceTOTPStep(ctx, user.ID, step); err != nil {
+		return errors.New("authentication code has already been used")
+	}
+
+	return nil
+}
+
+// issueRecoveryCodes replaces the user's recovery codes and returns the
+// plaintext values, which are only shown once
+func (s *mfaService) issueRecoveryCodes(ctx context.Context, userID uuid.UUID) ([]string, error) {
+	plain := make([]string, 0, recoveryCodeCount)
+	codes := make([]model.RecoveryCode, 0, recoveryCodeCount)
+
+	for i := 0; i < recoveryCodeCount; i++ {
+		code, err := generateRecoveryCode()
+		if err != nil {
+			return nil, errors.New("failed to generate recovery codes")
+		}
+		plain = append(plain, code)
+		codes = append(codes, model.RecoveryCode{
+			UserID:   userID,
+			CodeHash: auth.HashToken(normalizeRecoveryCode(code)),
+		})
+	}
+
+	if err := s.recoveryRepo.ReplaceForUser(ctx, userID, codes); err != nil {
+		return nil, errors.New("failed to store recovery codes")
+	}
+
+	return plain, nil
+}
+
+func (s *mfaService) findUser(ctx context.Context, userID string) (*model.User, error) {
+	parsedID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, errors.New("invalid user ID")
+	}
+
+	user, err := s.repo.FindByID(ctx, parsedID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+	return user, nil
+}
+
+// generateRecoveryCode returns a code formatted as xxxxx-xxxxx
+func generateRecoveryCode() (string, error) {
+	bytes := make([]byte, 10)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	for i, b := range bytes {
+		if i == 5 {
+			sb.WriteByte('-')
+		}
+		sb.WriteByte(recoveryCodeAlphabet[int(b)%len(recoveryCodeAlphabet)])
+	}
+	return sb.String(), nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+}
@@ -0,0 +1,733 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"log/slog"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+	"user-service/internal/auth"
+	"user-service/internal/model"
+	"user-service/internal/repository"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// Scopes understood by the authorization server
+const (
+	ScopeOpenID        = "openid"
+	ScopeProfile       = "profile"
+	ScopeEmail         = "email"
+	ScopeOfflineAccess = "offline_access"
+)
+
+var supportedScopes = []string{ScopeOpenID, ScopeProfile, ScopeEmail, ScopeOfflineAccess}
+
+// OAuthError is an error response defined by RFC 6749, returned to clients
+// either as a redirect or as the token endpoint body
+type OAuthError struct {
+	Code        string
+	Description string
+}
+
+func (e *OAuthError) Error() string {
+	return e.Code + ": " + e.Description
+}
+
+func oauthError(code, description string) *OAuthError {
+	return &OAuthError{Code: code, Description: description}
+}
+
+// ErrOAuthClientNotFound is returned when a client_id is not registered
+var ErrOAuthClientNotFound = errors.New("oauth client not found")
+
+type RegisterClientRequest struct {
+	Name         string   `json:"name"`
+	RedirectURIs []string `json:"redirect_uris"`
+	Scopes       []string `json:"scopes"`
+	Public       bool     `json:"public"`
+	FirstParty   bool     `json:"first_party"`
+
+	// Service accounts use the client credentials grant to call internal
+	// APIs of the listed audiences; they have no redirect URIs and their
+	// scopes are defined by the resource servers
+	ServiceAccount bool     `json:"service_account"`
+	Audiences      []string `json:"audiences"`
+}
+
+// AuthorizeRequest holds the query parameters of the authorize endpoint
+type AuthorizeRequest struct {
+	ResponseType        string
+	ClientID            string
+	RedirectURI         string
+	Scope               string
+	State               string
+	Nonce               string
+	CodeChallenge       string
+	CodeChallengeMethod string
+}
+
+// Scopes returns the requested scopes, defaulting to openid
+func (r *AuthorizeRequest) Scopes() []string {
+	scopes := strings.Fields(r.Scope)
+	if len(scopes) == 0 {
+		return []string{ScopeOpenID}
+	}
+	return scopes
+}
+
+// TokenRequest holds the form parameters of the token endpoint
+type TokenRequest struct {
+	GrantType    string
+	Code         string
+	RedirectURI  string
+	CodeVerifier string
+	RefreshToken string
+	Scope        string
+	Audience     string
+	ClientID     string
+	ClientSecret string
+}
+
+type TokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	IDToken      string `json:"id_token,omitempty"`
+	Scope        string `json:"scope"`
+	Audience     string `json:"audience,omitempty"`
+}
+
+type OAuthService interface {
+	Issuer() string
+	RegisterClient(ctx context.Context, req RegisterClientRequest) (*model.OAuthClientResponse, error)
+	ListClients(ctx context.Context) ([]model.OAuthClientResponse, error)
+	DeleteClient(ctx context.Context, clientID string) error
+	FindUser(ctx context.Context, userID string) (*model.User, error)
+	// StartSession opens a session for a login on the authorize page and
+	// returns the value of its cookie
+	StartSession(ctx context.Context, user *model.User, mfa bool, info ClientInfo) (string, error)
+
+	// ValidateAuthorizeRequest returns a nil client when the client or
+	// redirect URI is invalid, in which case the user must not be redirected.
+	// Other errors are *OAuthError values to be sent to the redirect URI.
+	ValidateAuthorizeRequest(ctx context.Context, req *AuthorizeRequest) (*model.OAuthClient, error)
+	NeedsConsent(ctx context.Context, userID string, client *model.OAuthClient, scopes []string) (bool, error)
+	GrantConsent(ctx context.Context, userID string, client *model.OAuthClient, scopes []string) error
+	IssueCode(ctx context.Context, userID string, mfa bool, authTime time.Time, req *AuthorizeRequest) (string, error)
+	Token(ctx context.Context, req TokenRequest, client ClientInfo) (*TokenResponse, error)
+	UserInfo(ctx context.Context, userID, sessionID string) (map[string]interface{}, error)
+}
+
+type oauthService struct {
+	repo        repository.UserRepository
+	refreshRepo repository.RefreshTokenRepository
+	clientRepo  repository.OAuthClientRepository
+	codeRepo    repository.OAuthCodeRepository
+	consentRepo repository.OAuthConsentRepository
+	events      AuthEventService
+	issuer      string
+}
+
+func NewOAuthService(
+	repo repository.UserRepository,
+	refreshRepo repository.RefreshTokenRepository,
+	clientRepo repository.OAuthClientRepository,
+	codeRepo repository.OAuthCodeRepository,
+	consentRepo repository.OAuthConsentRepository,
+	events AuthEventService,
+) OAuthService {
+	issuer := os.Getenv("OIDC_ISSUER")
+	if issuer == "" {
+		issuer = "http://localhost:3001"
+	}
+	return &oauthService{
+		repo:        repo,
+		refreshRepo: refreshRepo,
+		clientRepo:  clientRepo,
+		codeRepo:    codeRepo,
+		consentRepo: consentRepo,
+		events:      events,
+		issuer:      strings.TrimRight(issuer, "/"),
+	}
+}
+
+func (s *oauthService) Issuer() string {
+	return s.issuer
+}
+
+func (s *oauthService) RegisterClient(ctx context.Context, req RegisterClientRequest) (*model.OAuthClientResponse, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, errors.New("name is required")
+	}
+
+	if req.ServiceAccount {
+		return s.registerServiceAccount(ctx, name, req)
+	}
+
+	if len(req.RedirectURIs) == 0 {
+		return nil, errors.New("at least one redirect URI is required")
+	}
+	for _, uri := range req.RedirectURIs {
+		parsed, err := url.Parse(uri)
+		if err != nil || !parsed.IsAbs() || parsed.Fragment != "" || strings.ContainsAny(uri, " \t\n") {
+			return nil, errors.New("invalid redirect URI: " + uri)
+		}
+	}
+
+	scopes := req.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{ScopeOpenID, ScopeProfile, ScopeEmail}
+	}
+	for _, scope := range scopes {
+		if !containsScope(supportedScopes, scope) {
+			return nil, errors.New("unsupported scope: " + scope)
+		}
+	}
+
+	client := &model.OAuthClient{
+		ClientID:     uuid.New().String(),
+		Name:         name,
+		RedirectURIs: strings.Join(req.RedirectURIs, " "),
+		Scopes:       strings.Join(scopes, " "),
+		Public:       req.Public,
+		FirstParty:   req.FirstParty,
+	}
+
+	return s.createClient(ctx, client)
+}
+
+func (s *oauthService) registerServiceAccount(ctx context.Context, name string, req RegisterClientRequest) (*model.OAuthClientResponse, error) {
+	if req.Public {
+		return nil, errors.New("service accounts must be confidential clients")
+	}
+	if len(req.Audiences) == 0 {
+		return nil, errors.New("at least one audience is required")
+	}
+	if len(req.Scopes) == 0 {
+		return nil, errors.New("at least one scope is required")
+	}
+	for _, value := range append(append([]string{}, req.Audiences...), req.Scopes...) {
+		if value == "" || strings.ContainsAny(value, " \t\n") {
+			return nil, errors.New("invalid audience or scope: " + value)
+		}
+	}
+
+	return s.createClient(ctx, &model.OAuthClient{
+		ClientID:       uuid.New().String(),
+		Name:           name,
+		Scopes:         strings.Join(req.Scopes, " "),
+		Audiences:      strings.Join(req.Audiences, " "),
+		ServiceAccount: true,
+	})
+}
+
+func (s *oauthService) createClient(ctx context.Context, client *model.OAuthClient) (*model.OAuthClientResponse, error) {
+	// Confidential clients get a secret that is only shown once
+	var secret string
+	if !client.Public {
+		var err error
+		secret, err = auth.GenerateOpaqueToken()
+		if err != nil {
+			return nil, errors.New("failed to generate client secret")
+		}
+		client.ClientSecretHash = auth.HashToken(secret)
+	}
+
+	if err := s.clientRepo.Create(ctx, client); err != nil {
+		return nil, errors.New("failed to register client")
+	}
+
+	response := client.ToResponse()
+	response.ClientSecret = secret
+	return response, nil
+}
+
+func (s *oauthService) ListClients(ctx context.Context) ([]model.OAuthClientResponse, error) {
+	clients, err := s.clientRepo.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]model.OAuthClientResponse, 0, len(clients))
+	for i := range clients {
+		responses = append(responses, *clients[i].ToResponse())
+	}
+	return responses, nil
+}
+
+func (s *oauthService) DeleteClient(ctx context.Context, clientID string) error {
+	if _, err := s.clientRepo.FindByClientID(ctx, clientID); err != nil {
+		return ErrOAuthClientNotFound
+	}
+	return s.clientRepo.DeleteByClientID(ctx, clientID)
+}
+
+func (s *oauthService) FindUser(ctx context.Context, userID string) (*model.User, error) {
+	parsedID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, errors.New("invalid user ID")
+	}
+
+	user, err := s.repo.FindByID(ctx, parsedID)
+	if err != nil {
+		return nil, err
+	}
+	if user.IsDisabled() {
+		return nil, ErrAccountDisabled
+	}
+	return user, nil
+}
+
+// StartSession records the login as a session of the user lasting as long
+// as the cookie, so that it is listed with the other sessions and revoking
+// them signs the authorize page out too. The session has no refresh token
+// that is ever handed out.
+func (s *oauthService) StartSession(ctx context.Context, user *model.User, mfa bool, info ClientInfo) (string, error) {
+	unused, err := auth.GenerateRefreshToken()
+	if err != nil {
+		return "", errors.New("failed to start session")
+	}
+
+	session := &model.RefreshToken{
+		UserID:    user.ID,
+		Token:     unused,
+		ExpiresAt: time.Now().Add(auth.OAuthSessionExpiry),
+		MFA:       mfa,
+		UserAgent: truncate(info.UserAgent, 512),
+		IP:        info.IP,
+	}
+	if err := s.refreshRepo.Create(ctx, session); err != nil {
+		return "", errors.New("failed to start session")
+	}
+
+	return auth.GenerateOAuthSessionToken(user, session)
+}
+
+func (s *oauthService) ValidateAuthorizeRequest(ctx context.Context, req *AuthorizeRequest) (*model.OAuthClient, error) {
+	client, err := s.clientRepo.FindByClientID(ctx, req.ClientID)
+	if err != nil {
+		return nil, errors.New("unknown client")
+	}
+
+	if client.ServiceAccount || !client.HasRedirectURI(req.RedirectURI) {
+		return nil, errors.New("redirect URI is not registered for this client")
+	}
+
+	if req.ResponseType != "code" {
+		return client, oauthError("unsupported_response_type", "only the code response type is supported")
+	}
+
+	if !client.AllowsScopes(req.Scopes()) {
+		return client, oauthError("invalid_scope", "requested scope is not allowed for this client")
+	}
+
+	if req.CodeChallenge == "" {
+		if client.Public {
+			return client, oauthError("invalid_request", "public clients must use PKCE")
+		}
+	} else if req.CodeChallengeMethod != "S256" {
+		return client, oauthError("invalid_request", "code_challenge_method must be S256")
+	}
+
+	return client, nil
+}
+
+func (s *oauthService) NeedsConsent(ctx context.Context, userID string, client *model.OAuthClient, scopes []string) (bool, error) {
+	if client.FirstParty {
+		return false, nil
+	}
+
+	parsedID, err := uuid.Parse(userID)
+	if err != nil {
+		return false, errors.New("invalid user ID")
+	}
+
+	consent, err := s.consentRepo.Find(ctx, parsedID, client.ClientID)
+	if err != nil {
+		return true, nil
+	}
+
+	granted := strings.Fields(consent.Scopes)
+	for _, scope := range scopes {
+		if !containsScope(granted, scope) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *oauthService) GrantConsent(ctx context.Context, userID string, client *model.OAuthClient, scopes []string) error {
+	parsedID, err := uuid.Parse(userID)
+	if err != nil {
+		return errors.New("invalid user ID")
+	}
+
+	// Keep previously granted scopes so that narrower requests don't ask again
+	merged := append([]string{}, scopes...)
+	if consent, err := s.consentRepo.Find(ctx, parsedID, client.ClientID); err == nil {
+		for _, scope := range strings.Fields(consent.Scopes) {
+			if !containsScope(merged, scope) {
+				merged = append(merged, scope)
+			}
+		}
+	}
+
+	return s.consentRepo.Upsert(ctx, &model.OAuthConsent{
+		UserID:   parsedID,
+		ClientID: client.ClientID,
+		Scopes:   strings.Join(merged, " "),
+	})
+}
+
+func (s *oauthService) IssueCode(ctx context.Context, userID string, mfa bool, authTime time.Time, req *AuthorizeRequest) (string, error) {
+	parsedID, err := uuid.Parse(userID)
+	if err != nil {
+		return "", errors.New("invalid user ID")
+	}
+
+	code, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		return "", errors.New("failed to generate authorization code")
+	}
+
+	authCode := &model.OAuthAuthorizationCode{
+		CodeHash:            auth.HashToken(code),
+		ClientID:            req.ClientID,
+		UserID:              parsedID,
+		RedirectURI:         req.RedirectURI,
+		Scope:               strings.Join(req.Scopes(), " "),
+		Nonce:               req.Nonce,
+		CodeChallenge:       req.CodeChallenge,
+		CodeChallengeMethod: req.CodeChallengeMethod,
+		MFA:                 mfa,
+		AuthTime:            authTime,
+		ExpiresAt:           time.Now().Add(auth.OAuthCodeExpiry),
+	}
+
+	if err := s.codeRepo.Create(ctx, authCode); err != nil {
+		return "", errors.New("failed to store authorization code")
+	}
+
+	return code, nil
+}
+
+func (s *oauthService) Token(ctx context.Context, req TokenRequest, info ClientInfo) (*TokenResponse, error) {
+	client, err := s.authenticateClient(ctx, req.ClientID, req.ClientSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	// Service accounts act on their own behalf and never on a user's
+	switch req.GrantType {
+	case "authorization_code", "refresh_token":
+		if client.ServiceAccount {
+			return nil, oauthError("unauthorized_client", "grant type is not allowed for this client")
+		}
+	case "client_credentials":
+		if !client.ServiceAccount {
+			return nil, oauthError("unauthorized_client", "grant type is not allowed for this client")
+		}
+	}
+
+	switch req.GrantType {
+	case "authorization_code":
+		return s.exchangeCode(ctx, client, req, info)
+	case "refresh_token":
+		return s.refresh(ctx, client, req, info)
+	case "client_credentials":
+		return s.clientCredentials(client, req)
+	default:
+		return nil, oauthError("unsupported_grant_type", "grant type is not supported")
+	}
+}
+
+// authenticateClient checks client credentials. Public clients only
+// identify themselves and rely on PKCE instead of a secret.
+func (s *oauthService) authenticateClient(ctx context.Context, clientID, secret string) (*model.OAuthClient, error) {
+	if clientID == "" {
+		return nil, oauthError("invalid_client", "client authentication failed")
+	}
+
+	client, err := s.clientRepo.FindByClientID(ctx, clientID)
+	if err != nil {
+		return nil, oauthError("invalid_client", "client authentication failed")
+	}
+
+	if client.Public {
+		return client, nil
+	}
+
+	hash := auth.HashToken(secret)
+	if secret == "" || subtle.ConstantTimeCompare([]byte(hash), []byte(client.ClientSecretHash)) != 1 {
+		return nil, oauthError("invalid_client", "client authentication failed")
+	}
+
+	return client, nil
+}
+
+func (s *oauthService) exchangeCode(ctx context.Context, client *model.OAuthClient, req TokenRequest, info ClientInfo) (*TokenResponse, error) {
+	if req.Code == "" {
+		return nil, oauthError("invalid_request", "code is required")
+	}
+
+	code, err := s.codeRepo.FindByCodeHash(ctx, auth.HashToken(req.Code))
+	if err != nil || code.UsedAt != nil || code.IsExpired() {
+		return nil, oauthError("invalid_grant", "authorization code is invalid or expired")
+	}
+
+	if code.ClientID != client.ClientID || code.RedirectURI != req.RedirectURI {
+		return nil, oauthError("invalid_grant", "authorization code was not issued to this client")
+	}
+
+	if code.CodeChallenge != "" {
+		if req.CodeVerifier == "" {
+			return nil, oauthError("invalid_grant", "code_verifier is required")
+		}
+		sum := sha256.Sum256([]byte(req.CodeVerifier))
+		challenge := base64.RawURLEncoding.EncodeToString(sum[:])
+		if subtle.ConstantTimeCompare([]byte(challenge), []byte(code.CodeChallenge)) != 1 {
+			return nil, oauthError("invalid_grant", "code_verifier does not match")
+		}
+	}
+
+	// Codes are single use; losing the race means another request redeemed it
+	if err := s.codeRepo.MarkUsed(ctx, code.ID); err != nil {
+		return nil, oauthError("invalid_grant", "authorization code is invalid or expired")
+	}
+
+	user, err := s.repo.FindByID(ctx, code.UserID)
+	if err != nil || user.IsDisabled() {
+		return nil, oauthError("invalid_grant", "user not found")
+	}
+
+	scopes := strings.Fields(code.Scope)
+	offline := containsScope(scopes, ScopeOfflineAccess)
+
+	refreshTokenStr, err := auth.GenerateRefreshToken()
+	if err != nil {
+		return nil, errors.New("failed to generate refresh token")
+	}
+
+	// Every grant gets a session so that it shows up in the session list and
+	// can be revoked; without offline_access it only lives as long as the
+	// access token and the refresh token is never handed out.
+	expiresAt := auth.GetRefreshTokenExpiry()
+	if !offline {
+		expiresAt = time.Now().Add(auth.AccessTokenExpiry)
+	}
+
+	session := &model.RefreshToken{
+		UserID:    user.ID,
+		Token:     refreshTokenStr,
+		ExpiresAt: expiresAt,
+		MFA:       code.MFA,
+		ClientID:  client.ClientID,
+		Scope:     code.Scope,
+		UserAgent: truncate(info.UserAgent, 512),
+		IP:        info.IP,
+	}
+	if err := s.refreshRepo.Create(ctx, session); err != nil {
+		return nil, errors.New("failed to store session")
+	}
+
+	accessToken, err := auth.GenerateOAuthAccessToken(s.issuer, session)
+	if err != nil {
+		return nil, errors.New("failed to generate access token")
+	}
+
+	response := &TokenResponse{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   auth.GetAccessTokenExpirySeconds(),
+		Scope:       code.Scope,
+	}
+	if offline {
+		response.RefreshToken = refreshTokenStr
+	}
+
+	if containsScope(scopes, ScopeOpenID) {
+		idToken, err := s.generateIDToken(user, client.ClientID, scopes, code.Nonce, code.AuthTime)
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to sign id_token", "error", err)
+			return nil, errors.New("failed to generate id token")
+		}
+		response.IDToken = idToken
+	}
+
+	return response, nil
+}
+
+func (s *oauthService) refresh(ctx context.Context, client *model.OAuthClient, req TokenRequest, info ClientInfo) (*TokenResponse, error) {
+	if req.RefreshToken == "" {
+		return nil, oauthError("invalid_request", "refresh_token is required")
+	}
+
+	session, err := s.refreshRepo.FindByToken(ctx, req.RefreshToken)
+	if err != nil || session.ClientID != client.ClientID || session.IsExpired() {
+		s.recordRefresh(ctx, client, nil, info, "invalid_token")
+		return nil, oauthError("invalid_grant", "refresh token is invalid or expired")
+	}
+
+	user, err := s.repo.FindByID(ctx, session.UserID)
+	if err != nil {
+		s.recordRefresh(ctx, client, nil, info, "unknown_user")
+		return nil, oauthError("invalid_grant", "user not found")
+	}
+	if user.IsDisabled() {
+		s.recordRefresh(ctx, client, user, info, "account_disabled")
+		return nil, oauthError("invalid_grant", "user not found")
+	}
+
+	accessToken, err := auth.GenerateOAuthAccessToken(s.issuer, session)
+	if err != nil {
+		return nil, errors.New("failed to generate access token")
+	}
+
+	if err := s.refreshRepo.Touch(ctx, session.ID, info.IP, truncate(info.UserAgent, 512)); err != nil {
+		slog.ErrorContext(ctx, "failed to update session", "session_id", session.ID, "error", err)
+	}
+	s.recordRefresh(ctx, client, user, info, "")
+
+	return &TokenResponse{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   auth.GetAccessTokenExpirySeconds(),
+		Scope:       session.Scope,
+	}, nil
+}
+
+// recordRefresh adds a refresh_token grant to the audit log
+func (s *oauthService) recordRefresh(ctx context.Context, client *model.OAuthClient, user *model.User, info ClientInfo, reason string) {
+	event := newAuthEvent(model.AuthEventRefresh, user, info, reason)
+	event.Detail = "oauth client " + client.ClientID
+	s.events.Record(ctx, event)
+}
+
+// clientCredentials issues an RS256 access token for a service account.
+// Resource servers verify it locally against the JWKS, checking the audience
+// and the scopes their internal routes require.
+func (s *oauthService) clientCredentials(client *model.OAuthClient, req TokenRequest) (*TokenResponse, error) {
+	audience := req.Audience
+	if audience == "" {
+		audiences := strings.Fields(client.Audiences)
+		if len(audiences) != 1 {
+			return nil, oauthError("invalid_request", "audience is required")
+		}
+		audience = audiences[0]
+	}
+	if !client.HasAudience(audience) {
+		return nil, oauthError("invalid_target", "audience is not allowed for this client")
+	}
+
+	scopes := strings.Fields(req.Scope)
+	if len(scopes) == 0 {
+		scopes = strings.Fields(client.Scopes)
+	}
+	if !client.AllowsScopes(scopes) {
+		return nil, oauthError("invalid_scope", "requested scope is not allowed for this client")
+	}
+	scope := strings.Join(scopes, " ")
+
+	now := time.Now()
+	token, err := auth.SignServiceToken(jwt.MapClaims{
+		"iss":       s.issuer,
+		"sub":       client.ClientID,
+		"aud":       audience,
+		"client_id": client.ClientID,
+		"scope":     scope,
+		"iat":       now.Unix(),
+		"exp":       now.Add(auth.ServiceTokenExpiry).Unix(),
+		"jti":       uuid.New().String(),
+	})
+	if err != nil {
+		slog.Error("failed to sign service token", "client_id", client.ClientID, "error", err)
+		return nil, errors.New("failed to generate access token")
+	}
+
+	return &TokenResponse{
+		AccessToken: token,
+		TokenType:   "Bearer",
+		ExpiresIn:   int(auth.ServiceTokenExpiry.Seconds()),
+		Scope:       scope,
+		Audience:    audience,
+	}, nil
+}
+
+func (s *oauthService) generateIDToken(user *model.User, clientID string, scopes []string, nonce string, authTime time.Time) (string, error) {
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss":       s.issuer,
+		"sub":       user.ID.String(),
+		"aud":       clientID,
+		"iat":       now.Unix(),
+		"exp":       now.Add(auth.IDTokenExpiry).Unix(),
+		"auth_time": authTime.Unix(),
+	}
+	if nonce != "" {
+		claims["nonce"] = nonce
+	}
+	for key, value := range userClaims(user, scopes) {
+		claims[key] = value
+	}
+	return auth.SignIDToken(claims)
+}
+
+func (s *oauthService) UserInfo(ctx context.Context, userID, sessionID string) (map[string]interface{}, error) {
+	user, err := s.FindUser(ctx, userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	// Tokens from a direct login carry every scope; OAuth tokens carry the
+	// scopes stored on their session
+	scopes := []string{ScopeOpenID, ScopeProfile, ScopeEmail}
+	if parsedID, err := uuid.Parse(sessionID); err == nil {
+		if session, err := s.refreshRepo.FindByID(ctx, parsedID); err == nil && session.ClientID != "" {
+			scopes = strings.Fields(session.Scope)
+		}
+	}
+
+	if !containsScope(scopes, ScopeOpenID) {
+		return nil, errors.New("token does not have the openid scope")
+	}
+
+	claims := userClaims(user, scopes)
+	claims["sub"] = user.ID.String()
+	return claims, nil
+}
+
+// userClaims returns the standard claims released for the given scopes
+func userClaims(user *model.User, scopes []string) map[string]interface{} {
+	claims := map[string]interface{}{}
+	if containsScope(scopes, ScopeProfile) {
+		claims["preferred_username"] = user.Username
+	}
+	if containsScope(scopes, ScopeEmail) && user.GetEmail() != "" {
+		claims["email"] = user.GetEmail()
+		claims["email_verified"] = user.IsEmailVerified()
+	}
+	return claims
+}
+
+func containsScope(scopes []string, scope string) bool {
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// SupportedScopes lists the scopes advertised in the discovery document
+func SupportedScopes() []string {
+	return append([]string{}, supportedScopes...)
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+// PasswordPolicy decides whether a new password is acceptable
+type PasswordPolicy struct {
+	MinLength int
+	MaxLength int
+	blocklist map[string]struct{}
+}
+
+// LoadPasswordPolicy reads PASSWORD_MIN_LENGTH, PASSWORD_MAX_LENGTH and the
+// common password list at PASSWORD_BLOCKLIST_PATH (one password per line).
+// A missing list is logged and leaves only the length rules in place.
+func LoadPasswordPolicy() *PasswordPolicy {
+	policy := &PasswordPolicy{
+		MinLength: getEnvInt("PASSWORD_MIN_LENGTH", 8),
+		MaxLength: getEnvInt("PASSWORD_MAX_LENGTH", 128),
+		blocklist: make(map[string]struct{}),
+	}
+
+	path := os.Getenv("PASSWORD_BLOCKLIST_PATH")
+	if path == "" {
+		path = "config/common_passwords.txt"
+	}
+
+	if err := policy.loadBlocklist(path); err != nil {
+		slog.Warn("Password blocklist not loaded", "path", path, "error", err)
+	}
+
+	return policy
+}
+
+func (p *PasswordPolicy) loadBlocklist(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		p.blocklist[strings.ToLower(line)] = struct{}{}
+	}
+	return scanner.Err()
+}
+
+// Validate checks password against the policy. username is rejected as a
+// password as well.
+func (p *PasswordPolicy) Validate(password, username string) error {
+	length := utf8.RuneCountInString(password)
+	if length < p.MinLength {
+		return fmt.Errorf("password must be at least %d characters", p.MinLength)
+	}
+	if length > p.MaxLength {
+		return fmt.Errorf("password must be at most %d characters", p.MaxLength)
+	}
+
+	lowered := strings.ToLower(password)
+	if username != "" && lowered == strings.ToLower(username) {
+		return errors.New("password must not be the same as the username")
+	}
+	if _, blocked := p.blocklist[lowered]; blocked {
+		return errors.New("password is too common, please choose another one")
+	}
+
+	return nil
+}
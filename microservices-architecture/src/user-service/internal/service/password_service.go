@@ -0,0 +1,252 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"user-service/internal/auth"
+	"user-service/internal/mailer"
+	"user-service/internal/model"
+	"user-service/internal/repository"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ErrIncorrectPassword is returned when the current password does not match
+var ErrIncorrectPassword = errors.New("current password is incorrect")
+
+type PasswordService interface {
+	ForgotPassword(ctx context.Context, identifier string) error
+	ResetPassword(ctx context.Context, token, newPassword string, client ClientInfo) error
+	ChangePassword(ctx context.Context, userID, sessionID, currentPassword, newPassword string, client ClientInfo) error
+}
+
+type passwordService struct {
+	db        *gorm.DB
+	repo      repository.UserRepository
+	sessions  SessionService
+	resetRepo repository.PasswordResetTokenRepository
+	hasher    auth.PasswordHasher
+	policy    *PasswordPolicy
+	events    AuthEventService
+	mailer    mailer.Mailer
+	resetURL  string
+}
+
+func NewPasswordService(
+	db *gorm.DB,
+	repo repository.UserRepository,
+	sessions SessionService,
+	resetRepo repository.PasswordResetTokenRepository,
+	hasher auth.PasswordHasher,
+	policy *PasswordPolicy,
+	events AuthEventService,
+	mailer mailer.Mailer,
+) PasswordService {
+	resetURL := os.Getenv("PASSWORD_RESET_URL")
+	if resetURL == "" {
+		resetURL = "http://localhost:3000/reset-password"
+	}
+	return &passwordService{
+		db:        db,
+		repo:      repo,
+		sessions:  sessions,
+		resetRepo: resetRepo,
+		hasher:    hasher,
+		policy:    policy,
+		events:    events,
+		mailer:    mailer,
+		resetURL:  resetURL,
+	}
+}
+
+// ForgotPassword issues a reset token and mails it to the account owner.
+// The identifier may be an email address or a username. It never reports
+// whether the account exists; unknown accounts are a no-op. The account is
+// looked up and the token issued in the background, so that the response
+// takes as long either way.
+func (s *passwordService) ForgotPassword(ctx context.Context, identifier string) error {
+	if identifier == "" {
+		return errors.New("email or username is required")
+	}
+
+	go s.issueResetToken(context.WithoutCancel(ctx), identifier)
+	return nil
+}
+
+// issueResetToken mails a reset token to the owner of the account named by
+// identifier, if there is one
+func (s *passwordService) issueResetToken(ctx context.Context, identifier string) {
+	user := s.findAccount(ctx, identifier)
+	if user == nil || user.GetEmail() == "" {
+		return
+	}
+
+	// Only the most recent reset token stays valid
+	if err := s.resetRepo.DeleteByUserID(ctx, user.ID); err != nil {
+		slog.ErrorContext(ctx, "failed to invalidate previous reset tokens", "user_id", user.ID, "error", err)
+		return
+	}
+
+	token, err := auth.GenerateResetToken()
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to generate reset token", "error", err)
+		return
+	}
+
+	resetToken := &model.PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: auth.HashToken(token),
+		ExpiresAt: auth.GetResetTokenExpiry(),
+	}
+
+	if err := s.resetRepo.Create(ctx, resetToken); err != nil {
+		slog.ErrorContext(ctx, "failed to store reset token", "user_id", user.ID, "error", err)
+		return
+	}
+
+	sendMail(ctx, s.mailer, user.GetEmail(), "Reset your password", s.resetMailBody(token))
+}
+
+func (s *passwordService) findAccount(ctx context.Context, identifier string) *model.User {
+	if email, err := normalizeEmail(identifier); err == nil {
+		if user, err := s.repo.FindByEmail(ctx, email); err == nil {
+			return user
+		}
+	}
+
+	user, err := s.repo.FindByUsername(ctx, identifier)
+	if err != nil {
+		return nil
+	}
+	return user
+}
+
+func (s *passwordService) ResetPassword(ctx context.Context, token, newPassword string, client ClientInfo) error {
+	if token == "" || newPassword == "" {
+		return errors.New("token and password are required")
+	}
+
+	resetToken, err := s.resetRepo.FindByTokenHash(ctx, auth.HashToken(token))
+	if err != nil {
+		s.events.Record(ctx, newAuthEvent(model.AuthEventPasswordReset, nil, client, "invalid_token"))
+		return errors.New("invalid or expired reset token")
+	}
+
+	if resetToken.IsUsed() || resetToken.IsExpired() {
+		s.events.Record(ctx, newAuthEvent(model.AuthEventPasswordReset, nil, client, "invalid_token"))
+		return errors.New("invalid or expired reset token")
+	}
+
+	user, err := s.repo.FindByID(ctx, resetToken.UserID)
+	if err != nil {
+		s.events.Record(ctx, newAuthEvent(model.AuthEventPasswordReset, nil, client, "unknown_user"))
+		return errors.New("invalid or expired reset token")
+	}
+
+	// Check the policy before using up the token so the user can try again
+	if err := s.policy.Validate(newPassword, user.Username); err != nil {
+		s.events.Record(ctx, newAuthEvent(model.AuthEventPasswordReset, user, client, "policy_violation"))
+		return err
+	}
+
+	hashedPassword, err := s.hasher.Hash(newPassword)
+	if err != nil {
+		return err
+	}
+
+	// The token is only used up together with the password change, so a
+	// failed update leaves it valid for another try
+	errTokenUsed := errors.New("invalid or expired reset token")
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := s.resetRepo.WithTx(tx).MarkUsed(ctx, resetToken.ID); err != nil {
+			return errTokenUsed
+		}
+		return s.repo.WithTx(tx).UpdatePassword(ctx, resetToken.UserID, hashedPassword)
+	})
+	if errors.Is(err, errTokenUsed) {
+		return err
+	}
+	if err != nil {
+		return errors.New("failed to reset password")
+	}
+
+	// Revoke existing sessions so a compromised refresh or access token stops working
+	if err := s.sessions.RevokeAllSessions(ctx, resetToken.UserID.String()); err != nil {
+		return errors.New("failed to revoke existing sessions")
+	}
+	s.events.Record(ctx, newAuthEvent(model.AuthEventPasswordReset, user, client, ""))
+
+	return nil
+}
+
+// ChangePassword replaces the password of a logged in user and signs out
+// every other session
+func (s *passwordService) ChangePassword(ctx context.Context, userID, sessionID, currentPassword, newPassword string, client ClientInfo) error {
+	if currentPassword == "" || newPassword == "" {
+		return errors.New("current and new password are required")
+	}
+
+	parsedID, err := uuid.Parse(userID)
+	if err != nil {
+		return errors.New("invalid user ID")
+	}
+
+	user, err := s.repo.FindByID(ctx, parsedID)
+	if err != nil {
+		return errors.New("user not found")
+	}
+
+	ok, _, err := s.hasher.Verify(currentPassword, user.Password)
+	if err != nil || !ok {
+		s.events.Record(ctx, newAuthEvent(model.AuthEventPasswordChange, user, client, "incorrect_password"))
+		return ErrIncorrectPassword
+	}
+
+	if err := s.policy.Validate(newPassword, user.Username); err != nil {
+		s.events.Record(ctx, newAuthEvent(model.AuthEventPasswordChange, user, client, "policy_violation"))
+		return err
+	}
+
+	if currentPassword == newPassword {
+		return errors.New("new password must be different from the current password")
+	}
+
+	hashedPassword, err := s.hasher.Hash(newPassword)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.UpdatePassword(ctx, user.ID, hashedPassword); err != nil {
+		return errors.New("failed to change password")
+	}
+
+	sessions, err := s.sessions.ListSessions(ctx, userID, sessionID)
+	if err != nil {
+		return errors.New("failed to revoke other sessions")
+	}
+	for _, session := range sessions {
+		if session.Current {
+			continue
+		}
+		if err := s.sessions.RevokeSession(ctx, userID, session.ID.String()); err != nil && !errors.Is(err, ErrSessionNotFound) {
+			return errors.New("failed to revoke other sessions")
+		}
+	}
+	s.events.Record(ctx, newAuthEvent(model.AuthEventPasswordChange, user, client, ""))
+
+	return nil
+}
+
+func (s *passwordService) resetMailBody(token string) string {
+	return fmt.Sprintf(
+		"We received a request to reset your password.\n\n"+
+			"Use the link below to choose a new password. It expires in %d minutes and can only be used once.\n\n"+
+			"%s?token=%s\n\n"+
+			"If you did not request a password reset, you can ignore this email.\n",
+		int(auth.ResetTokenExpiry.Minutes()), s.resetURL, token,
+	)
+}
@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+	"user-service/internal/auth"
+	"user-service/internal/model"
+	"user-service/internal/repository"
+)
+
+// RevocationService keeps the access token denylist. Entries are stored in
+// Postgres so every replica sees them, and mirrored in memory so checking a
+// token does not cost a query.
+type RevocationService interface {
+	RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error
+	RevokeSession(ctx context.Context, sessionID string) error
+	IsRevoked(jti, sessionID string) bool
+	ListSince(ctx context.Context, since time.Time) ([]model.RevokedToken, error)
+	StartSync(ctx context.Context, interval time.Duration)
+}
+
+type revocationService struct {
+	repo     repository.RevokedTokenRepository
+	mu       sync.RWMutex
+	entries  map[string]time.Time // kind:value -> expiry
+	lastSync time.Time
+}
+
+func NewRevocationService(ctx context.Context, repo repository.RevokedTokenRepository) RevocationService {
+	s := &revocationService{
+		repo:    repo,
+		entries: make(map[string]time.Time),
+	}
+
+	s.lastSync = time.Now()
+	tokens, err := repo.FindActive(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to load revoked tokens", "error", err)
+	}
+	s.add(tokens)
+
+	return s
+}
+
+func (s *revocationService) RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	if jti == "" {
+		return nil
+	}
+	return s.revoke(ctx, model.RevokedKindToken, jti, expiresAt)
+}
+
+// RevokeSession denylists every access token carrying the session ID. Those
+// tokens are at most one access token lifetime old, so that is how long the
+// entry has to live.
+func (s *revocationService) RevokeSession(ctx context.Context, sessionID string) error {
+	if sessionID == "" {
+		return nil
+	}
+	return s.revoke(ctx, model.RevokedKindSession, sessionID, time.Now().Add(auth.AccessTokenExpiry))
+}
+
+func (s *revocationService) IsRevoked(jti, sessionID string) bool {
+	now := time.Now()
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if expiry, ok := s.entries[revocationKey(model.RevokedKindToken, jti)]; ok && jti != "" && now.Before(expiry) {
+		return true
+	}
+	if expiry, ok := s.entries[revocationKey(model.RevokedKindSession, sessionID)]; ok && sessionID != "" && now.Before(expiry) {
+		return true
+	}
+	return false
+}
+
+func (s *revocationService) ListSince(ctx context.Context, since time.Time) ([]model.RevokedToken, error) {
+	return s.repo.FindCreatedSince(ctx, since)
+}
+
+// StartSync periodically pulls entries written by other replicas and prunes
+// expired ones until ctx is cancelled
+func (s *revocationService) StartSync(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.sync(ctx)
+			}
+		}
+	}()
+}
+
+func (s *revocationService) sync(ctx context.Context) {
+	// Overlap the window slightly so rows committed out of order are not missed
+	since := s.lastSync.Add(-5 * time.Second)
+	now := time.Now()
+
+	tokens, err := s.repo.FindCreatedSince(ctx, since)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to sync revoked tokens", "error", err)
+		return
+	}
+	s.add(tokens)
+	s.lastSync = now
+
+	s.mu.Lock()
+	for k, expiry := range s.entries {
+		if now.After(expiry) {
+			delete(s.entries, k)
+		}
+	}
+	s.mu.Unlock()
+
+	if err := s.repo.DeleteExpired(ctx); err != nil {
+		slog.ErrorContext(ctx, "failed to delete expired revoked tokens", "error", err)
+	}
+}
+
+func (s *revocationService) revoke(ctx context.Context, kind, value string, expiresAt time.Time) error {
+	token := &model.RevokedToken{
+		Kind:      kind,
+		Value:     value,
+		ExpiresAt: expiresAt,
+	}
+	if err := s.repo.Create(ctx, token); err != nil {
+		return err
+	}
+	s.add([]model.RevokedToken{*token})
+	return nil
+}
+
+func (s *revocationService) add(tokens []model.RevokedToken) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, token := range tokens {
+		s.entries[revocationKey(token.Kind, token.Value)] = token.ExpiresAt
+	}
+}
+
+func revocationKey(kind, value string) string {
+	return kind + ":" + value
+}
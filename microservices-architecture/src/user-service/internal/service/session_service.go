@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"user-service/internal/model"
+	"user-service/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+var ErrSessionNotFound = errors.New("session not found")
+
+type SessionService interface {
+	ListSessions(ctx context.Context, userID, currentSessionID string) ([]model.SessionResponse, error)
+	RevokeSession(ctx context.Context, userID, sessionID string) error
+	RevokeAllSessions(ctx context.Context, userID string) error
+}
+
+type sessionService struct {
+	repo        repository.UserRepository
+	refreshRepo repository.RefreshTokenRepository
+	revocations RevocationService
+}
+
+func NewSessionService(
+	repo repository.UserRepository,
+	refreshRepo repository.RefreshTokenRepository,
+	revocations RevocationService,
+) SessionService {
+	return &sessionService{
+		repo:        repo,
+		refreshRepo: refreshRepo,
+		revocations: revocations,
+	}
+}
+
+func (s *sessionService) ListSessions(ctx context.Context, userID, currentSessionID string) ([]model.SessionResponse, error) {
+	parsedID, err := s.parseUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens, err := s.refreshRepo.FindActiveByUserID(ctx, parsedID)
+	if err != nil {
+		return nil, errors.New("failed to retrieve sessions")
+	}
+
+	sessions := make([]model.SessionResponse, 0, len(tokens))
+	for _, token := range tokens {
+		sessions = append(sessions, token.ToSessionResponse(currentSessionID))
+	}
+
+	return sessions, nil
+}
+
+func (s *sessionService) RevokeSession(ctx context.Context, userID, sessionID string) error {
+	parsedID, err := s.parseUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	parsedSessionID, err := uuid.Parse(sessionID)
+	if err != nil {
+		return errors.New("invalid session ID")
+	}
+
+	found, err := s.refreshRepo.DeleteByIDAndUserID(ctx, parsedSessionID, parsedID)
+	if err != nil {
+		return errors.New("failed to revoke session")
+	}
+	if !found {
+		return ErrSessionNotFound
+	}
+
+	// Access tokens already issued for the session stop working too
+	if err := s.revocations.RevokeSession(ctx, parsedSessionID.String()); err != nil {
+		return errors.New("failed to revoke session")
+	}
+
+	return nil
+}
+
+func (s *sessionService) RevokeAllSessions(ctx context.Context, userID string) error {
+	parsedID, err := s.parseUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	tokens, err := s.refreshRepo.FindActiveByUserID(ctx, parsedID)
+	if err != nil {
+		return errors.New("failed to revoke sessions")
+	}
+
+	if err := s.refreshRepo.DeleteByUserID(ctx, parsedID); err != nil {
+		return errors.New("failed to revoke sessions")
+	}
+
+	for _, token := range tokens {
+		if err := s.revocations.RevokeSession(ctx, token.ID.String()); err != nil {
+			return errors.New("failed to revoke sessions")
+		}
+	}
+
+	return nil
+}
+
+func (s *sessionService) parseUser(ctx context.Context, userID string) (uuid.UUID, error) {
+	parsedID, err := uuid.Parse(userID)
+	if err != nil {
+		return uuid.Nil, errors.New("invalid user ID")
+	}
+
+	if _, err := s.repo.FindByID(ctx, parsedID); err != nil {
+		return uuid.Nil, errors.New("user not found")
+	}
+
+	return parsedID, nil
+}
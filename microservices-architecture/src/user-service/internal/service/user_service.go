@@ -1,93 +1,349 @@
 package service
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"unicode/utf8"
 	"user-service/internal/auth"
+	"user-service/internal/mailer"
+	"user-service/internal/metrics"
 	"user-service/internal/model"
 	"user-service/internal/repository"
 
 	"github.com/google/uuid"
-	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 )
 
 type LoginResponse struct {
-	AccessToken  string `json:"access_token"`
-	RefreshToken string `json:"refresh_token"`
-	ExpiresIn    int    `json:"expires_in"`
+	AccessToken  string `json:"access_token,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	ExpiresIn    int    `json:"expires_in,omitempty"`
+
+	// Set instead of the tokens above when a second factor is required
+	MFARequired bool   `json:"mfa_required,omitempty"`
+	MFAToken    string `json:"mfa_token,omitempty"`
+
+	// Set when the account's role requires MFA but none is enrolled yet
+	MFAEnrollmentRequired bool `json:"mfa_enrollment_required,omitempty"`
 }
 
+// ClientInfo describes where a request came from
+type ClientInfo struct {
+	IP        string
+	UserAgent string
+}
+
+// ErrMFACodeRequired is returned by Authenticate when the account has MFA
+// enabled and no second factor was given
+var ErrMFACodeRequired = errors.New("two-factor code is required")
+
+// ErrAccountDisabled is returned when an administrator has disabled the account
+var ErrAccountDisabled = errors.New("account is disabled")
+
 type RefreshResponse struct {
 	AccessToken string `json:"access_token"`
 	ExpiresIn   int    `json:"expires_in"`
 }
 
 type UserService interface {
-	CreateUser(username, password string) (*model.UserResponse, error)
-	Login(username, password string) (*LoginResponse, error)
-	GetAuthenticatedUser(userID string) (*model.UserResponse, error)
-	RefreshToken(refreshToken string) (*RefreshResponse, error)
-	Logout(refreshToken string) error
+	CreateUser(ctx context.Context, username, email, password string) (*model.UserResponse, error)
+	Login(ctx context.Context, username, password string, client ClientInfo) (*LoginResponse, error)
+	Authenticate(ctx context.Context, username, password, code, recoveryCode string, client ClientInfo) (*model.User, error)
+	VerifyMFALogin(ctx context.Context, mfaToken, code, recoveryCode string, client ClientInfo) (*LoginResponse, error)
+	GetAuthenticatedUser(ctx context.Context, userID string) (*model.UserResponse, error)
+	RefreshToken(ctx context.Context, refreshToken string, client ClientInfo) (*RefreshResponse, error)
+	Logout(ctx context.Context, refreshToken, accessToken string, client ClientInfo) error
+	VerifyEmail(ctx context.Context, token string) error
+	ResendVerification(ctx context.Context, userID string) error
 }
 
 type userService struct {
-	repo         repository.UserRepository
-	refreshRepo  repository.RefreshTokenRepository
+	db          *gorm.DB
+	repo        repository.UserRepository
+	refreshRepo repository.RefreshTokenRepository
+	verifyRepo  repository.EmailVerificationTokenRepository
+	loginGuard  LoginGuard
+	mfaService  MFAService
+	revocations RevocationService
+	hasher      auth.PasswordHasher
+	policy      *PasswordPolicy
+	events      AuthEventService
+	mailer      mailer.Mailer
+	verifyURL   string
 }
 
-func NewUserService(repo repository.UserRepository, refreshRepo repository.RefreshTokenRepository) UserService {
+func NewUserService(
+	db *gorm.DB,
+	repo repository.UserRepository,
+	refreshRepo repository.RefreshTokenRepository,
+	verifyRepo repository.EmailVerificationTokenRepository,
+	loginGuard LoginGuard,
+	mfaService MFAService,
+	revocations RevocationService,
+	hasher auth.PasswordHasher,
+	policy *PasswordPolicy,
+	events AuthEventService,
+	mailer mailer.Mailer,
+) UserService {
+	verifyURL := os.Getenv("EMAIL_VERIFY_URL")
+	if verifyURL == "" {
+		verifyURL = "http://localhost:3000/verify-email"
+	}
 	return &userService{
+		db:          db,
 		repo:        repo,
 		refreshRepo: refreshRepo,
+		verifyRepo:  verifyRepo,
+		loginGuard:  loginGuard,
+		mfaService:  mfaService,
+		revocations: revocations,
+		hasher:      hasher,
+		policy:      policy,
+		events:      events,
+		mailer:      mailer,
+		verifyURL:   verifyURL,
 	}
 }
 
-func (s *userService) CreateUser(username, password string) (*model.UserResponse, error) {
+func (s *userService) CreateUser(ctx context.Context, username, email, password string) (*model.UserResponse, error) {
+	normalizedEmail, err := normalizeEmail(email)
+	if err != nil {
+		return nil, err
+	}
+
 	// Check if username already exists
-	existingUser, _ := s.repo.FindByUsername(username)
+	existingUser, _ := s.repo.FindByUsername(ctx, username)
 	if existingUser != nil {
 		return nil, errors.New("username already exists")
 	}
 
+	// Check if email already exists
+	existingUser, _ = s.repo.FindByEmail(ctx, normalizedEmail)
+	if existingUser != nil {
+		return nil, errors.New("email already exists")
+	}
+
+	if err := s.policy.Validate(password, username); err != nil {
+		return nil, err
+	}
+
 	// Hash password
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	hashedPassword, err := s.hasher.Hash(password)
 	if err != nil {
 		return nil, err
 	}
 
 	user := &model.User{
 		Username: username,
-		Password: string(hashedPassword),
+		Email:    &normalizedEmail,
+		Password: hashedPassword,
 	}
 
-	if err := s.repo.Create(user); err != nil {
+	if err := s.repo.Create(ctx, user); err != nil {
 		return nil, err
 	}
 
-	return &model.UserResponse{
-		ID:       user.ID.String(),
-		Username: user.Username,
-	}, nil
+	if err := s.sendVerification(ctx, user); err != nil {
+		slog.ErrorContext(ctx, "failed to send verification email", "user_id", user.ID, "error", err)
+	}
+
+	return user.ToResponse(), nil
 }
 
-func (s *userService) Login(username, password string) (*LoginResponse, error) {
-	// Find user by username
-	user, err := s.repo.FindByUsername(username)
+func (s *userService) Login(ctx context.Context, username, password string, client ClientInfo) (*LoginResponse, error) {
+	user, err := s.checkPassword(ctx, username, password, client)
+	if err != nil {
+		return nil, err
+	}
+
+	// Accounts with MFA get a challenge token instead of a session. Their
+	// failures are only cleared once the second factor checks out, so
+	// that code guesses keep counting towards the lockout.
+	if user.IsMFAEnabled() {
+		mfaToken, err := auth.GenerateMFAChallengeToken(user)
+		if err != nil {
+			return nil, errors.New("failed to generate MFA challenge")
+		}
+		return &LoginResponse{
+			MFARequired: true,
+			MFAToken:    mfaToken,
+		}, nil
+	}
+
+	s.loginGuard.RecordSuccess(ctx, username)
+
+	response, err := s.issueSession(ctx, user, false, client)
 	if err != nil {
+		return nil, err
+	}
+	s.recordLogin(ctx, user, username, client, "", "password")
+	response.MFAEnrollmentRequired = s.mfaService.IsRequiredFor(user)
+
+	return response, nil
+}
+
+// checkPassword verifies a username and password under the login guard.
+// Failures are recorded; the caller records the success once every factor
+// has been checked.
+func (s *userService) checkPassword(ctx context.Context, username, password string, client ClientInfo) (*model.User, error) {
+	// Find user by username; a missing user is still subject to throttling
+	user, err := s.repo.FindByUsername(ctx, username)
+	if err != nil {
+		user = nil
+	}
+
+	if err := s.checkLoginGuard(ctx, user, username, client); err != nil {
+		return nil, err
+	}
+
+	if user == nil {
+		s.loginGuard.RecordFailure(ctx, nil, username, client.IP)
+		s.recordLogin(ctx, nil, username, client, "unknown_user", "")
 		return nil, errors.New("invalid username or password")
 	}
 
 	// Compare password
-	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password))
+	ok, needsRehash, err := s.hasher.Verify(password, user.Password)
 	if err != nil {
+		slog.ErrorContext(ctx, "failed to verify password hash", "user_id", user.ID, "error", err)
+	}
+	if !ok {
+		s.loginGuard.RecordFailure(ctx, user, username, client.IP)
+		s.recordLogin(ctx, user, username, client, "invalid_password", "")
 		return nil, errors.New("invalid username or password")
 	}
 
-	// Generate access token (JWT)
-	accessToken, err := auth.GenerateAccessToken(user.ID, user.Username)
+	// Only reveal that the account is disabled to someone who knows the password
+	if user.IsDisabled() {
+		s.recordLogin(ctx, user, username, client, "account_disabled", "")
+		return nil, ErrAccountDisabled
+	}
+
+	// Upgrade bcrypt hashes and outdated argon2 parameters while the
+	// plaintext is at hand
+	if needsRehash {
+		if hashedPassword, err := s.hasher.Hash(password); err != nil {
+			slog.ErrorContext(ctx, "failed to rehash password", "user_id", user.ID, "error", err)
+		} else if err := s.repo.UpdatePassword(ctx, user.ID, hashedPassword); err != nil {
+			slog.ErrorContext(ctx, "failed to store rehashed password", "user_id", user.ID, "error", err)
+		} else {
+			user.Password = hashedPassword
+		}
+	}
+
+	return user, nil
+}
+
+func (s *userService) checkLoginGuard(ctx context.Context, user *model.User, username string, client ClientInfo) error {
+	if err := s.loginGuard.Check(ctx, user, username, client.IP); err != nil {
+		var throttleErr *ThrottleError
+		if errors.As(err, &throttleErr) {
+			reason := "throttled"
+			if throttleErr.Locked {
+				reason = "locked"
+			}
+			s.recordLogin(ctx, user, username, client, reason, "")
+			return err
+		}
+		slog.ErrorContext(ctx, "failed to check login throttle", "error", err)
+		return errors.New("failed to process login")
+	}
+	return nil
+}
+
+// Authenticate checks a username, password and, for accounts with MFA, the
+// second factor without issuing a session. ErrMFACodeRequired is returned
+// when the password is correct but no second factor was given.
+func (s *userService) Authenticate(ctx context.Context, username, password, code, recoveryCode string, client ClientInfo) (*model.User, error) {
+	user, err := s.checkPassword(ctx, username, password, client)
 	if err != nil {
-		return nil, errors.New("failed to generate access token")
+		return nil, err
 	}
 
+	if user.IsMFAEnabled() {
+		if code == "" && recoveryCode == "" {
+			return nil, ErrMFACodeRequired
+		}
+		if err := s.mfaService.VerifySecondFactor(ctx, user, code, recoveryCode); err != nil {
+			s.loginGuard.RecordFailure(ctx, user, user.Username, client.IP)
+			s.recordLogin(ctx, user, username, client, "invalid_second_factor", "oauth")
+			return nil, err
+		}
+	}
+
+	s.loginGuard.RecordSuccess(ctx, username)
+	s.recordLogin(ctx, user, username, client, "", "oauth")
+
+	return user, nil
+}
+
+func (s *userService) VerifyMFALogin(ctx context.Context, mfaToken, code, recoveryCode string, client ClientInfo) (*LoginResponse, error) {
+	ip := client.IP
+
+	if mfaToken == "" || (code == "" && recoveryCode == "") {
+		return nil, errors.New("MFA token and code are required")
+	}
+
+	claims, err := auth.ValidateMFAChallengeToken(mfaToken)
+	if err != nil {
+		return nil, errors.New("invalid or expired MFA token")
+	}
+
+	userID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		return nil, errors.New("invalid or expired MFA token")
+	}
+
+	user, err := s.repo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, errors.New("invalid or expired MFA token")
+	}
+	if user.IsDisabled() {
+		return nil, ErrAccountDisabled
+	}
+
+	// Second factor guesses count towards the same lockout as passwords
+	if err := s.checkLoginGuard(ctx, user, user.Username, client); err != nil {
+		return nil, err
+	}
+
+	if err := s.mfaService.VerifySecondFactor(ctx, user, code, recoveryCode); err != nil {
+		s.loginGuard.RecordFailure(ctx, user, user.Username, ip)
+		s.recordLogin(ctx, user, user.Username, client, "invalid_second_factor", "mfa")
+		return nil, err
+	}
+
+	s.loginGuard.RecordSuccess(ctx, user.Username)
+
+	response, err := s.issueSession(ctx, user, true, client)
+	if err != nil {
+		return nil, err
+	}
+	s.recordLogin(ctx, user, user.Username, client, "", "mfa")
+
+	return response, nil
+}
+
+// recordLogin adds a login attempt to the audit log. An empty reason marks
+// a successful login.
+func (s *userService) recordLogin(ctx context.Context, user *model.User, username string, client ClientInfo, reason, detail string) {
+	event := newAuthEvent(model.AuthEventLogin, user, client, reason)
+	if user == nil {
+		event.Username = username
+	}
+	event.Detail = detail
+	s.events.Record(ctx, event)
+
+	if reason != "" {
+		metrics.LoginFailure(reason)
+	}
+}
+
+// issueSession creates an access token and a stored refresh token for user
+func (s *userService) issueSession(ctx context.Context, user *model.User, mfa bool, client ClientInfo) (*LoginResponse, error) {
 	// Generate refresh token
 	refreshTokenStr, err := auth.GenerateRefreshToken()
 	if err != nil {
@@ -99,12 +355,21 @@ func (s *userService) Login(username, password string) (*LoginResponse, error) {
 		UserID:    user.ID,
 		Token:     refreshTokenStr,
 		ExpiresAt: auth.GetRefreshTokenExpiry(),
+		MFA:       mfa,
+		UserAgent: truncate(client.UserAgent, 512),
+		IP:        client.IP,
 	}
 
-	if err := s.refreshRepo.Create(refreshToken); err != nil {
+	if err := s.refreshRepo.Create(ctx, refreshToken); err != nil {
 		return nil, errors.New("failed to store refresh token")
 	}
 
+	// Generate access token (JWT)
+	accessToken, err := auth.GenerateAccessToken(user, refreshToken)
+	if err != nil {
+		return nil, errors.New("failed to generate access token")
+	}
+
 	return &LoginResponse{
 		AccessToken:  accessToken,
 		RefreshToken: refreshTokenStr,
@@ -112,7 +377,7 @@ func (s *userService) Login(username, password string) (*LoginResponse, error) {
 	}, nil
 }
 
-func (s *userService) GetAuthenticatedUser(userID string) (*model.UserResponse, error) {
+func (s *userService) GetAuthenticatedUser(ctx context.Context, userID string) (*model.UserResponse, error) {
 	if userID == "" {
 		return nil, errors.New("user ID is required")
 	}
@@ -122,63 +387,199 @@ func (s *userService) GetAuthenticatedUser(userID string) (*model.UserResponse,
 		return nil, errors.New("invalid user ID")
 	}
 
-	user, err := s.repo.FindByID(parsedID)
+	user, err := s.repo.FindByID(ctx, parsedID)
 	if err != nil {
 		return nil, errors.New("user not found")
 	}
+	if user.IsDisabled() {
+		return nil, ErrAccountDisabled
+	}
 
-	return &model.UserResponse{
-		ID:       user.ID.String(),
-		Username: user.Username,
-	}, nil
+	return user.ToResponse(), nil
 }
 
-func (s *userService) RefreshToken(refreshTokenStr string) (*RefreshResponse, error) {
+func (s *userService) RefreshToken(ctx context.Context, refreshTokenStr string, client ClientInfo) (*RefreshResponse, error) {
 	if refreshTokenStr == "" {
 		return nil, errors.New("refresh token is required")
 	}
 
 	// Find refresh token in database
-	refreshToken, err := s.refreshRepo.FindByToken(refreshTokenStr)
+	refreshToken, err := s.refreshRepo.FindByToken(ctx, refreshTokenStr)
 	if err != nil {
+		s.events.Record(ctx, newAuthEvent(model.AuthEventRefresh, nil, client, "invalid_token"))
+		return nil, errors.New("invalid refresh token")
+	}
+
+	// Tokens issued to OAuth clients are refreshed through the token endpoint
+	if refreshToken.ClientID != "" {
+		s.events.Record(ctx, newAuthEvent(model.AuthEventRefresh, nil, client, "invalid_token"))
 		return nil, errors.New("invalid refresh token")
 	}
 
 	// Check if refresh token is expired
 	if refreshToken.IsExpired() {
 		// Delete expired token
-		s.refreshRepo.DeleteByToken(refreshTokenStr)
+		s.refreshRepo.DeleteByToken(ctx, refreshTokenStr)
+		s.events.Record(ctx, newAuthEvent(model.AuthEventRefresh, nil, client, "expired_token"))
 		return nil, errors.New("refresh token has expired")
 	}
 
 	// Get user
-	user, err := s.repo.FindByID(refreshToken.UserID)
+	user, err := s.repo.FindByID(ctx, refreshToken.UserID)
 	if err != nil {
+		s.events.Record(ctx, newAuthEvent(model.AuthEventRefresh, nil, client, "unknown_user"))
 		return nil, errors.New("user not found")
 	}
+	if user.IsDisabled() {
+		s.events.Record(ctx, newAuthEvent(model.AuthEventRefresh, user, client, "account_disabled"))
+		return nil, ErrAccountDisabled
+	}
 
 	// Generate new access token
-	accessToken, err := auth.GenerateAccessToken(user.ID, user.Username)
+	accessToken, err := auth.GenerateAccessToken(user, refreshToken)
 	if err != nil {
 		return nil, errors.New("failed to generate access token")
 	}
 
+	if err := s.refreshRepo.Touch(ctx, refreshToken.ID, client.IP, truncate(client.UserAgent, 512)); err != nil {
+		slog.ErrorContext(ctx, "failed to update session", "session_id", refreshToken.ID, "error", err)
+	}
+	s.events.Record(ctx, newAuthEvent(model.AuthEventRefresh, user, client, ""))
+
 	return &RefreshResponse{
 		AccessToken: accessToken,
 		ExpiresIn:   auth.GetAccessTokenExpirySeconds(),
 	}, nil
 }
 
-func (s *userService) Logout(refreshTokenStr string) error {
+// Logout ends the session belonging to the refresh token. Access tokens
+// issued for the session, and accessToken if given, are revoked as well.
+func (s *userService) Logout(ctx context.Context, refreshTokenStr, accessToken string, client ClientInfo) error {
 	if refreshTokenStr == "" {
 		return errors.New("refresh token is required")
 	}
 
+	event := newAuthEvent(model.AuthEventLogout, nil, client, "")
+	refreshToken, err := s.refreshRepo.FindByToken(ctx, refreshTokenStr)
+	if err == nil {
+		if err := s.revocations.RevokeSession(ctx, refreshToken.ID.String()); err != nil {
+			return errors.New("failed to logout")
+		}
+		userID := refreshToken.UserID
+		event.UserID = &userID
+	}
+
+	if accessToken != "" {
+		if claims, err := auth.ValidateAccessToken(accessToken); err == nil && claims.ExpiresAt != nil {
+			if err := s.revocations.RevokeToken(ctx, claims.ID, claims.ExpiresAt.Time); err != nil {
+				return errors.New("failed to logout")
+			}
+		}
+	}
+
 	// Delete refresh token from database
-	err := s.refreshRepo.DeleteByToken(refreshTokenStr)
+	err = s.refreshRepo.DeleteByToken(ctx, refreshTokenStr)
 	if err != nil {
 		return errors.New("failed to logout")
 	}
+	s.events.Record(ctx, event)
+
+	return nil
+}
+
+func (s *userService) VerifyEmail(ctx context.Context, token string) error {
+	if token == "" {
+		return errors.New("verification token is required")
+	}
+
+	verifyToken, err := s.verifyRepo.FindByTokenHash(ctx, auth.HashToken(token))
+	if err != nil {
+		return errors.New("invalid or expired verification token")
+	}
+
+	if verifyToken.IsUsed() || verifyToken.IsExpired() {
+		return errors.New("invalid or expired verification token")
+	}
+
+	// Used up only together with the verification, so a failed update
+	// leaves the token valid for another try
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := s.verifyRepo.WithTx(tx).MarkUsed(ctx, verifyToken.ID); err != nil {
+			return err
+		}
+		return s.repo.WithTx(tx).MarkEmailVerified(ctx, verifyToken.UserID, verifyToken.Email)
+	})
+	if err != nil {
+		return errors.New("invalid or expired verification token")
+	}
 
 	return nil
 }
+
+func (s *userService) ResendVerification(ctx context.Context, userID string) error {
+	parsedID, err := uuid.Parse(userID)
+	if err != nil {
+		return errors.New("invalid user ID")
+	}
+
+	user, err := s.repo.FindByID(ctx, parsedID)
+	if err != nil {
+		return errors.New("user not found")
+	}
+
+	if user.GetEmail() == "" {
+		return errors.New("no email address on account")
+	}
+
+	if user.IsEmailVerified() {
+		return errors.New("email is already verified")
+	}
+
+	return s.sendVerification(ctx, user)
+}
+
+// sendVerification replaces any outstanding verification token for the user
+// and mails a new one to their current address
+func (s *userService) sendVerification(ctx context.Context, user *model.User) error {
+	if err := s.verifyRepo.DeleteByUserID(ctx, user.ID); err != nil {
+		return err
+	}
+
+	token, err := auth.GenerateVerifyToken()
+	if err != nil {
+		return err
+	}
+
+	verifyToken := &model.EmailVerificationToken{
+		UserID:    user.ID,
+		Email:     user.GetEmail(),
+		TokenHash: auth.HashToken(token),
+		ExpiresAt: auth.GetVerifyTokenExpiry(),
+	}
+
+	if err := s.verifyRepo.Create(ctx, verifyToken); err != nil {
+		return err
+	}
+
+	body := fmt.Sprintf(
+		"Welcome, %s!\n\n"+
+			"Please confirm your email address by opening the link below. It expires in %d hours.\n\n"+
+			"%s?token=%s\n",
+		user.Username, int(auth.VerifyTokenExpiry.Hours()), s.verifyURL, token,
+	)
+	sendMail(ctx, s.mailer, user.GetEmail(), "Verify your email address", body)
+
+	return nil
+}
+
+// truncate cuts value to at most max bytes to fit its column
+func truncate(value string, max int) string {
+	if len(value) <= max {
+		return value
+	}
+	// Cut on a rune boundary so multi-byte characters are not split
+	for max > 0 && !utf8.RuneStart(value[max]) {
+		max--
+	}
+	return value[:max]
+}
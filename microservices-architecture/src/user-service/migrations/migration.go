@@ -1,16 +1,50 @@
 package migrations
 
 import (
-	"log"
+	"log/slog"
 	"user-service/internal/model"
 
+	"platform/logging"
+
 	"gorm.io/gorm"
 )
 
 func RunMigrations(db *gorm.DB) {
-	err := db.AutoMigrate(&model.User{}, &model.RefreshToken{})
+	err := db.AutoMigrate(
+		&model.User{},
+		&model.RefreshToken{},
+		&model.PasswordResetToken{},
+		&model.EmailVerificationToken{},
+		&model.LoginFailure{},
+		&model.AccountLockEvent{},
+		&model.RecoveryCode{},
+		&model.RevokedToken{},
+		&model.OAuthClient{},
+		&model.OAuthAuthorizationCode{},
+		&model.OAuthConsent{},
+		&model.APIKey{},
+		&model.AuthEvent{},
+	)
 	if err != nil {
-		log.Fatal("Failed to run migrations:", err)
+		logging.Fatal("Failed to run migrations", "error", err)
+	}
+
+	// The audit log is append-only; rows may only be deleted by retention
+	statements := []string{
+		`CREATE OR REPLACE FUNCTION auth_events_reject_update() RETURNS trigger AS $$
+		BEGIN
+			RAISE EXCEPTION 'auth_events is append-only';
+		END;
+		$$ LANGUAGE plpgsql`,
+		`DROP TRIGGER IF EXISTS auth_events_no_update ON auth_events`,
+		`CREATE TRIGGER auth_events_no_update BEFORE UPDATE ON auth_events
+			FOR EACH ROW EXECUTE FUNCTION auth_events_reject_update()`,
 	}
-	log.Println("Migrations completed successfully")
+	for _, statement := range statements {
+		if err := db.Exec(statement).Error; err != nil {
+			logging.Fatal("Failed to protect auth_events", "error", err)
+		}
+	}
+
+	slog.Info("Migrations completed successfully")
 }